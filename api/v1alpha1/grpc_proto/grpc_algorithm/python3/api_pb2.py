@@ -19,7 +19,7 @@ DESCRIPTOR = _descriptor.FileDescriptor(
     syntax="proto3",
     serialized_options=b"Z\024../grpc_algorithm/go",
     create_key=_descriptor._internal_create_key,
-    serialized_pb=b'\n\tapi.proto\x12\x0e\x61pi.suggestion"&\n\x08KeyValue\x12\x0b\n\x03key\x18\x01 \x01(\t\x12\r\n\x05value\x18\x02 \x01(\t"D\n\x14ParameterAssignments\x12,\n\nkey_values\x18\x01 \x03(\x0b\x32\x18.api.suggestion.KeyValue"\\\n\x0bTrialResult\x12\x37\n\x15parameter_assignments\x18\x01 \x03(\x0b\x32\x18.api.suggestion.KeyValue\x12\x14\n\x0cobject_value\x18\x02 \x01(\x02"l\n\rParameterSpec\x12\x0c\n\x04name\x18\x01 \x01(\t\x12\x35\n\x0eparameter_type\x18\x02 \x01(\x0e\x32\x1d.api.suggestion.ParameterType\x12\x16\n\x0e\x66\x65\x61sible_space\x18\x03 \x03(\t"\xbc\x02\n\x0fSamplingRequest\x12\x18\n\x10is_first_request\x18\x01 \x01(\x08\x12\x16\n\x0e\x61lgorithm_name\x18\x02 \x01(\t\x12:\n\x18\x61lgorithm_extra_settings\x18\x03 \x03(\x0b\x32\x18.api.suggestion.KeyValue\x12!\n\x19sampling_number_specified\x18\x04 \x01(\x05\x12\x19\n\x11required_sampling\x18\x06 \x01(\x05\x12\x13\n\x0bis_maximize\x18\x07 \x01(\x08\x12\x35\n\x10\x65xisting_results\x18\x08 \x03(\x0b\x32\x1b.api.suggestion.TrialResult\x12\x31\n\nparameters\x18\t \x03(\x0b\x32\x1d.api.suggestion.ParameterSpec"Q\n\x10SamplingResponse\x12=\n\x0f\x61ssignments_set\x18\x01 \x03(\x0b\x32$.api.suggestion.ParameterAssignments"\xda\x01\n\x19SamplingValidationRequest\x12\x16\n\x0e\x61lgorithm_name\x18\x01 \x01(\t\x12:\n\x18\x61lgorithm_extra_settings\x18\x02 \x03(\x0b\x32\x18.api.suggestion.KeyValue\x12!\n\x19sampling_number_specified\x18\x03 \x01(\x05\x12\x13\n\x0bis_maximize\x18\x04 \x01(\x08\x12\x31\n\nparameters\x18\x05 \x03(\x0b\x32\x1d.api.suggestion.ParameterSpec"\x1c\n\x1aSamplingValidationResponse*U\n\rParameterType\x12\x10\n\x0cUNKNOWN_TYPE\x10\x00\x12\n\n\x06\x44OUBLE\x10\x01\x12\x07\n\x03INT\x10\x02\x12\x0c\n\x08\x44ISCRETE\x10\x03\x12\x0f\n\x0b\x43\x41TEGORICAL\x10\x04\x32\xd5\x01\n\nSuggestion\x12S\n\x0eGetSuggestions\x12\x1f.api.suggestion.SamplingRequest\x1a .api.suggestion.SamplingResponse\x12r\n\x19ValidateAlgorithmSettings\x12).api.suggestion.SamplingValidationRequest\x1a*.api.suggestion.SamplingValidationResponseB\x16Z\x14../grpc_algorithm/gob\x06proto3',
+    serialized_pb=b'\n\tapi.proto\x12\x0e\x61pi.suggestion"&\n\x08KeyValue\x12\x0b\n\x03key\x18\x01 \x01(\t\x12\r\n\x05value\x18\x02 \x01(\t"D\n\x14ParameterAssignments\x12,\n\nkey_values\x18\x01 \x03(\x0b\x32\x18.api.suggestion.KeyValue"p\n\x0bTrialResult\x12\x37\n\x15parameter_assignments\x18\x01 \x03(\x0b\x32\x18.api.suggestion.KeyValue\x12\x14\n\x0cobject_value\x18\x02 \x01(\x02\x12\x12\n\ntrial_name\x18\x03 \x01(\t"k\n\rFeasibleRange\x12\x0b\n\x03min\x18\x01 \x01(\x01\x12\x0b\n\x03max\x18\x02 \x01(\x01\x12\x0c\n\x04step\x18\x03 \x01(\x01\x12\x32\n\x0c\x64istribution\x18\x04 \x01(\x0e\x32\x1c.api.suggestion.Distribution"7\n\x12ParameterCondition\x12\x11\n\tparameter\x18\x01 \x01(\t\x12\x0e\n\x06values\x18\x02 \x03(\t"\xdb\x01\n\rParameterSpec\x12\x0c\n\x04name\x18\x01 \x01(\t\x12\x35\n\x0eparameter_type\x18\x02 \x01(\x0e\x32\x1d.api.suggestion.ParameterType\x12\x16\n\x0e\x66\x65\x61sible_space\x18\x03 \x03(\t\x12\x36\n\nconditions\x18\x04 \x03(\x0b\x32".api.suggestion.ParameterCondition\x12\x35\n\x0e\x66\x65\x61sible_range\x18\x05 \x01(\x0b\x32\x1d.api.suggestion.FeasibleRange"\xa8\x03\n\x0fSamplingRequest\x12\x18\n\x10is_first_request\x18\x01 \x01(\x08\x12\x16\n\x0e\x61lgorithm_name\x18\x02 \x01(\t\x12:\n\x18\x61lgorithm_extra_settings\x18\x03 \x03(\x0b\x32\x18.api.suggestion.KeyValue\x12!\n\x19sampling_number_specified\x18\x04 \x01(\x05\x12\x19\n\x11required_sampling\x18\x06 \x01(\x05\x12\x13\n\x0bis_maximize\x18\x07 \x01(\x08\x12\x35\n\x10\x65xisting_results\x18\x08 \x03(\x0b\x32\x1b.api.suggestion.TrialResult\x12\x31\n\nparameters\x18\t \x03(\x0b\x32\x1d.api.suggestion.ParameterSpec\x12\x13\n\x0b\x63onstraints\x18\n \x03(\t\x12\x12\n\nsession_id\x18\x0b \x01(\t\x12\x41\n\x13pending_assignments\x18\x0c \x03(\x0b\x32$.api.suggestion.ParameterAssignments"d\n\x10SamplingResponse\x12=\n\x0f\x61ssignments_set\x18\x01 \x03(\x0b\x32$.api.suggestion.ParameterAssignments\x12\x11\n\texhausted\x18\x02 \x01(\x08"\xef\x01\n\x19SamplingValidationRequest\x12\x16\n\x0e\x61lgorithm_name\x18\x01 \x01(\t\x12:\n\x18\x61lgorithm_extra_settings\x18\x02 \x03(\x0b\x32\x18.api.suggestion.KeyValue\x12!\n\x19sampling_number_specified\x18\x03 \x01(\x05\x12\x13\n\x0bis_maximize\x18\x04 \x01(\x08\x12\x31\n\nparameters\x18\x05 \x03(\x0b\x32\x1d.api.suggestion.ParameterSpec\x12\x13\n\x0b\x63onstraints\x18\x06 \x03(\t"\x1c\n\x1aSamplingValidationResponse"Y\n\x14\x43reateSessionRequest\x12\x12\n\nsession_id\x18\x01 \x01(\t\x12-\n\x04spec\x18\x02 \x01(\x0b\x32\x1f.api.suggestion.SamplingRequest"\x17\n\x15\x43reateSessionResponse"W\n\x13ReportResultRequest\x12\x12\n\nsession_id\x18\x01 \x01(\t\x12,\n\x07results\x18\x02 \x03(\x0b\x32\x1b.api.suggestion.TrialResult"\x16\n\x14ReportResultResponse*c\n\rParameterType\x12\x10\n\x0cUNKNOWN_TYPE\x10\x00\x12\n\n\x06\x44OUBLE\x10\x01\x12\x07\n\x03INT\x10\x02\x12\x0c\n\x08\x44ISCRETE\x10\x03\x12\x0f\n\x0b\x43\x41TEGORICAL\x10\x04\x12\x0c\n\x08QUANTITY\x10\x05*,\n\x0c\x44istribution\x12\x0b\n\x07UNIFORM\x10\x00\x12\x0f\n\x0bLOG_UNIFORM\x10\x01\x32\x8e\x03\n\nSuggestion\x12S\n\x0eGetSuggestions\x12\x1f.api.suggestion.SamplingRequest\x1a .api.suggestion.SamplingResponse\x12r\n\x19ValidateAlgorithmSettings\x12).api.suggestion.SamplingValidationRequest\x1a*.api.suggestion.SamplingValidationResponse\x12\\\n\rCreateSession\x12$.api.suggestion.CreateSessionRequest\x1a%.api.suggestion.CreateSessionResponse\x12Y\n\x0cReportResult\x12#.api.suggestion.ReportResultRequest\x1a$.api.suggestion.ReportResultResponseB\x16Z\x14../grpc_algorithm/gob\x06proto3',
 )
 
 _PARAMETERTYPE = _descriptor.EnumDescriptor(
@@ -69,20 +69,63 @@ _PARAMETERTYPE = _descriptor.EnumDescriptor(
             type=None,
             create_key=_descriptor._internal_create_key,
         ),
+        _descriptor.EnumValueDescriptor(
+            name="QUANTITY",
+            index=5,
+            number=5,
+            serialized_options=None,
+            type=None,
+            create_key=_descriptor._internal_create_key,
+        ),
     ],
     containing_type=None,
     serialized_options=None,
-    serialized_start=996,
-    serialized_end=1081,
+    serialized_start=1671,
+    serialized_end=1770,
 )
 _sym_db.RegisterEnumDescriptor(_PARAMETERTYPE)
 
 ParameterType = enum_type_wrapper.EnumTypeWrapper(_PARAMETERTYPE)
+_DISTRIBUTION = _descriptor.EnumDescriptor(
+    name="Distribution",
+    full_name="api.suggestion.Distribution",
+    filename=None,
+    file=DESCRIPTOR,
+    create_key=_descriptor._internal_create_key,
+    values=[
+        _descriptor.EnumValueDescriptor(
+            name="UNIFORM",
+            index=0,
+            number=0,
+            serialized_options=None,
+            type=None,
+            create_key=_descriptor._internal_create_key,
+        ),
+        _descriptor.EnumValueDescriptor(
+            name="LOG_UNIFORM",
+            index=1,
+            number=1,
+            serialized_options=None,
+            type=None,
+            create_key=_descriptor._internal_create_key,
+        ),
+    ],
+    containing_type=None,
+    serialized_options=None,
+    serialized_start=1772,
+    serialized_end=1816,
+)
+_sym_db.RegisterEnumDescriptor(_DISTRIBUTION)
+
+Distribution = enum_type_wrapper.EnumTypeWrapper(_DISTRIBUTION)
 UNKNOWN_TYPE = 0
 DOUBLE = 1
 INT = 2
 DISCRETE = 3
 CATEGORICAL = 4
+QUANTITY = 5
+UNIFORM = 0
+LOG_UNIFORM = 1
 
 
 _KEYVALUE = _descriptor.Descriptor(
@@ -232,6 +275,25 @@ _TRIALRESULT = _descriptor.Descriptor(
             file=DESCRIPTOR,
             create_key=_descriptor._internal_create_key,
         ),
+        _descriptor.FieldDescriptor(
+            name="trial_name",
+            full_name="api.suggestion.TrialResult.trial_name",
+            index=2,
+            number=3,
+            type=9,
+            cpp_type=9,
+            label=1,
+            has_default_value=False,
+            default_value=b"".decode("utf-8"),
+            message_type=None,
+            enum_type=None,
+            containing_type=None,
+            is_extension=False,
+            extension_scope=None,
+            serialized_options=None,
+            file=DESCRIPTOR,
+            create_key=_descriptor._internal_create_key,
+        ),
     ],
     extensions=[],
     nested_types=[],
@@ -242,7 +304,165 @@ _TRIALRESULT = _descriptor.Descriptor(
     extension_ranges=[],
     oneofs=[],
     serialized_start=139,
-    serialized_end=231,
+    serialized_end=251,
+)
+
+
+_FEASIBLERANGE = _descriptor.Descriptor(
+    name="FeasibleRange",
+    full_name="api.suggestion.FeasibleRange",
+    filename=None,
+    file=DESCRIPTOR,
+    containing_type=None,
+    create_key=_descriptor._internal_create_key,
+    fields=[
+        _descriptor.FieldDescriptor(
+            name="min",
+            full_name="api.suggestion.FeasibleRange.min",
+            index=0,
+            number=1,
+            type=1,
+            cpp_type=5,
+            label=1,
+            has_default_value=False,
+            default_value=float(0),
+            message_type=None,
+            enum_type=None,
+            containing_type=None,
+            is_extension=False,
+            extension_scope=None,
+            serialized_options=None,
+            file=DESCRIPTOR,
+            create_key=_descriptor._internal_create_key,
+        ),
+        _descriptor.FieldDescriptor(
+            name="max",
+            full_name="api.suggestion.FeasibleRange.max",
+            index=1,
+            number=2,
+            type=1,
+            cpp_type=5,
+            label=1,
+            has_default_value=False,
+            default_value=float(0),
+            message_type=None,
+            enum_type=None,
+            containing_type=None,
+            is_extension=False,
+            extension_scope=None,
+            serialized_options=None,
+            file=DESCRIPTOR,
+            create_key=_descriptor._internal_create_key,
+        ),
+        _descriptor.FieldDescriptor(
+            name="step",
+            full_name="api.suggestion.FeasibleRange.step",
+            index=2,
+            number=3,
+            type=1,
+            cpp_type=5,
+            label=1,
+            has_default_value=False,
+            default_value=float(0),
+            message_type=None,
+            enum_type=None,
+            containing_type=None,
+            is_extension=False,
+            extension_scope=None,
+            serialized_options=None,
+            file=DESCRIPTOR,
+            create_key=_descriptor._internal_create_key,
+        ),
+        _descriptor.FieldDescriptor(
+            name="distribution",
+            full_name="api.suggestion.FeasibleRange.distribution",
+            index=3,
+            number=4,
+            type=14,
+            cpp_type=8,
+            label=1,
+            has_default_value=False,
+            default_value=0,
+            message_type=None,
+            enum_type=None,
+            containing_type=None,
+            is_extension=False,
+            extension_scope=None,
+            serialized_options=None,
+            file=DESCRIPTOR,
+            create_key=_descriptor._internal_create_key,
+        ),
+    ],
+    extensions=[],
+    nested_types=[],
+    enum_types=[],
+    serialized_options=None,
+    is_extendable=False,
+    syntax="proto3",
+    extension_ranges=[],
+    oneofs=[],
+    serialized_start=253,
+    serialized_end=360,
+)
+
+
+_PARAMETERCONDITION = _descriptor.Descriptor(
+    name="ParameterCondition",
+    full_name="api.suggestion.ParameterCondition",
+    filename=None,
+    file=DESCRIPTOR,
+    containing_type=None,
+    create_key=_descriptor._internal_create_key,
+    fields=[
+        _descriptor.FieldDescriptor(
+            name="parameter",
+            full_name="api.suggestion.ParameterCondition.parameter",
+            index=0,
+            number=1,
+            type=9,
+            cpp_type=9,
+            label=1,
+            has_default_value=False,
+            default_value=b"".decode("utf-8"),
+            message_type=None,
+            enum_type=None,
+            containing_type=None,
+            is_extension=False,
+            extension_scope=None,
+            serialized_options=None,
+            file=DESCRIPTOR,
+            create_key=_descriptor._internal_create_key,
+        ),
+        _descriptor.FieldDescriptor(
+            name="values",
+            full_name="api.suggestion.ParameterCondition.values",
+            index=1,
+            number=2,
+            type=9,
+            cpp_type=9,
+            label=3,
+            has_default_value=False,
+            default_value=[],
+            message_type=None,
+            enum_type=None,
+            containing_type=None,
+            is_extension=False,
+            extension_scope=None,
+            serialized_options=None,
+            file=DESCRIPTOR,
+            create_key=_descriptor._internal_create_key,
+        ),
+    ],
+    extensions=[],
+    nested_types=[],
+    enum_types=[],
+    serialized_options=None,
+    is_extendable=False,
+    syntax="proto3",
+    extension_ranges=[],
+    oneofs=[],
+    serialized_start=362,
+    serialized_end=417,
 )
 
 
@@ -311,6 +531,44 @@ _PARAMETERSPEC = _descriptor.Descriptor(
             file=DESCRIPTOR,
             create_key=_descriptor._internal_create_key,
         ),
+        _descriptor.FieldDescriptor(
+            name="conditions",
+            full_name="api.suggestion.ParameterSpec.conditions",
+            index=3,
+            number=4,
+            type=11,
+            cpp_type=10,
+            label=3,
+            has_default_value=False,
+            default_value=[],
+            message_type=None,
+            enum_type=None,
+            containing_type=None,
+            is_extension=False,
+            extension_scope=None,
+            serialized_options=None,
+            file=DESCRIPTOR,
+            create_key=_descriptor._internal_create_key,
+        ),
+        _descriptor.FieldDescriptor(
+            name="feasible_range",
+            full_name="api.suggestion.ParameterSpec.feasible_range",
+            index=4,
+            number=5,
+            type=11,
+            cpp_type=10,
+            label=1,
+            has_default_value=False,
+            default_value=None,
+            message_type=None,
+            enum_type=None,
+            containing_type=None,
+            is_extension=False,
+            extension_scope=None,
+            serialized_options=None,
+            file=DESCRIPTOR,
+            create_key=_descriptor._internal_create_key,
+        ),
     ],
     extensions=[],
     nested_types=[],
@@ -320,8 +578,8 @@ _PARAMETERSPEC = _descriptor.Descriptor(
     syntax="proto3",
     extension_ranges=[],
     oneofs=[],
-    serialized_start=233,
-    serialized_end=341,
+    serialized_start=420,
+    serialized_end=639,
 )
 
 
@@ -485,6 +743,63 @@ _SAMPLINGREQUEST = _descriptor.Descriptor(
             file=DESCRIPTOR,
             create_key=_descriptor._internal_create_key,
         ),
+        _descriptor.FieldDescriptor(
+            name="constraints",
+            full_name="api.suggestion.SamplingRequest.constraints",
+            index=8,
+            number=10,
+            type=9,
+            cpp_type=9,
+            label=3,
+            has_default_value=False,
+            default_value=[],
+            message_type=None,
+            enum_type=None,
+            containing_type=None,
+            is_extension=False,
+            extension_scope=None,
+            serialized_options=None,
+            file=DESCRIPTOR,
+            create_key=_descriptor._internal_create_key,
+        ),
+        _descriptor.FieldDescriptor(
+            name="session_id",
+            full_name="api.suggestion.SamplingRequest.session_id",
+            index=9,
+            number=11,
+            type=9,
+            cpp_type=9,
+            label=1,
+            has_default_value=False,
+            default_value=b"".decode("utf-8"),
+            message_type=None,
+            enum_type=None,
+            containing_type=None,
+            is_extension=False,
+            extension_scope=None,
+            serialized_options=None,
+            file=DESCRIPTOR,
+            create_key=_descriptor._internal_create_key,
+        ),
+        _descriptor.FieldDescriptor(
+            name="pending_assignments",
+            full_name="api.suggestion.SamplingRequest.pending_assignments",
+            index=10,
+            number=12,
+            type=11,
+            cpp_type=10,
+            label=3,
+            has_default_value=False,
+            default_value=[],
+            message_type=None,
+            enum_type=None,
+            containing_type=None,
+            is_extension=False,
+            extension_scope=None,
+            serialized_options=None,
+            file=DESCRIPTOR,
+            create_key=_descriptor._internal_create_key,
+        ),
     ],
     extensions=[],
     nested_types=[],
@@ -494,8 +809,8 @@ _SAMPLINGREQUEST = _descriptor.Descriptor(
     syntax="proto3",
     extension_ranges=[],
     oneofs=[],
-    serialized_start=344,
-    serialized_end=660,
+    serialized_start=642,
+    serialized_end=1066,
 )
 
 
@@ -526,6 +841,25 @@ _SAMPLINGRESPONSE = _descriptor.Descriptor(
             file=DESCRIPTOR,
             create_key=_descriptor._internal_create_key,
         ),
+        _descriptor.FieldDescriptor(
+            name="exhausted",
+            full_name="api.suggestion.SamplingResponse.exhausted",
+            index=1,
+            number=2,
+            type=8,
+            cpp_type=7,
+            label=1,
+            has_default_value=False,
+            default_value=False,
+            message_type=None,
+            enum_type=None,
+            containing_type=None,
+            is_extension=False,
+            extension_scope=None,
+            serialized_options=None,
+            file=DESCRIPTOR,
+            create_key=_descriptor._internal_create_key,
+        ),
     ],
     extensions=[],
     nested_types=[],
@@ -535,8 +869,8 @@ _SAMPLINGRESPONSE = _descriptor.Descriptor(
     syntax="proto3",
     extension_ranges=[],
     oneofs=[],
-    serialized_start=662,
-    serialized_end=743,
+    serialized_start=1068,
+    serialized_end=1168,
 )
 
 
@@ -643,6 +977,25 @@ _SAMPLINGVALIDATIONREQUEST = _descriptor.Descriptor(
             file=DESCRIPTOR,
             create_key=_descriptor._internal_create_key,
         ),
+        _descriptor.FieldDescriptor(
+            name="constraints",
+            full_name="api.suggestion.SamplingValidationRequest.constraints",
+            index=5,
+            number=6,
+            type=9,
+            cpp_type=9,
+            label=3,
+            has_default_value=False,
+            default_value=[],
+            message_type=None,
+            enum_type=None,
+            containing_type=None,
+            is_extension=False,
+            extension_scope=None,
+            serialized_options=None,
+            file=DESCRIPTOR,
+            create_key=_descriptor._internal_create_key,
+        ),
     ],
     extensions=[],
     nested_types=[],
@@ -652,8 +1005,8 @@ _SAMPLINGVALIDATIONREQUEST = _descriptor.Descriptor(
     syntax="proto3",
     extension_ranges=[],
     oneofs=[],
-    serialized_start=746,
-    serialized_end=964,
+    serialized_start=1171,
+    serialized_end=1410,
 )
 
 
@@ -673,24 +1026,196 @@ _SAMPLINGVALIDATIONRESPONSE = _descriptor.Descriptor(
     syntax="proto3",
     extension_ranges=[],
     oneofs=[],
-    serialized_start=966,
-    serialized_end=994,
+    serialized_start=1412,
+    serialized_end=1440,
+)
+
+
+_CREATESESSIONREQUEST = _descriptor.Descriptor(
+    name="CreateSessionRequest",
+    full_name="api.suggestion.CreateSessionRequest",
+    filename=None,
+    file=DESCRIPTOR,
+    containing_type=None,
+    create_key=_descriptor._internal_create_key,
+    fields=[
+        _descriptor.FieldDescriptor(
+            name="session_id",
+            full_name="api.suggestion.CreateSessionRequest.session_id",
+            index=0,
+            number=1,
+            type=9,
+            cpp_type=9,
+            label=1,
+            has_default_value=False,
+            default_value=b"".decode("utf-8"),
+            message_type=None,
+            enum_type=None,
+            containing_type=None,
+            is_extension=False,
+            extension_scope=None,
+            serialized_options=None,
+            file=DESCRIPTOR,
+            create_key=_descriptor._internal_create_key,
+        ),
+        _descriptor.FieldDescriptor(
+            name="spec",
+            full_name="api.suggestion.CreateSessionRequest.spec",
+            index=1,
+            number=2,
+            type=11,
+            cpp_type=10,
+            label=1,
+            has_default_value=False,
+            default_value=None,
+            message_type=None,
+            enum_type=None,
+            containing_type=None,
+            is_extension=False,
+            extension_scope=None,
+            serialized_options=None,
+            file=DESCRIPTOR,
+            create_key=_descriptor._internal_create_key,
+        ),
+    ],
+    extensions=[],
+    nested_types=[],
+    enum_types=[],
+    serialized_options=None,
+    is_extendable=False,
+    syntax="proto3",
+    extension_ranges=[],
+    oneofs=[],
+    serialized_start=1442,
+    serialized_end=1531,
+)
+
+
+_CREATESESSIONRESPONSE = _descriptor.Descriptor(
+    name="CreateSessionResponse",
+    full_name="api.suggestion.CreateSessionResponse",
+    filename=None,
+    file=DESCRIPTOR,
+    containing_type=None,
+    create_key=_descriptor._internal_create_key,
+    fields=[],
+    extensions=[],
+    nested_types=[],
+    enum_types=[],
+    serialized_options=None,
+    is_extendable=False,
+    syntax="proto3",
+    extension_ranges=[],
+    oneofs=[],
+    serialized_start=1533,
+    serialized_end=1556,
+)
+
+
+_REPORTRESULTREQUEST = _descriptor.Descriptor(
+    name="ReportResultRequest",
+    full_name="api.suggestion.ReportResultRequest",
+    filename=None,
+    file=DESCRIPTOR,
+    containing_type=None,
+    create_key=_descriptor._internal_create_key,
+    fields=[
+        _descriptor.FieldDescriptor(
+            name="session_id",
+            full_name="api.suggestion.ReportResultRequest.session_id",
+            index=0,
+            number=1,
+            type=9,
+            cpp_type=9,
+            label=1,
+            has_default_value=False,
+            default_value=b"".decode("utf-8"),
+            message_type=None,
+            enum_type=None,
+            containing_type=None,
+            is_extension=False,
+            extension_scope=None,
+            serialized_options=None,
+            file=DESCRIPTOR,
+            create_key=_descriptor._internal_create_key,
+        ),
+        _descriptor.FieldDescriptor(
+            name="results",
+            full_name="api.suggestion.ReportResultRequest.results",
+            index=1,
+            number=2,
+            type=11,
+            cpp_type=10,
+            label=3,
+            has_default_value=False,
+            default_value=[],
+            message_type=None,
+            enum_type=None,
+            containing_type=None,
+            is_extension=False,
+            extension_scope=None,
+            serialized_options=None,
+            file=DESCRIPTOR,
+            create_key=_descriptor._internal_create_key,
+        ),
+    ],
+    extensions=[],
+    nested_types=[],
+    enum_types=[],
+    serialized_options=None,
+    is_extendable=False,
+    syntax="proto3",
+    extension_ranges=[],
+    oneofs=[],
+    serialized_start=1558,
+    serialized_end=1645,
+)
+
+
+_REPORTRESULTRESPONSE = _descriptor.Descriptor(
+    name="ReportResultResponse",
+    full_name="api.suggestion.ReportResultResponse",
+    filename=None,
+    file=DESCRIPTOR,
+    containing_type=None,
+    create_key=_descriptor._internal_create_key,
+    fields=[],
+    extensions=[],
+    nested_types=[],
+    enum_types=[],
+    serialized_options=None,
+    is_extendable=False,
+    syntax="proto3",
+    extension_ranges=[],
+    oneofs=[],
+    serialized_start=1647,
+    serialized_end=1669,
 )
 
 _PARAMETERASSIGNMENTS.fields_by_name["key_values"].message_type = _KEYVALUE
 _TRIALRESULT.fields_by_name["parameter_assignments"].message_type = _KEYVALUE
+_FEASIBLERANGE.fields_by_name["distribution"].enum_type = _DISTRIBUTION
 _PARAMETERSPEC.fields_by_name["parameter_type"].enum_type = _PARAMETERTYPE
+_PARAMETERSPEC.fields_by_name["conditions"].message_type = _PARAMETERCONDITION
+_PARAMETERSPEC.fields_by_name["feasible_range"].message_type = _FEASIBLERANGE
 _SAMPLINGREQUEST.fields_by_name["algorithm_extra_settings"].message_type = _KEYVALUE
 _SAMPLINGREQUEST.fields_by_name["existing_results"].message_type = _TRIALRESULT
 _SAMPLINGREQUEST.fields_by_name["parameters"].message_type = _PARAMETERSPEC
+_SAMPLINGREQUEST.fields_by_name[
+    "pending_assignments"
+].message_type = _PARAMETERASSIGNMENTS
 _SAMPLINGRESPONSE.fields_by_name["assignments_set"].message_type = _PARAMETERASSIGNMENTS
 _SAMPLINGVALIDATIONREQUEST.fields_by_name[
     "algorithm_extra_settings"
 ].message_type = _KEYVALUE
 _SAMPLINGVALIDATIONREQUEST.fields_by_name["parameters"].message_type = _PARAMETERSPEC
+_CREATESESSIONREQUEST.fields_by_name["spec"].message_type = _SAMPLINGREQUEST
+_REPORTRESULTREQUEST.fields_by_name["results"].message_type = _TRIALRESULT
 DESCRIPTOR.message_types_by_name["KeyValue"] = _KEYVALUE
 DESCRIPTOR.message_types_by_name["ParameterAssignments"] = _PARAMETERASSIGNMENTS
 DESCRIPTOR.message_types_by_name["TrialResult"] = _TRIALRESULT
+DESCRIPTOR.message_types_by_name["FeasibleRange"] = _FEASIBLERANGE
+DESCRIPTOR.message_types_by_name["ParameterCondition"] = _PARAMETERCONDITION
 DESCRIPTOR.message_types_by_name["ParameterSpec"] = _PARAMETERSPEC
 DESCRIPTOR.message_types_by_name["SamplingRequest"] = _SAMPLINGREQUEST
 DESCRIPTOR.message_types_by_name["SamplingResponse"] = _SAMPLINGRESPONSE
@@ -700,7 +1225,12 @@ DESCRIPTOR.message_types_by_name[
 DESCRIPTOR.message_types_by_name[
     "SamplingValidationResponse"
 ] = _SAMPLINGVALIDATIONRESPONSE
+DESCRIPTOR.message_types_by_name["CreateSessionRequest"] = _CREATESESSIONREQUEST
+DESCRIPTOR.message_types_by_name["CreateSessionResponse"] = _CREATESESSIONRESPONSE
+DESCRIPTOR.message_types_by_name["ReportResultRequest"] = _REPORTRESULTREQUEST
+DESCRIPTOR.message_types_by_name["ReportResultResponse"] = _REPORTRESULTRESPONSE
 DESCRIPTOR.enum_types_by_name["ParameterType"] = _PARAMETERTYPE
+DESCRIPTOR.enum_types_by_name["Distribution"] = _DISTRIBUTION
 _sym_db.RegisterFileDescriptor(DESCRIPTOR)
 
 KeyValue = _reflection.GeneratedProtocolMessageType(
@@ -736,6 +1266,28 @@ TrialResult = _reflection.GeneratedProtocolMessageType(
 )
 _sym_db.RegisterMessage(TrialResult)
 
+FeasibleRange = _reflection.GeneratedProtocolMessageType(
+    "FeasibleRange",
+    (_message.Message,),
+    {
+        "DESCRIPTOR": _FEASIBLERANGE,
+        "__module__": "api_pb2"
+        # @@protoc_insertion_point(class_scope:api.suggestion.FeasibleRange)
+    },
+)
+_sym_db.RegisterMessage(FeasibleRange)
+
+ParameterCondition = _reflection.GeneratedProtocolMessageType(
+    "ParameterCondition",
+    (_message.Message,),
+    {
+        "DESCRIPTOR": _PARAMETERCONDITION,
+        "__module__": "api_pb2"
+        # @@protoc_insertion_point(class_scope:api.suggestion.ParameterCondition)
+    },
+)
+_sym_db.RegisterMessage(ParameterCondition)
+
 ParameterSpec = _reflection.GeneratedProtocolMessageType(
     "ParameterSpec",
     (_message.Message,),
@@ -791,6 +1343,50 @@ SamplingValidationResponse = _reflection.GeneratedProtocolMessageType(
 )
 _sym_db.RegisterMessage(SamplingValidationResponse)
 
+CreateSessionRequest = _reflection.GeneratedProtocolMessageType(
+    "CreateSessionRequest",
+    (_message.Message,),
+    {
+        "DESCRIPTOR": _CREATESESSIONREQUEST,
+        "__module__": "api_pb2"
+        # @@protoc_insertion_point(class_scope:api.suggestion.CreateSessionRequest)
+    },
+)
+_sym_db.RegisterMessage(CreateSessionRequest)
+
+CreateSessionResponse = _reflection.GeneratedProtocolMessageType(
+    "CreateSessionResponse",
+    (_message.Message,),
+    {
+        "DESCRIPTOR": _CREATESESSIONRESPONSE,
+        "__module__": "api_pb2"
+        # @@protoc_insertion_point(class_scope:api.suggestion.CreateSessionResponse)
+    },
+)
+_sym_db.RegisterMessage(CreateSessionResponse)
+
+ReportResultRequest = _reflection.GeneratedProtocolMessageType(
+    "ReportResultRequest",
+    (_message.Message,),
+    {
+        "DESCRIPTOR": _REPORTRESULTREQUEST,
+        "__module__": "api_pb2"
+        # @@protoc_insertion_point(class_scope:api.suggestion.ReportResultRequest)
+    },
+)
+_sym_db.RegisterMessage(ReportResultRequest)
+
+ReportResultResponse = _reflection.GeneratedProtocolMessageType(
+    "ReportResultResponse",
+    (_message.Message,),
+    {
+        "DESCRIPTOR": _REPORTRESULTRESPONSE,
+        "__module__": "api_pb2"
+        # @@protoc_insertion_point(class_scope:api.suggestion.ReportResultResponse)
+    },
+)
+_sym_db.RegisterMessage(ReportResultResponse)
+
 
 DESCRIPTOR._options = None
 
@@ -801,8 +1397,8 @@ _SUGGESTION = _descriptor.ServiceDescriptor(
     index=0,
     serialized_options=None,
     create_key=_descriptor._internal_create_key,
-    serialized_start=1084,
-    serialized_end=1297,
+    serialized_start=1819,
+    serialized_end=2217,
     methods=[
         _descriptor.MethodDescriptor(
             name="GetSuggestions",
@@ -824,6 +1420,26 @@ _SUGGESTION = _descriptor.ServiceDescriptor(
             serialized_options=None,
             create_key=_descriptor._internal_create_key,
         ),
+        _descriptor.MethodDescriptor(
+            name="CreateSession",
+            full_name="api.suggestion.Suggestion.CreateSession",
+            index=2,
+            containing_service=None,
+            input_type=_CREATESESSIONREQUEST,
+            output_type=_CREATESESSIONRESPONSE,
+            serialized_options=None,
+            create_key=_descriptor._internal_create_key,
+        ),
+        _descriptor.MethodDescriptor(
+            name="ReportResult",
+            full_name="api.suggestion.Suggestion.ReportResult",
+            index=3,
+            containing_service=None,
+            input_type=_REPORTRESULTREQUEST,
+            output_type=_REPORTRESULTRESPONSE,
+            serialized_options=None,
+            create_key=_descriptor._internal_create_key,
+        ),
     ],
 )
 _sym_db.RegisterServiceDescriptor(_SUGGESTION)
@@ -23,6 +23,16 @@ class SuggestionStub(object):
             request_serializer=api__pb2.SamplingValidationRequest.SerializeToString,
             response_deserializer=api__pb2.SamplingValidationResponse.FromString,
         )
+        self.CreateSession = channel.unary_unary(
+            "/api.suggestion.Suggestion/CreateSession",
+            request_serializer=api__pb2.CreateSessionRequest.SerializeToString,
+            response_deserializer=api__pb2.CreateSessionResponse.FromString,
+        )
+        self.ReportResult = channel.unary_unary(
+            "/api.suggestion.Suggestion/ReportResult",
+            request_serializer=api__pb2.ReportResultRequest.SerializeToString,
+            response_deserializer=api__pb2.ReportResultResponse.FromString,
+        )
 
 
 class SuggestionServicer(object):
@@ -40,6 +50,21 @@ class SuggestionServicer(object):
         context.set_details("Method not implemented!")
         raise NotImplementedError("Method not implemented!")
 
+    def CreateSession(self, request, context):
+        """Sessions keep the spec and the results of an experiment on the algorithm server, so that the requests of the
+        session carry only the results not reported yet. Servers without sessions return UNIMPLEMENTED, and requests of
+        unknown sessions fail with NOT_FOUND.
+        """
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details("Method not implemented!")
+        raise NotImplementedError("Method not implemented!")
+
+    def ReportResult(self, request, context):
+        """Missing associated documentation comment in .proto file."""
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details("Method not implemented!")
+        raise NotImplementedError("Method not implemented!")
+
 
 def add_SuggestionServicer_to_server(servicer, server):
     rpc_method_handlers = {
@@ -53,6 +78,16 @@ def add_SuggestionServicer_to_server(servicer, server):
             request_deserializer=api__pb2.SamplingValidationRequest.FromString,
             response_serializer=api__pb2.SamplingValidationResponse.SerializeToString,
         ),
+        "CreateSession": grpc.unary_unary_rpc_method_handler(
+            servicer.CreateSession,
+            request_deserializer=api__pb2.CreateSessionRequest.FromString,
+            response_serializer=api__pb2.CreateSessionResponse.SerializeToString,
+        ),
+        "ReportResult": grpc.unary_unary_rpc_method_handler(
+            servicer.ReportResult,
+            request_deserializer=api__pb2.ReportResultRequest.FromString,
+            response_serializer=api__pb2.ReportResultResponse.SerializeToString,
+        ),
     }
     generic_handler = grpc.method_handlers_generic_handler(
         "api.suggestion.Suggestion", rpc_method_handlers
@@ -121,3 +156,61 @@ class Suggestion(object):
             timeout,
             metadata,
         )
+
+    @staticmethod
+    def CreateSession(
+        request,
+        target,
+        options=(),
+        channel_credentials=None,
+        call_credentials=None,
+        insecure=False,
+        compression=None,
+        wait_for_ready=None,
+        timeout=None,
+        metadata=None,
+    ):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            "/api.suggestion.Suggestion/CreateSession",
+            api__pb2.CreateSessionRequest.SerializeToString,
+            api__pb2.CreateSessionResponse.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+        )
+
+    @staticmethod
+    def ReportResult(
+        request,
+        target,
+        options=(),
+        channel_credentials=None,
+        call_credentials=None,
+        insecure=False,
+        compression=None,
+        wait_for_ready=None,
+        timeout=None,
+        metadata=None,
+    ):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            "/api.suggestion.Suggestion/ReportResult",
+            api__pb2.ReportResultRequest.SerializeToString,
+            api__pb2.ReportResultResponse.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+        )
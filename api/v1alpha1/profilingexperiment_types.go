@@ -20,6 +20,8 @@ import (
 	"k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // ProfilingExperimentSpec defines the desired state of ProfilingExperiment
@@ -50,6 +52,62 @@ type ProfilingExperimentSpec struct {
 
 	// The maximum time in seconds for a deployment to make progress before it is considered to be failed.
 	ServiceProgressDeadline *int32 `json:"serviceProgressDeadline,omitempty"`
+
+	// The workload running the service under test, a Deployment of ServicePodTemplate if not set.
+	ServiceWorkload *ServiceWorkloadSpec `json:"serviceWorkload,omitempty"`
+
+	// The k8s service exposing the service under test to the client, with port 8500 named profile-service if not set.
+	Service *ServiceSpec `json:"service,omitempty"`
+
+	// Prices of the resources allocated to service pods, to observe the allocated cost metrics of trials.
+	CostModel *CostModel `json:"costModel,omitempty"`
+
+	// How the optimal parameters are applied to the production workload once the experiment succeeds.
+	ApplyPolicy *ApplyPolicy `json:"applyPolicy,omitempty"`
+
+	// Constraints across parameters which every sampled configuration must satisfy, e.g., cpu * replicas <= 32.
+	// A constraint compares two arithmetic expressions (+, -, *, /) of numbers and parameter names with
+	// <, <=, >, >=, == or !=; names with other characters are quoted, e.g., 'limits.nvidia.com/gpu' <= 4.
+	// Constraints referring to an inactive conditional parameter are ignored.
+	Constraints []string `json:"constraints,omitempty"`
+
+	// Successive halving over a fidelity of the client, e.g., the test duration, if set. MaxNumTrials configurations
+	// are sampled and tested at the lowest fidelity, then the best fraction of them is promoted to the next, higher
+	// fidelity, rung by rung up to the maximum one.
+	MultiFidelity *MultiFidelitySpec `json:"multiFidelity,omitempty"`
+
+	// No trials are created while suspend is true. Resuming continues with the trials and samplings so far.
+	Suspend *bool `json:"suspend,omitempty"`
+
+	// What happens to the pending and running trials on suspension, one of Wait, Kill. Defaults to Wait.
+	SuspendPolicy SuspendPolicy `json:"suspendPolicy,omitempty"`
+}
+
+// SuspendPolicy defines what happens to the active trials of a suspended experiment
+type SuspendPolicy string
+
+const (
+	// Pending and running trials complete while the experiment is suspended.
+	SuspendPolicyWait SuspendPolicy = "Wait"
+
+	// Pending and running trials are killed, and their configurations may be sampled again on resumption.
+	SuspendPolicyKill SuspendPolicy = "Kill"
+)
+
+// MultiFidelitySpec defines the rungs of successive halving
+type MultiFidelitySpec struct {
+	// Name of the fidelity, e.g., DURATION, passed with its value to the client in the env of this name and FIDELITY.
+	Name string `json:"name"`
+
+	// The fidelity of the first rung, e.g., a test duration in seconds or a request count.
+	Min int32 `json:"min"`
+
+	// The fidelity of the last rung.
+	Max int32 `json:"max"`
+
+	// The fidelity is multiplied by the reduction factor rung by rung, and 1/factor of the trials of a rung are
+	// promoted to the next one. Defaults to 3.
+	ReductionFactor *int32 `json:"reductionFactor,omitempty"`
 }
 
 type ProfilingExperimentStatus struct {
@@ -100,6 +158,240 @@ type ProfilingExperimentStatus struct {
 
 	// How many trials have failed.
 	TrialsFailed int32 `json:"trialsFailed,omitempty"`
+
+	// The state of applying the optimal parameters to the target workload of the ApplyPolicy.
+	ApplyStatus *ApplyStatus `json:"applyStatus,omitempty"`
+
+	// Samplings received from the algorithm server whose trials are not created yet, which are created before new
+	// samplings are requested.
+	PendingSamplings []TrialAssignment `json:"pendingSamplings,omitempty"`
+
+	// The number of samplings received from the algorithm server, indexing the names of their trials.
+	SamplingCount int32 `json:"samplingCount,omitempty"`
+
+	// The rungs of successive halving, from the lowest fidelity, if spec.multiFidelity is set.
+	Rungs []RungStatus `json:"rungs,omitempty"`
+
+	// The bookkeeping of the assignments of the search space tried by the trials.
+	SearchSpace *SearchSpaceStatus `json:"searchSpace,omitempty"`
+
+	// The generation of the spec observed by the controller, which restarts the experiment on changes.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// SearchSpaceStatus is the state of the exploration of the search space. Assignments are counted once however many
+// trials tried them, and the assignments of killed trials only are sampled again.
+type SearchSpaceStatus struct {
+	// The generation of the experiment the size is computed for.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// The number of distinct feasible assignments, 0 if the search space is unbounded, e.g., with continuous parameters.
+	Size int32 `json:"size,omitempty"`
+
+	// The number of distinct assignments tried by pending, running, succeeded or failed trials.
+	Tried int32 `json:"tried,omitempty"`
+
+	// The number of distinct assignments tried by succeeded trials.
+	Succeeded int32 `json:"succeeded,omitempty"`
+
+	// The number of distinct assignments tried by failed trials only.
+	Failed int32 `json:"failed,omitempty"`
+
+	// Whether the sampler has reported it has no more assignments to suggest.
+	Exhausted bool `json:"exhausted,omitempty"`
+}
+
+// RungStatus is the state of a rung of successive halving
+type RungStatus struct {
+	// Index of the rung, 0 for the lowest fidelity.
+	Rung int32 `json:"rung"`
+
+	// The fidelity of the trials of the rung.
+	Fidelity int32 `json:"fidelity"`
+
+	// The number of trials of the rung.
+	Trials int32 `json:"trials,omitempty"`
+
+	// The number of completed trials of the rung.
+	Completed int32 `json:"completed,omitempty"`
+
+	// The trials of the rung whose configurations are promoted to the next rung, decided once all trials of the rung
+	// are completed.
+	Promoted []string `json:"promoted,omitempty"`
+}
+
+// ServiceWorkloadKind is the provider of the workload running the service under test
+type ServiceWorkloadKind string
+
+const (
+	ServiceWorkloadDeployment   ServiceWorkloadKind = "Deployment"
+	ServiceWorkloadStatefulSet  ServiceWorkloadKind = "StatefulSet"
+	ServiceWorkloadUnstructured ServiceWorkloadKind = "Unstructured"
+)
+
+// ServiceWorkloadSpec describes the workload running the service under test
+type ServiceWorkloadSpec struct {
+	// Provider of the workload: Deployment, StatefulSet or Unstructured. Defaults to Deployment.
+	Kind ServiceWorkloadKind `json:"kind,omitempty"`
+
+	// Object template of an Unstructured workload, e.g., a KServe InferenceService or a Knative Service.
+	// Its name and namespace are set for each trial.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Template *runtime.RawExtension `json:"template,omitempty"`
+
+	// Dot-separated path of the pod template in the Unstructured workload. Defaults to spec.template.
+	// It is filled with ServicePodTemplate if absent in the object template, then trial parameters are applied to it.
+	PodTemplatePath string `json:"podTemplatePath,omitempty"`
+
+	// Dot-separated path of a pod spec inlined in the Unstructured workload, e.g., spec.predictor of InferenceService,
+	// used instead of PodTemplatePath. Pods are not labeled then, so the client should test the endpoint of the workload.
+	PodSpecPath string `json:"podSpecPath,omitempty"`
+
+	// Dot-separated path of the replica count in the Unstructured workload, e.g., spec.replicas,
+	// set if replicas are tuned. Replicas are not set for Unstructured workloads without it.
+	ReplicasPath string `json:"replicasPath,omitempty"`
+
+	// Rules that all have to be matched for the Unstructured workload to be ready. Defaults to the Ready condition being True.
+	ReadinessRules []WorkloadStatusRule `json:"readinessRules,omitempty"`
+
+	// Rules that fail the trial if any of them is matched by the Unstructured workload.
+	FailureRules []WorkloadStatusRule `json:"failureRules,omitempty"`
+}
+
+// ServiceSpec defines the k8s service exposing the service under test
+type ServiceSpec struct {
+	// Ports of the service, the first one of which is the endpoint handed to the client in ServiceName.
+	Ports []ServicePort `json:"ports,omitempty"`
+
+	// Headless makes the service resolve to the addresses of service pods, instead of a cluster IP.
+	Headless bool `json:"headless,omitempty"`
+}
+
+// ServicePort is a port of the service under test
+type ServicePort struct {
+	// Name of the port, exposed to the client in the env SERVICE_PORT_<NAME>.
+	Name string `json:"name"`
+
+	// Port number of the service.
+	Port int32 `json:"port"`
+
+	// Transport protocol of the port: TCP, UDP or SCTP. Defaults to TCP.
+	Protocol corev1.Protocol `json:"protocol,omitempty"`
+
+	// Application protocol of the port, e.g., http or grpc, exposed to the client only.
+	AppProtocol string `json:"appProtocol,omitempty"`
+
+	// Number or name of the port on service pods. Defaults to the port number.
+	TargetPort intstr.IntOrString `json:"targetPort,omitempty"`
+}
+
+// CostModel prices the resources used by and allocated to the service pods of a trial
+type CostModel struct {
+	// Price of a unit of each resource used or requested by a service pod, e.g., cpu: "0.048".
+	// Units are cores for cpu, GiB for memory and storage, and devices for other resources.
+	ResourcePrices map[corev1.ResourceName]string `json:"resourcePrices,omitempty"`
+
+	// Name of the throughput metric reported by the client, used to observe the costs per throughput. Defaults to qps.
+	ThroughputMetricName string `json:"throughputMetricName,omitempty"`
+}
+
+// WorkloadStatusRule matches either a condition or a field of an unstructured workload
+type WorkloadStatusRule struct {
+	// Type of the condition in status.conditions to match.
+	ConditionType string `json:"conditionType,omitempty"`
+
+	// Status of the condition to match, defaults to True.
+	ConditionStatus string `json:"conditionStatus,omitempty"`
+
+	// Dot-separated path of the field to match, e.g., status.readyReplicas, used if ConditionType is empty.
+	FieldPath string `json:"fieldPath,omitempty"`
+
+	// Value of the field to match, compared as a string.
+	Value string `json:"value,omitempty"`
+}
+
+// ApplyMode defines when the optimal parameters are applied to the target workload
+type ApplyMode string
+
+const (
+	// The optimal parameters are applied by users on demand, e.g., with morphlingctl apply-best.
+	ApplyModeManual ApplyMode = "Manual"
+
+	// The optimal parameters are applied once the experiment succeeds.
+	ApplyModeOnSuccess ApplyMode = "OnSuccess"
+
+	// The patch of the target workload is rendered into status once the experiment succeeds, without applying it,
+	// so that a GitOps pipeline could raise a pull request with it.
+	ApplyModePullRequest ApplyMode = "PullRequest"
+)
+
+// ApplyPolicy describes the production workload to be patched with the optimal parameters
+type ApplyPolicy struct {
+	// The workload to patch.
+	TargetRef ApplyTargetReference `json:"targetRef"`
+
+	// When to apply, one of Manual, OnSuccess, PullRequest. Defaults to Manual.
+	Mode ApplyMode `json:"mode,omitempty"`
+
+	// The container to patch with all the parameters. If empty, each parameter is applied to the containers it was
+	// profiled in, which must be named the same in the pod template of the workload.
+	Container string `json:"container,omitempty"`
+}
+
+// ApplyTargetReference refers to a workload of any kind embedding a pod template, e.g., Deployment or StatefulSet
+type ApplyTargetReference struct {
+	// API version of the workload, e.g., apps/v1.
+	APIVersion string `json:"apiVersion"`
+
+	// Kind of the workload, e.g., Deployment.
+	Kind string `json:"kind"`
+
+	// Name of the workload.
+	Name string `json:"name"`
+
+	// Namespace of the workload, the same as the experiment if empty.
+	Namespace string `json:"namespace,omitempty"`
+
+	// Dot-separated path of the pod template in the workload. Defaults to spec.template, as of Deployment and StatefulSet.
+	PodTemplatePath string `json:"podTemplatePath,omitempty"`
+}
+
+// ApplyPhase is the state of applying the optimal parameters
+type ApplyPhase string
+
+const (
+	// Waiting for users to apply the patch.
+	ApplyPending ApplyPhase = "Pending"
+
+	// The patch is rendered for a pull request.
+	ApplyProposed ApplyPhase = "Proposed"
+
+	// The patch is applied to the target workload.
+	ApplyApplied ApplyPhase = "Applied"
+
+	// The patch could not be rendered or applied.
+	ApplyFailed ApplyPhase = "Failed"
+
+	// The experiment did not succeed, nothing to apply.
+	ApplySkipped ApplyPhase = "Skipped"
+)
+
+// ApplyStatus is the state of applying the optimal parameters to the target workload
+type ApplyStatus struct {
+	// Phase of applying.
+	Phase ApplyPhase `json:"phase,omitempty"`
+
+	// A human readable message indicating details about the phase.
+	Message string `json:"message,omitempty"`
+
+	// The parameters applied or to be applied.
+	TunableParameters []ParameterAssignment `json:"tunableParameters,omitempty"`
+
+	// The JSON merge patch of the target workload.
+	Patch string `json:"patch,omitempty"`
+
+	// The last time the phase was updated.
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
 }
 
 type CollectorKind string
@@ -151,12 +443,27 @@ const (
 	ParameterTypeInt         ParameterType = "int"
 	ParameterTypeDiscrete    ParameterType = "discrete"
 	ParameterTypeCategorical ParameterType = "categorical"
+
+	// Kubernetes quantities, e.g., 500m or 2Gi, with min, max and step being quantities too.
+	ParameterTypeQuantity ParameterType = "quantity"
 )
 
 // ParameterCategory id the category of parameters, high-level parameter divisions, including env, args, resource
 type ParameterCategory struct {
 	Category   Category        `json:"category,omitempty"`
 	Parameters []ParameterSpec `json:"parameters,omitempty"`
+
+	// Containers of service pods the parameters of the category are applied to, all containers if not set.
+	ContainerSelector *ContainerSelector `json:"containerSelector,omitempty"`
+}
+
+// ContainerSelector selects the containers of service pods by names
+type ContainerSelector struct {
+	// Names of the containers.
+	Containers []string `json:"containers,omitempty"`
+
+	// Names of the init containers.
+	InitContainers []string `json:"initContainers,omitempty"`
 }
 
 // FeasibleSpace defines the range of the hyper-parameters to be tuned
@@ -171,9 +478,24 @@ type FeasibleSpace struct {
 	List []string `json:"list,omitempty"`
 
 	// The step of sampling_client.
+	// Double parameters are quantized to multiples of the step from min, and are continuous without a step.
 	Step string `json:"step,omitempty"`
+
+	// The distribution of the values of an int, double or quantity parameter between min and max, uniform if not set.
+	Distribution Distribution `json:"distribution,omitempty"`
 }
 
+// Distribution of the values of an int, double or quantity parameter sampled between min and max.
+type Distribution string
+
+const (
+	// Values are equally likely.
+	DistributionUniform Distribution = "uniform"
+
+	// Logarithms of the values are equally likely, for positive ranges spanning orders of magnitude.
+	DistributionLogUniform Distribution = "logUniform"
+)
+
 // Category of the hyper-parameters to be tuned, for patching use.
 type Category string
 
@@ -186,6 +508,9 @@ const (
 
 	// Args for codes running in service pods/deployments.
 	CategoryArgs Category = "args"
+
+	// Replica count of service deployments, to profile how the service scales horizontally.
+	CategoryReplicas Category = "replicas"
 )
 
 // ParameterSpec is the meta data of a hyper-parameter to be tuned
@@ -193,6 +518,27 @@ type ParameterSpec struct {
 	Name          string        `json:"name,omitempty"`
 	ParameterType ParameterType `json:"parameterType,omitempty"`
 	FeasibleSpace FeasibleSpace `json:"feasibleSpace,omitempty"`
+
+	// Containers of service pods the parameter is applied to, overriding the selector of the category.
+	ContainerSelector *ContainerSelector `json:"containerSelector,omitempty"`
+
+	// Template of the args of an args parameter, e.g., --batch-size={{value}} or "--batch-size {{value}}".
+	// The flag is replaced if present in the args or command of the container, or appended to the args otherwise.
+	ArgTemplate string `json:"argTemplate,omitempty"`
+
+	// Conditions under which the parameter is sampled, all of which must hold.
+	// The parameter is left unset in trials where it is inactive.
+	Conditions []ParameterCondition `json:"conditions,omitempty"`
+}
+
+// ParameterCondition makes a parameter active only when another parameter takes one of the given values,
+// e.g., max_batch_wait is sampled only when enable_batching is true.
+type ParameterCondition struct {
+	// Name of the parameter the condition depends on.
+	Parameter string `json:"parameter"`
+
+	// Values of the parameter for which the condition holds.
+	Values []string `json:"values"`
 }
 
 // ObjectiveType is the optimization obj classes: minimize or maximize
@@ -244,6 +590,15 @@ type ParameterAssignment struct {
 	Name     string   `json:"name,omitempty"`
 	Value    string   `json:"value,omitempty"`
 	Category Category `json:"category,omitempty"`
+
+	// Containers of service pods receiving the value, all containers if empty.
+	Containers []string `json:"containers,omitempty"`
+
+	// Init containers of service pods receiving the value.
+	InitContainers []string `json:"initContainers,omitempty"`
+
+	// Template of the args of an args parameter, rendered with the value.
+	ArgTemplate string `json:"argTemplate,omitempty"`
 }
 
 // ProfilingConditionType defines the status of the ProfilingExperiment
@@ -253,6 +608,7 @@ const (
 	ProfilingCreated    ProfilingConditionType = "Created"
 	ProfilingRunning    ProfilingConditionType = "Running"
 	ProfilingRestarting ProfilingConditionType = "Restarting"
+	ProfilingSuspended  ProfilingConditionType = "Suspended"
 	ProfilingSucceeded  ProfilingConditionType = "Succeeded"
 	ProfilingFailed     ProfilingConditionType = "Failed"
 	ProfilingCompleted  ProfilingConditionType = "Completed"
@@ -0,0 +1,85 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SamplingAlgorithmSpec defines a sampling algorithm served by an algorithm service
+type SamplingAlgorithmSpec struct {
+	// The name of the algorithm referred by spec.algorithm.algorithmName of experiments, defaults to the name of the
+	// SamplingAlgorithm.
+	AlgorithmName AlgorithmName `json:"algorithmName,omitempty"`
+
+	// The address of the algorithm service serving GetSuggestions, e.g., my-optimizer.my-team:9996.
+	Endpoint string `json:"endpoint"`
+
+	// The description of the algorithm.
+	Description string `json:"description,omitempty"`
+
+	// The settings supported by the algorithm. Experiments with other settings are rejected, unless it is empty.
+	SupportedSettings []SupportedAlgorithmSetting `json:"supportedSettings,omitempty"`
+}
+
+// SupportedAlgorithmSetting is a setting supported by a sampling algorithm
+type SupportedAlgorithmSetting struct {
+	// The name of the setting.
+	Name string `json:"name"`
+
+	// The description of the setting.
+	Description string `json:"description,omitempty"`
+
+	// The value of the setting used by the algorithm if it is not set.
+	DefaultValue string `json:"defaultValue,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName="sa"
+// +kubebuilder:printcolumn:name="Algorithm",type=string,JSONPath=`.spec.algorithmName`
+// +kubebuilder:printcolumn:name="Endpoint",type=string,JSONPath=`.spec.endpoint`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// SamplingAlgorithm registers a sampling algorithm with the address of its algorithm service, to which the
+// samplings of the experiments of the algorithm are routed
+type SamplingAlgorithm struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec SamplingAlgorithmSpec `json:"spec,omitempty"`
+}
+
+// GetAlgorithmName returns the name of the algorithm, which defaults to the name of the SamplingAlgorithm
+func (a *SamplingAlgorithm) GetAlgorithmName() AlgorithmName {
+	if a.Spec.AlgorithmName != "" {
+		return a.Spec.AlgorithmName
+	}
+	return AlgorithmName(a.Name)
+}
+
+// +kubebuilder:object:root=true
+
+// SamplingAlgorithmList contains a list of SamplingAlgorithm
+type SamplingAlgorithmList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SamplingAlgorithm `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SamplingAlgorithm{}, &SamplingAlgorithmList{})
+}
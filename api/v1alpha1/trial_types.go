@@ -41,6 +41,30 @@ type TrialSpec struct {
 
 	// The maximum time in seconds for a deployment to make progress before it is considered to be failed.
 	ServiceProgressDeadline *int32 `json:"serviceProgressDeadline,omitempty"`
+
+	// The workload running the service under test, a Deployment of ServicePodTemplate if not set.
+	ServiceWorkload *ServiceWorkloadSpec `json:"serviceWorkload,omitempty"`
+
+	// The k8s service exposing the service under test to the client, with port 8500 named profile-service if not set.
+	Service *ServiceSpec `json:"service,omitempty"`
+
+	// Prices of the resources allocated to service pods, to observe the cost metrics of the trial.
+	CostModel *CostModel `json:"costModel,omitempty"`
+
+	// The fidelity the client tests the service at, set for trials of experiments with successive halving.
+	Fidelity *TrialFidelity `json:"fidelity,omitempty"`
+}
+
+// TrialFidelity is the fidelity of a trial in a rung of successive halving
+type TrialFidelity struct {
+	// Name of the fidelity, e.g., DURATION.
+	Name string `json:"name"`
+
+	// Value of the fidelity.
+	Value int32 `json:"value"`
+
+	// Index of the rung of the trial.
+	Rung int32 `json:"rung"`
 }
 
 // TrialStatus defines the status of this pressure test
@@ -56,6 +80,22 @@ type TrialStatus struct {
 
 	// The time this trial was completed.
 	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Resource usage of the service pods observed while the client job runs, sampled from the metrics API if a cost
+	// model is set.
+	ResourceUsage *ResourceUsage `json:"resourceUsage,omitempty"`
+}
+
+// ResourceUsage accumulates samples of the total resource usage of the service pods of a trial
+type ResourceUsage struct {
+	// Number of usage samples taken.
+	Samples int32 `json:"samples"`
+
+	// Sum over the samples of the usage of all containers of the service pods, averaged by dividing by Samples.
+	Total corev1.ResourceList `json:"total,omitempty"`
+
+	// The time of the metrics of the last sample, newer metrics are required for the next sample.
+	LastSampleTime *metav1.Time `json:"lastSampleTime,omitempty"`
 }
 
 type TrialCondition struct {
@@ -68,6 +108,9 @@ type TrialCondition struct {
 	// Status of the condition, one of True, False, Unknown.
 	Status corev1.ConditionStatus `json:"status"`
 
+	// A machine readable reason for the transition, e.g., ExperimentSuspended for killed trials.
+	Reason string `json:"reason,omitempty"`
+
 	// A human readable message indicating details about the transition.
 	Message string `json:"message,omitempty"`
 
@@ -86,6 +129,15 @@ const (
 	TrialKilled    TrialConditionType = "Killed"
 )
 
+// Reasons of the Killed condition of trials
+const (
+	// TrialKilledByUser is the reason of trials killed by users, from the console or morphlingctl
+	TrialKilledByUser = "KilledByUser"
+	// TrialKilledOnSuspension is the reason of trials killed as their experiment is suspended, whose assignments are
+	// profiled again on resumption
+	TrialKilledOnSuspension = "ExperimentSuspended"
+)
+
 // +kubebuilder:object:root=true
 // +kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.conditions[-1:].type`
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
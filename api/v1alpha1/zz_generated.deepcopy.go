@@ -21,6 +21,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/api/core/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -59,6 +60,107 @@ func (in *AlgorithmSpec) DeepCopy() *AlgorithmSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplyPolicy) DeepCopyInto(out *ApplyPolicy) {
+	*out = *in
+	out.TargetRef = in.TargetRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplyPolicy.
+func (in *ApplyPolicy) DeepCopy() *ApplyPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplyPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplyStatus) DeepCopyInto(out *ApplyStatus) {
+	*out = *in
+	if in.TunableParameters != nil {
+		in, out := &in.TunableParameters, &out.TunableParameters
+		*out = make([]ParameterAssignment, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplyStatus.
+func (in *ApplyStatus) DeepCopy() *ApplyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplyTargetReference) DeepCopyInto(out *ApplyTargetReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplyTargetReference.
+func (in *ApplyTargetReference) DeepCopy() *ApplyTargetReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplyTargetReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContainerSelector) DeepCopyInto(out *ContainerSelector) {
+	*out = *in
+	if in.Containers != nil {
+		in, out := &in.Containers, &out.Containers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.InitContainers != nil {
+		in, out := &in.InitContainers, &out.InitContainers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerSelector.
+func (in *ContainerSelector) DeepCopy() *ContainerSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(ContainerSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CostModel) DeepCopyInto(out *CostModel) {
+	*out = *in
+	if in.ResourcePrices != nil {
+		in, out := &in.ResourcePrices, &out.ResourcePrices
+		*out = make(map[v1.ResourceName]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CostModel.
+func (in *CostModel) DeepCopy() *CostModel {
+	if in == nil {
+		return nil
+	}
+	out := new(CostModel)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FeasibleSpace) DeepCopyInto(out *FeasibleSpace) {
 	*out = *in
@@ -186,6 +288,26 @@ func (in *Metric) DeepCopy() *Metric {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MultiFidelitySpec) DeepCopyInto(out *MultiFidelitySpec) {
+	*out = *in
+	if in.ReductionFactor != nil {
+		in, out := &in.ReductionFactor, &out.ReductionFactor
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MultiFidelitySpec.
+func (in *MultiFidelitySpec) DeepCopy() *MultiFidelitySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MultiFidelitySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ObjectiveSpec) DeepCopyInto(out *ObjectiveSpec) {
 	*out = *in
@@ -204,6 +326,16 @@ func (in *ObjectiveSpec) DeepCopy() *ObjectiveSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ParameterAssignment) DeepCopyInto(out *ParameterAssignment) {
 	*out = *in
+	if in.Containers != nil {
+		in, out := &in.Containers, &out.Containers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.InitContainers != nil {
+		in, out := &in.InitContainers, &out.InitContainers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ParameterAssignment.
@@ -226,6 +358,11 @@ func (in *ParameterCategory) DeepCopyInto(out *ParameterCategory) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ContainerSelector != nil {
+		in, out := &in.ContainerSelector, &out.ContainerSelector
+		*out = new(ContainerSelector)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ParameterCategory.
@@ -238,10 +375,42 @@ func (in *ParameterCategory) DeepCopy() *ParameterCategory {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ParameterCondition) DeepCopyInto(out *ParameterCondition) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ParameterCondition.
+func (in *ParameterCondition) DeepCopy() *ParameterCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(ParameterCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ParameterSpec) DeepCopyInto(out *ParameterSpec) {
 	*out = *in
 	in.FeasibleSpace.DeepCopyInto(&out.FeasibleSpace)
+	if in.ContainerSelector != nil {
+		in, out := &in.ContainerSelector, &out.ContainerSelector
+		*out = new(ContainerSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]ParameterCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ParameterSpec.
@@ -358,6 +527,41 @@ func (in *ProfilingExperimentSpec) DeepCopyInto(out *ProfilingExperimentSpec) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.ServiceWorkload != nil {
+		in, out := &in.ServiceWorkload, &out.ServiceWorkload
+		*out = new(ServiceWorkloadSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Service != nil {
+		in, out := &in.Service, &out.Service
+		*out = new(ServiceSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CostModel != nil {
+		in, out := &in.CostModel, &out.CostModel
+		*out = new(CostModel)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ApplyPolicy != nil {
+		in, out := &in.ApplyPolicy, &out.ApplyPolicy
+		*out = new(ApplyPolicy)
+		**out = **in
+	}
+	if in.Constraints != nil {
+		in, out := &in.Constraints, &out.Constraints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MultiFidelity != nil {
+		in, out := &in.MultiFidelity, &out.MultiFidelity
+		*out = new(MultiFidelitySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Suspend != nil {
+		in, out := &in.Suspend, &out.Suspend
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProfilingExperimentSpec.
@@ -421,6 +625,30 @@ func (in *ProfilingExperimentStatus) DeepCopyInto(out *ProfilingExperimentStatus
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ApplyStatus != nil {
+		in, out := &in.ApplyStatus, &out.ApplyStatus
+		*out = new(ApplyStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PendingSamplings != nil {
+		in, out := &in.PendingSamplings, &out.PendingSamplings
+		*out = make([]TrialAssignment, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Rungs != nil {
+		in, out := &in.Rungs, &out.Rungs
+		*out = make([]RungStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SearchSpace != nil {
+		in, out := &in.SearchSpace, &out.SearchSpace
+		*out = new(SearchSpaceStatus)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProfilingExperimentStatus.
@@ -433,6 +661,226 @@ func (in *ProfilingExperimentStatus) DeepCopy() *ProfilingExperimentStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceUsage) DeepCopyInto(out *ResourceUsage) {
+	*out = *in
+	if in.Total != nil {
+		in, out := &in.Total, &out.Total
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.LastSampleTime != nil {
+		in, out := &in.LastSampleTime, &out.LastSampleTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceUsage.
+func (in *ResourceUsage) DeepCopy() *ResourceUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RungStatus) DeepCopyInto(out *RungStatus) {
+	*out = *in
+	if in.Promoted != nil {
+		in, out := &in.Promoted, &out.Promoted
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RungStatus.
+func (in *RungStatus) DeepCopy() *RungStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RungStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SamplingAlgorithm) DeepCopyInto(out *SamplingAlgorithm) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SamplingAlgorithm.
+func (in *SamplingAlgorithm) DeepCopy() *SamplingAlgorithm {
+	if in == nil {
+		return nil
+	}
+	out := new(SamplingAlgorithm)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SamplingAlgorithm) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SamplingAlgorithmList) DeepCopyInto(out *SamplingAlgorithmList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SamplingAlgorithm, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SamplingAlgorithmList.
+func (in *SamplingAlgorithmList) DeepCopy() *SamplingAlgorithmList {
+	if in == nil {
+		return nil
+	}
+	out := new(SamplingAlgorithmList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SamplingAlgorithmList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SamplingAlgorithmSpec) DeepCopyInto(out *SamplingAlgorithmSpec) {
+	*out = *in
+	if in.SupportedSettings != nil {
+		in, out := &in.SupportedSettings, &out.SupportedSettings
+		*out = make([]SupportedAlgorithmSetting, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SamplingAlgorithmSpec.
+func (in *SamplingAlgorithmSpec) DeepCopy() *SamplingAlgorithmSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SamplingAlgorithmSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SearchSpaceStatus) DeepCopyInto(out *SearchSpaceStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SearchSpaceStatus.
+func (in *SearchSpaceStatus) DeepCopy() *SearchSpaceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SearchSpaceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServicePort) DeepCopyInto(out *ServicePort) {
+	*out = *in
+	out.TargetPort = in.TargetPort
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServicePort.
+func (in *ServicePort) DeepCopy() *ServicePort {
+	if in == nil {
+		return nil
+	}
+	out := new(ServicePort)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceSpec) DeepCopyInto(out *ServiceSpec) {
+	*out = *in
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]ServicePort, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceSpec.
+func (in *ServiceSpec) DeepCopy() *ServiceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceWorkloadSpec) DeepCopyInto(out *ServiceWorkloadSpec) {
+	*out = *in
+	if in.Template != nil {
+		in, out := &in.Template, &out.Template
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReadinessRules != nil {
+		in, out := &in.ReadinessRules, &out.ReadinessRules
+		*out = make([]WorkloadStatusRule, len(*in))
+		copy(*out, *in)
+	}
+	if in.FailureRules != nil {
+		in, out := &in.FailureRules, &out.FailureRules
+		*out = make([]WorkloadStatusRule, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceWorkloadSpec.
+func (in *ServiceWorkloadSpec) DeepCopy() *ServiceWorkloadSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceWorkloadSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SupportedAlgorithmSetting) DeepCopyInto(out *SupportedAlgorithmSetting) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SupportedAlgorithmSetting.
+func (in *SupportedAlgorithmSetting) DeepCopy() *SupportedAlgorithmSetting {
+	if in == nil {
+		return nil
+	}
+	out := new(SupportedAlgorithmSetting)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Trial) DeepCopyInto(out *Trial) {
 	*out = *in
@@ -466,7 +914,9 @@ func (in *TrialAssignment) DeepCopyInto(out *TrialAssignment) {
 	if in.ParameterAssignments != nil {
 		in, out := &in.ParameterAssignments, &out.ParameterAssignments
 		*out = make([]ParameterAssignment, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
@@ -497,6 +947,21 @@ func (in *TrialCondition) DeepCopy() *TrialCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrialFidelity) DeepCopyInto(out *TrialFidelity) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrialFidelity.
+func (in *TrialFidelity) DeepCopy() *TrialFidelity {
+	if in == nil {
+		return nil
+	}
+	out := new(TrialFidelity)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TrialList) DeepCopyInto(out *TrialList) {
 	*out = *in
@@ -535,7 +1000,9 @@ func (in *TrialResult) DeepCopyInto(out *TrialResult) {
 	if in.TunableParameters != nil {
 		in, out := &in.TunableParameters, &out.TunableParameters
 		*out = make([]ParameterAssignment, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.ObjectiveMetricsObserved != nil {
 		in, out := &in.ObjectiveMetricsObserved, &out.ObjectiveMetricsObserved
@@ -560,7 +1027,9 @@ func (in *TrialSpec) DeepCopyInto(out *TrialSpec) {
 	if in.SamplingResult != nil {
 		in, out := &in.SamplingResult, &out.SamplingResult
 		*out = make([]ParameterAssignment, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	out.Objective = in.Objective
 	in.ClientTemplate.DeepCopyInto(&out.ClientTemplate)
@@ -570,6 +1039,26 @@ func (in *TrialSpec) DeepCopyInto(out *TrialSpec) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.ServiceWorkload != nil {
+		in, out := &in.ServiceWorkload, &out.ServiceWorkload
+		*out = new(ServiceWorkloadSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Service != nil {
+		in, out := &in.Service, &out.Service
+		*out = new(ServiceSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CostModel != nil {
+		in, out := &in.CostModel, &out.CostModel
+		*out = new(CostModel)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Fidelity != nil {
+		in, out := &in.Fidelity, &out.Fidelity
+		*out = new(TrialFidelity)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrialSpec.
@@ -605,6 +1094,11 @@ func (in *TrialStatus) DeepCopyInto(out *TrialStatus) {
 		in, out := &in.CompletionTime, &out.CompletionTime
 		*out = (*in).DeepCopy()
 	}
+	if in.ResourceUsage != nil {
+		in, out := &in.ResourceUsage, &out.ResourceUsage
+		*out = new(ResourceUsage)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrialStatus.
@@ -616,3 +1110,18 @@ func (in *TrialStatus) DeepCopy() *TrialStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadStatusRule) DeepCopyInto(out *WorkloadStatusRule) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadStatusRule.
+func (in *WorkloadStatusRule) DeepCopy() *WorkloadStatusRule {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadStatusRule)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -1,3 +1,4 @@
+import os
 import time
 from concurrent import futures
 
@@ -6,17 +7,46 @@ import grpc
 from api.v1alpha1.grpc_proto.grpc_algorithm.python3 import api_pb2_grpc
 from api.v1alpha1.grpc_proto.health.python import health_pb2_grpc
 from pkg.algorithm.v1alpha1.grid.service import BaseService
+from pkg.algorithm.v1alpha1.grid.session import SessionStore, storage_stub
 
 _ONE_DAY_IN_SECONDS = 60 * 60 * 24
 DEFAULT_PORT = "0.0.0.0:9996"
+# Accept the keepalive pings of the long-lived connections of the controllers
+SERVER_OPTIONS = [
+    ("grpc.keepalive_permit_without_calls", 1),
+    ("grpc.http2.min_ping_interval_without_data_ms", 30000),
+]
+
+
+def server_credentials(tls_dir):
+    """Returns the credentials of the server certificate in tls_dir, requiring client certificates if it has ca.crt"""
+    def read(name):
+        with open(os.path.join(tls_dir, name), "rb") as f:
+            return f.read()
+
+    ca_path = os.path.join(tls_dir, "ca.crt")
+    ca = read("ca.crt") if os.path.exists(ca_path) else None
+    return grpc.ssl_server_credentials(
+        [(read("tls.key"), read("tls.crt"))],
+        root_certificates=ca,
+        require_client_auth=ca is not None)
 
 
 def serve():
-    server = grpc.server(futures.ThreadPoolExecutor(max_workers=10))
-    service = BaseService()
+    server = grpc.server(futures.ThreadPoolExecutor(max_workers=10), options=SERVER_OPTIONS)
+    # Sessions are persisted through the db-manager if its endpoint is set, and kept in memory only otherwise
+    db_manager_endpoint = os.environ.get("DB_MANAGER_ENDPOINT")
+    storage = None
+    if db_manager_endpoint:
+        storage = storage_stub(db_manager_endpoint, os.environ.get("TLS_DIR"))
+    service = BaseService(SessionStore(storage))
     api_pb2_grpc.add_SuggestionServicer_to_server(service, server)
     health_pb2_grpc.add_HealthServicer_to_server(service, server)
-    server.add_insecure_port(DEFAULT_PORT)
+    tls_dir = os.environ.get("TLS_DIR")
+    if tls_dir:
+        server.add_secure_port(DEFAULT_PORT, server_credentials(tls_dir))
+    else:
+        server.add_insecure_port(DEFAULT_PORT)
     print("Listening...")
     server.start()
     try:
@@ -23,7 +23,12 @@ import (
 
 	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
 	"github.com/alibaba/morphling/pkg/controllers"
+	"github.com/alibaba/morphling/pkg/controllers/consts"
+	"github.com/alibaba/morphling/pkg/controllers/grpcconn"
+	"github.com/alibaba/morphling/pkg/recording"
+	"google.golang.org/grpc/credentials"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -45,10 +50,14 @@ func main() {
 		ctrlMetricsAddr string
 		//metricsAddr          string
 		enableLeaderElection bool
+		recordFile           string
+		grpcConfig           = grpcconn.DefaultConfig()
 	)
 
 	flag.StringVar(&ctrlMetricsAddr, "controller-metrics-addr", ":8080", "The address the controller metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false, "Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&recordFile, "record-file", "", "The file to append the samplings and trial results of experiments to, for replays in tests.")
+	grpcConfig.AddFlags(flag.CommandLine)
 	flag.Parse()
 	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
 
@@ -74,6 +83,32 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Share the connections to the algorithm server and the db-manager, with mTLS if configured
+	var creds credentials.TransportCredentials
+	if grpcConfig.TLSSecret != "" {
+		key := types.NamespacedName{Namespace: consts.DefaultControllerNamespace, Name: grpcConfig.TLSSecret}
+		if creds, err = grpcconn.LoadClientCredentials(mgr.GetAPIReader(), key); err != nil {
+			setupLog.Error(err, "unable to load gRPC TLS credentials")
+			os.Exit(1)
+		}
+	}
+	grpcconn.Default = grpcconn.NewManager(grpcConfig, creds)
+	defer grpcconn.Default.Close()
+	setupLog.Info("Connecting to gRPC servers", "algorithmServer", grpcConfig.AlgorithmEndpoint,
+		"dbManager", grpcConfig.DBManagerEndpoint, "tls", creds != nil)
+
+	// Record samplings and trial results
+	if recordFile != "" {
+		f, err := os.OpenFile(recordFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			setupLog.Error(err, "unable to open record file")
+			os.Exit(1)
+		}
+		defer f.Close()
+		controllers.Recorder = recording.NewRecorder(f)
+		setupLog.Info("Recording samplings and trial results", "file", recordFile)
+	}
+
 	// Setup all Controllers
 	setupLog.Info("Setting up controller")
 	if err := controllers.AddToManager(mgr); err != nil {
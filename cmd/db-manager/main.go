@@ -2,16 +2,22 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	api_pb "github.com/alibaba/morphling/api/v1alpha1/grpc_proto/grpc_storage/go"
 	health_pb "github.com/alibaba/morphling/api/v1alpha1/grpc_proto/health"
 	"k8s.io/klog"
 	"net"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 
+	"github.com/alibaba/morphling/pkg/controllers/grpcconn"
 	"github.com/alibaba/morphling/pkg/storage/backends"
 )
 
@@ -35,6 +41,19 @@ func (s *server) GetResult(ctx context.Context, in *api_pb.GetResultRequest) (*a
 	return reply, err
 }
 
+func (s *server) SaveSession(ctx context.Context, in *api_pb.SaveSessionRequest) (*api_pb.SaveSessionReply, error) {
+	err := s.dbIf.SaveSession(in)
+	return &api_pb.SaveSessionReply{}, err
+}
+
+func (s *server) GetSession(ctx context.Context, in *api_pb.GetSessionRequest) (*api_pb.GetSessionReply, error) {
+	reply, err := s.dbIf.GetSession(in)
+	if errors.Is(err, backends.ErrSessionNotFound) {
+		return nil, status.Errorf(codes.NotFound, "session %s not found", in.SessionId)
+	}
+	return reply, err
+}
+
 func (s *server) Check(ctx context.Context, in *health_pb.HealthCheckRequest) (*health_pb.HealthCheckResponse, error) {
 	resp := health_pb.HealthCheckResponse{
 		Status: health_pb.HealthCheckResponse_SERVING,
@@ -50,6 +69,7 @@ func (s *server) Check(ctx context.Context, in *health_pb.HealthCheckRequest) (*
 }
 
 func main() {
+	tlsDir := flag.String("tls-dir", "", "The directory of the server certificate (tls.crt and tls.key), e.g., a mounted secret, serving TLS if set, and mTLS if it has the CA certificate of the clients (ca.crt) as well")
 	flag.Parse()
 
 	dbIf := backends.NewMysqlBackendService()
@@ -64,9 +84,21 @@ func main() {
 	}
 
 	klog.Infof("Start Morphling storage: %s", port)
-	s := grpc.NewServer()
+	opts := []grpc.ServerOption{
+		// Accept the keepalive pings of the long-lived connections of the controllers
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{MinTime: 30 * time.Second, PermitWithoutStream: true}),
+	}
+	if *tlsDir != "" {
+		creds, err := grpcconn.LoadServerCredentials(*tlsDir)
+		if err != nil {
+			klog.Fatalf("Failed to load TLS credentials: %v", err)
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+	s := grpc.NewServer(opts...)
 
 	api_pb.RegisterDBServer(s, &server{dbIf: dbIf})
+	api_pb.RegisterSessionServer(s, &server{dbIf: dbIf})
 	health_pb.RegisterHealthServer(s, &server{dbIf: dbIf})
 	reflection.Register(s)
 
@@ -8,8 +8,11 @@ import (
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	mockdb "github.com/alibaba/morphling/pkg/mock/db"
+	"github.com/alibaba/morphling/pkg/storage/backends"
 )
 
 var testCases = map[string]struct {
@@ -76,3 +79,21 @@ func TestGetObservationLog(t *testing.T) {
 		})
 	}
 }
+
+func TestGetSession(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mockdb.NewMockStorageBackend(ctrl)
+	s := &server{mockDB}
+
+	request := &api_pb.GetSessionRequest{SessionId: "default/pe/uid"}
+	mockDB.EXPECT().GetSession(request).Return(&api_pb.GetSessionReply{SessionId: "default/pe/uid", State: []byte("state")}, nil)
+	reply, err := s.GetSession(context.Background(), request)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("state"), reply.State)
+
+	mockDB.EXPECT().GetSession(request).Return(nil, backends.ErrSessionNotFound)
+	_, err = s.GetSession(context.Background(), request)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// morphling-sim runs an experiment against a synthetic objective in memory, without a cluster, to benchmark search
+// algorithms and experiment configurations in seconds
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	"github.com/alibaba/morphling/pkg/controllers/experiment/sampling_client"
+	"github.com/alibaba/morphling/pkg/controllers/trial/dbclient"
+	"github.com/alibaba/morphling/pkg/export"
+	"github.com/alibaba/morphling/pkg/simulation"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	fs := flag.NewFlagSet("morphling-sim", flag.ContinueOnError)
+	file := fs.String("f", "", "YAML or JSON file of the experiment, required")
+	function := fs.String("function", "", "Benchmark function of the objective: sphere, rosenbrock or rastrigin")
+	replay := fs.String("replay", "", "JSONL file of recorded trials to replay as the objective, e.g., of morphlingctl export -format jsonl")
+	endpoint := fs.String("algorithm-endpoint", "localhost:9996", "Endpoint of the algorithm server sampling the trials")
+	format := fs.String("format", string(export.FormatCSV), "Format of the trial records written to stdout: csv, jsonl or parquet")
+	maxRounds := fs.Int("max-rounds", simulation.DefaultMaxRounds, "Rounds to reconcile the experiment and its trials before giving up")
+	verbose := fs.Bool("v", false, "Log the reconciliations of the controllers")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: morphling-sim -f <experiment> (-function <name> | -replay <records>) [flags]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" || (*function == "") == (*replay == "") {
+		fs.Usage()
+		return fmt.Errorf("-f and either -function or -replay are required")
+	}
+	outputFormat, err := export.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+	if *verbose {
+		ctrl.SetLogger(zap.New(zap.UseDevMode(true), zap.WriteTo(os.Stderr)))
+	}
+
+	pe, err := readExperiment(*file)
+	if err != nil {
+		return err
+	}
+	var objective dbclient.DBClient
+	if *function != "" {
+		if objective, err = simulation.NewFunctionObjective(*function); err != nil {
+			return err
+		}
+	} else {
+		f, err := os.Open(*replay)
+		if err != nil {
+			return err
+		}
+		records, err := export.ReadJSONL(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", *replay, err)
+		}
+		objective = simulation.NewReplayObjective(records)
+	}
+
+	scheme := runtime.NewScheme()
+	if err := morphlingv1alpha1.AddToScheme(scheme); err != nil {
+		return err
+	}
+	c := simulation.NewClient(scheme)
+	s := simulation.New(c, scheme, sampling_client.NewForEndpoint(scheme, c, *endpoint), objective)
+	s.MaxRounds = *maxRounds
+
+	start := time.Now()
+	result, trials, err := s.Run(pe)
+	if err != nil {
+		return err
+	}
+	records := make([]export.Record, 0, len(trials))
+	for i := range trials {
+		records = append(records, export.NewRecord(result.Name, &trials[i]))
+	}
+	if err := export.Write(os.Stdout, outputFormat, records); err != nil {
+		return err
+	}
+
+	status := result.Status
+	fmt.Fprintf(os.Stderr, "Experiment %s completed in %v: %d trials, %d succeeded, %d failed\n",
+		result.Name, time.Since(start).Round(time.Millisecond), len(trials), status.TrialsSucceeded, status.TrialsFailed)
+	for _, p := range status.CurrentOptimalTrial.TunableParameters {
+		fmt.Fprintf(os.Stderr, "  optimal %s: %s\n", p.Name, p.Value)
+	}
+	for _, m := range status.CurrentOptimalTrial.ObjectiveMetricsObserved {
+		fmt.Fprintf(os.Stderr, "  optimal %s = %s\n", m.Name, m.Value)
+	}
+	return nil
+}
+
+// readExperiment reads the experiment of the file, in the default namespace unless one is set
+func readExperiment(file string) (*morphlingv1alpha1.ProfilingExperiment, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	pe := &morphlingv1alpha1.ProfilingExperiment{}
+	if err := yaml.Unmarshal(data, pe); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", file, err)
+	}
+	if pe.Name == "" {
+		return nil, fmt.Errorf("experiment of %s has no name", file)
+	}
+	if pe.Namespace == "" {
+		pe.Namespace = "default"
+	}
+	return pe, nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ghodss/yaml"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	"github.com/alibaba/morphling/pkg/controllers/experiment"
+)
+
+// runApplyBest patches the target workload with the optimal assignment of an experiment, the same way as trial
+// deployments. The target is the apply policy of the experiment, unless a deployment is given.
+func runApplyBest(args []string) error {
+	fs := newFlagSet("apply-best", "<experiment>")
+	namespace := fs.String("n", "default", "Namespace of the experiment")
+	deployName := fs.String("deployment", "", "Name of the deployment to patch, the target of the apply policy of the experiment if empty")
+	deployNamespace := fs.String("deployment-namespace", "", "Namespace of the deployment, the same as the experiment if empty")
+	container := fs.String("container", "", "Container to patch, the profiled containers of the same names if empty")
+	dryRun := fs.Bool("dry-run", false, "Print the patch instead of applying it")
+	positional, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	pe := &morphlingv1alpha1.ProfilingExperiment{}
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: *namespace, Name: positional[0]}, pe); err != nil {
+		return err
+	}
+	best := pe.Status.CurrentOptimalTrial.TunableParameters
+	if len(best) == 0 {
+		return fmt.Errorf("experiment %s/%s has no optimal trial yet", pe.Namespace, pe.Name)
+	}
+
+	policy := pe.Spec.ApplyPolicy
+	if *deployName != "" {
+		policy = &morphlingv1alpha1.ApplyPolicy{
+			TargetRef: morphlingv1alpha1.ApplyTargetReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       *deployName,
+				Namespace:  *deployNamespace,
+			},
+			Container: *container,
+		}
+	} else if policy == nil {
+		fs.Usage()
+		return fmt.Errorf("experiment %s/%s has no apply policy, -deployment is required", pe.Namespace, pe.Name)
+	} else if *container != "" {
+		policy = policy.DeepCopy()
+		policy.Container = *container
+	}
+
+	target, patch, err := experiment.RenderApplyPatch(c, policy, pe.Namespace, best)
+	if err != nil {
+		return err
+	}
+	if *dryRun {
+		data, err := yaml.JSONToYAML(patch)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("# patch of %s %s/%s\n%s", target.GetKind(), target.GetNamespace(), target.GetName(), data)
+		return nil
+	}
+	if err := c.Patch(context.Background(), target, client.RawPatch(types.MergePatchType, patch)); err != nil {
+		return err
+	}
+	fmt.Printf("%s %s/%s patched with %s\n", target.GetKind(), target.GetNamespace(), target.GetName(), formatAssignments(best))
+
+	// Record the outcome for the apply policy of the experiment
+	if *deployName == "" {
+		pe.Status.ApplyStatus = &morphlingv1alpha1.ApplyStatus{
+			Phase:             morphlingv1alpha1.ApplyApplied,
+			Message:           fmt.Sprintf("Optimal parameters are applied to %s %s/%s by morphlingctl", target.GetKind(), target.GetNamespace(), target.GetName()),
+			TunableParameters: best,
+			Patch:             string(patch),
+			LastUpdateTime:    metav1.Now(),
+		}
+		return c.Status().Update(context.Background(), pe)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+)
+
+// runCompare prints the settings and results of two experiments side by side, rows that differ are marked with *
+func runCompare(args []string) error {
+	fs := newFlagSet("compare", "<experiment> <experiment>")
+	namespace := fs.String("n", "default", "Namespace of the experiments")
+	positional, err := parseArgs(fs, args, 2)
+	if err != nil {
+		return err
+	}
+
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	peA, trialsA, err := getExperimentWithTrials(c, *namespace, positional[0])
+	if err != nil {
+		return err
+	}
+	peB, trialsB, err := getExperimentWithTrials(c, *namespace, positional[1])
+	if err != nil {
+		return err
+	}
+
+	rows := [][3]string{
+		{"State", experimentState(peA), experimentState(peB)},
+		{"Objective", objectiveString(peA), objectiveString(peB)},
+		{"Algorithm", string(peA.Spec.Algorithm.AlgorithmName), string(peB.Spec.Algorithm.AlgorithmName)},
+		{"Trials", trialSummary(peA, trialsA), trialSummary(peB, trialsB)},
+		{"Duration", experimentDuration(peA), experimentDuration(peB)},
+	}
+	// Best value of the objective of each experiment
+	metrics := []string{peA.Spec.Objective.ObjectiveMetricName}
+	if nameB := peB.Spec.Objective.ObjectiveMetricName; nameB != metrics[0] {
+		metrics = append(metrics, nameB)
+	}
+	for _, metric := range metrics {
+		rows = append(rows, [3]string{"Best " + metric,
+			objectiveValue(peA.Status.CurrentOptimalTrial.ObjectiveMetricsObserved, metric),
+			objectiveValue(peB.Status.CurrentOptimalTrial.ObjectiveMetricsObserved, metric)})
+	}
+	// Best config, parameters of both experiments in spec order
+	seen := map[string]bool{}
+	for _, name := range append(parameterNames(peA), parameterNames(peB)...) {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		rows = append(rows, [3]string{"Best " + name,
+			assignmentValue(peA.Status.CurrentOptimalTrial.TunableParameters, name),
+			assignmentValue(peB.Status.CurrentOptimalTrial.TunableParameters, name)})
+	}
+
+	w := stdoutTable()
+	fmt.Fprintf(w, "\t\t%s\t%s\n", peA.Name, peB.Name)
+	for _, row := range rows {
+		mark := ""
+		if row[1] != row[2] {
+			mark = "*"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", mark, row[0], row[1], row[2])
+	}
+	return w.Flush()
+}
+
+func objectiveString(pe *morphlingv1alpha1.ProfilingExperiment) string {
+	return strings.TrimSpace(fmt.Sprintf("%s %s", pe.Spec.Objective.Type, pe.Spec.Objective.ObjectiveMetricName))
+}
+
+func trialSummary(pe *morphlingv1alpha1.ProfilingExperiment, trials []morphlingv1alpha1.Trial) string {
+	maxTrials := "-"
+	if pe.Spec.MaxNumTrials != nil {
+		maxTrials = fmt.Sprint(*pe.Spec.MaxNumTrials)
+	}
+	return fmt.Sprintf("%d created of %s, %d succeeded, %d failed", len(trials), maxTrials, pe.Status.TrialsSucceeded, pe.Status.TrialsFailed)
+}
+
+func experimentDuration(pe *morphlingv1alpha1.ProfilingExperiment) string {
+	if pe.Status.StartTime == nil || pe.Status.CompletionTime == nil {
+		return none
+	}
+	return pe.Status.CompletionTime.Sub(pe.Status.StartTime.Time).String()
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	"github.com/alibaba/morphling/pkg/controllers/consts"
+)
+
+func runDescribe(args []string) error {
+	fs := newFlagSet("describe", "<experiment>")
+	namespace := fs.String("n", "default", "Namespace of the experiment")
+	positional, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	pe, trials, err := getExperimentWithTrials(c, *namespace, positional[0])
+	if err != nil {
+		return err
+	}
+	return describeExperiment(os.Stdout, pe, trials)
+}
+
+// getExperimentWithTrials gets the experiment and its trials ordered by creation
+func getExperimentWithTrials(c client.Client, namespace, name string) (*morphlingv1alpha1.ProfilingExperiment, []morphlingv1alpha1.Trial, error) {
+	pe := &morphlingv1alpha1.ProfilingExperiment{}
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: name}, pe); err != nil {
+		return nil, nil, err
+	}
+	trials := &morphlingv1alpha1.TrialList{}
+	if err := c.List(context.Background(), trials, client.InNamespace(namespace), client.MatchingLabels{consts.LabelExperimentName: name}); err != nil {
+		return nil, nil, err
+	}
+	sort.SliceStable(trials.Items, func(i, j int) bool {
+		if trials.Items[i].CreationTimestamp.Equal(&trials.Items[j].CreationTimestamp) {
+			return trials.Items[i].Name < trials.Items[j].Name
+		}
+		return trials.Items[i].CreationTimestamp.Before(&trials.Items[j].CreationTimestamp)
+	})
+	return pe, trials.Items, nil
+}
+
+func describeExperiment(out io.Writer, pe *morphlingv1alpha1.ProfilingExperiment, trials []morphlingv1alpha1.Trial) error {
+	metricName := pe.Spec.Objective.ObjectiveMetricName
+
+	w := newTabWriter(out)
+	fmt.Fprintf(w, "Name:\t%s\n", pe.Name)
+	fmt.Fprintf(w, "Namespace:\t%s\n", pe.Namespace)
+	fmt.Fprintf(w, "State:\t%s\n", experimentState(pe))
+	fmt.Fprintf(w, "Age:\t%s\n", age(pe.CreationTimestamp))
+	fmt.Fprintf(w, "Objective:\t%s %s\n", pe.Spec.Objective.Type, metricName)
+	fmt.Fprintf(w, "Algorithm:\t%s\n", pe.Spec.Algorithm.AlgorithmName)
+	if pe.Spec.MaxNumTrials != nil {
+		fmt.Fprintf(w, "Max Trials:\t%d\n", *pe.Spec.MaxNumTrials)
+	}
+	if pe.Spec.Parallelism != nil {
+		fmt.Fprintf(w, "Parallelism:\t%d\n", *pe.Spec.Parallelism)
+	}
+	fmt.Fprintf(w, "Trials:\t%d total, %d succeeded, %d running, %d pending, %d failed, %d killed\n",
+		pe.Status.TrialsTotal, pe.Status.TrialsSucceeded, pe.Status.TrialsRunning, pe.Status.TrialsPending,
+		pe.Status.TrialsFailed, pe.Status.TrialsKilled)
+	if len(pe.Status.Conditions) > 0 {
+		last := pe.Status.Conditions[len(pe.Status.Conditions)-1]
+		if last.Message != "" {
+			fmt.Fprintf(w, "Message:\t%s\n", last.Message)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	// Trials
+	names := parameterNames(pe)
+	fmt.Fprintf(out, "\nTrials:\n")
+	w = newTabWriter(out)
+	header := append([]string{"NAME", "STATE"}, names...)
+	header = append(header, metricName, "AGE")
+	fmt.Fprintf(w, "  %s\n", strings.ToUpper(strings.Join(header, "\t")))
+	for i := range trials {
+		trial := &trials[i]
+		row := []string{trial.Name, trialState(trial)}
+		for _, name := range names {
+			row = append(row, assignmentValue(trial.Spec.SamplingResult, name))
+		}
+		objective := none
+		if trial.Status.TrialResult != nil {
+			objective = objectiveValue(trial.Status.TrialResult.ObjectiveMetricsObserved, metricName)
+		}
+		row = append(row, objective, age(trial.CreationTimestamp))
+		fmt.Fprintf(w, "  %s\n", strings.Join(row, "\t"))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	// Best config
+	fmt.Fprintf(out, "\nBest Config:\n")
+	best := pe.Status.CurrentOptimalTrial
+	if len(best.TunableParameters) == 0 {
+		fmt.Fprintf(out, "  %s\n", none)
+		return nil
+	}
+	w = newTabWriter(out)
+	for _, a := range best.TunableParameters {
+		fmt.Fprintf(w, "  %s\t%s\t(%s)\n", a.Name, a.Value, a.Category)
+	}
+	for _, metric := range best.ObjectiveMetricsObserved {
+		fmt.Fprintf(w, "  %s\t%s\t(metric)\n", metric.Name, metric.Value)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"os"
+
+	"github.com/alibaba/morphling/pkg/controllers/trial/dbclient"
+	"github.com/alibaba/morphling/pkg/export"
+)
+
+func runExport(args []string) error {
+	fs := newFlagSet("export", "<experiment>")
+	namespace := fs.String("n", "default", "Namespace of the experiment")
+	format := fs.String("format", string(export.FormatCSV), "Output format, one of csv, jsonl, parquet")
+	output := fs.String("o", "-", "Output file, - for stdout")
+	dbEndpoint := fs.String("db-endpoint", "", "Address of db-manager, e.g., localhost:6799 with kubectl port-forward, "+
+		"to fill in the metrics missing in trial status; trial status only if empty")
+	positional, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	name := positional[0]
+	f, err := export.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	var db dbclient.DBClient
+	if *dbEndpoint != "" {
+		db = dbclient.NewTrialDBClientForEndpoint(*dbEndpoint)
+	}
+	records, err := export.Collect(context.Background(), c, db, *namespace, name)
+	if err != nil {
+		return err
+	}
+
+	out := os.Stdout
+	if *output != "-" {
+		if out, err = os.Create(*output); err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+	w := bufio.NewWriter(out)
+	if err := export.Write(w, f, records); err != nil {
+		return err
+	}
+	return w.Flush()
+}
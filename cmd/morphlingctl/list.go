@@ -0,0 +1,74 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+)
+
+func runList(args []string) error {
+	fs := newFlagSet("list", "")
+	namespace := fs.String("n", "default", "Namespace of the experiments")
+	allNamespaces := fs.Bool("A", false, "List experiments across all namespaces")
+	selector := fs.String("l", "", "Label selector of the experiments, e.g., team=infer")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+
+	opts := []client.ListOption{}
+	if !*allNamespaces {
+		opts = append(opts, client.InNamespace(*namespace))
+	}
+	if *selector != "" {
+		sel, err := labels.Parse(*selector)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, client.MatchingLabelsSelector{Selector: sel})
+	}
+
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	experiments := &morphlingv1alpha1.ProfilingExperimentList{}
+	if err := c.List(context.Background(), experiments, opts...); err != nil {
+		return err
+	}
+
+	w := stdoutTable()
+	fmt.Fprintln(w, "NAMESPACE\tNAME\tSTATE\tALGORITHM\tTRIALS\tOBJECTIVE\tOPTIMAL\tAGE")
+	for i := range experiments.Items {
+		pe := &experiments.Items[i]
+		maxTrials := "-"
+		if pe.Spec.MaxNumTrials != nil {
+			maxTrials = fmt.Sprint(*pe.Spec.MaxNumTrials)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d/%s\t%s\t%s\t%s\n", pe.Namespace, pe.Name, experimentState(pe),
+			pe.Spec.Algorithm.AlgorithmName, pe.Status.TrialsSucceeded, maxTrials,
+			pe.Spec.Objective.ObjectiveMetricName,
+			objectiveValue(pe.Status.CurrentOptimalTrial.ObjectiveMetricsObserved, pe.Spec.Objective.ObjectiveMetricName),
+			age(pe.CreationTimestamp))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = morphlingv1alpha1.AddToScheme(scheme)
+}
+
+// command is a subcommand of morphlingctl, it parses its own flags from args
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"submit":     {usage: "Submit an experiment from a YAML file or flags", run: runSubmit},
+	"list":       {usage: "List experiments", run: runList},
+	"describe":   {usage: "Show an experiment with its trials and the best config", run: runDescribe},
+	"watch":      {usage: "Follow the progress of an experiment until it completes", run: runWatch},
+	"kill-trial": {usage: "Kill a running trial", run: runKillTrial},
+	"export":     {usage: "Export trials of an experiment as csv, jsonl or parquet", run: runExport},
+	"compare":    {usage: "Compare two experiments side by side", run: runCompare},
+	"apply-best": {usage: "Patch a deployment with the optimal assignment of an experiment", run: runApplyBest},
+}
+
+func main() {
+	// The kubeconfig flag is registered by controller-runtime
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(flag.Args()[1:]); err == flag.ErrHelp {
+		return
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: morphlingctl [--kubeconfig=<path>] <command> [flags]\n\nCommands:\n")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", name, commands[name].usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun 'morphlingctl <command> -h' for the flags of a command.\n")
+}
+
+// newClient connects to the cluster of the kubeconfig flag, KUBECONFIG env, in-cluster config or ~/.kube/config
+func newClient() (client.Client, error) {
+	cfg, err := ctrl.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	return client.New(cfg, client.Options{Scheme: scheme})
+}
+
+// newFlagSet creates the flags of a command, argsUsage describes its positional arguments
+func newFlagSet(name, argsUsage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: morphlingctl %s [flags] %s\n\nFlags:\n", name, argsUsage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseArgs parses the flags, which may also be interleaved with the positional arguments, and returns exactly n
+// positional arguments
+func parseArgs(fs *flag.FlagSet, args []string, n int) ([]string, error) {
+	positional := make([]string, 0, n)
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if len(positional) != n {
+		fs.Usage()
+		return nil, fmt.Errorf("expected %d arguments, got %d", n, len(positional))
+	}
+	return positional, nil
+}
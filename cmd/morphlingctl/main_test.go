@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+)
+
+func TestParameterFlags(t *testing.T) {
+	params := parameterFlags{}
+	assert.NoError(t, params.Set("env:BATCH_SIZE:discrete=1,2,4"))
+	assert.NoError(t, params.Set("resource:cpu:int=1..4/1"))
+	assert.NoError(t, params.Set("env:DTYPE:categorical=fp16,int8"))
+	assert.NoError(t, params.Set("args:lr:double=0.0001..0.1~logUniform"))
+	assert.NoError(t, params.Set("resource:memory:quantity=1Gi..4Gi/512Mi"))
+	assert.Error(t, params.Set("env:BATCH_SIZE=1,2"))
+	assert.Error(t, params.Set("resource:memory:double=1Gi"))
+	assert.Error(t, params.Set("env:X:unknown=1"))
+
+	assert.Equal(t, parameterFlags{
+		{Category: morphlingv1alpha1.CategoryEnv, Parameters: []morphlingv1alpha1.ParameterSpec{
+			{Name: "BATCH_SIZE", ParameterType: morphlingv1alpha1.ParameterTypeDiscrete, FeasibleSpace: morphlingv1alpha1.FeasibleSpace{List: []string{"1", "2", "4"}}},
+			{Name: "DTYPE", ParameterType: morphlingv1alpha1.ParameterTypeCategorical, FeasibleSpace: morphlingv1alpha1.FeasibleSpace{List: []string{"fp16", "int8"}}},
+		}},
+		{Category: morphlingv1alpha1.CategoryResource, Parameters: []morphlingv1alpha1.ParameterSpec{
+			{Name: "cpu", ParameterType: morphlingv1alpha1.ParameterTypeInt, FeasibleSpace: morphlingv1alpha1.FeasibleSpace{Min: "1", Max: "4", Step: "1"}},
+			{Name: "memory", ParameterType: morphlingv1alpha1.ParameterTypeQuantity, FeasibleSpace: morphlingv1alpha1.FeasibleSpace{Min: "1Gi", Max: "4Gi", Step: "512Mi"}},
+		}},
+		{Category: morphlingv1alpha1.CategoryArgs, Parameters: []morphlingv1alpha1.ParameterSpec{
+			{Name: "lr", ParameterType: morphlingv1alpha1.ParameterTypeDouble, FeasibleSpace: morphlingv1alpha1.FeasibleSpace{Min: "0.0001", Max: "0.1", Distribution: morphlingv1alpha1.DistributionLogUniform}},
+		}},
+	}, params)
+}
+
+func TestDescribeExperiment(t *testing.T) {
+	pe := &morphlingv1alpha1.ProfilingExperiment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pe", Namespace: "default"},
+		Spec: morphlingv1alpha1.ProfilingExperimentSpec{
+			Objective: morphlingv1alpha1.ObjectiveSpec{Type: morphlingv1alpha1.ObjectiveTypeMaximize, ObjectiveMetricName: "qps"},
+			TunableParameters: []morphlingv1alpha1.ParameterCategory{{
+				Category:   morphlingv1alpha1.CategoryResource,
+				Parameters: []morphlingv1alpha1.ParameterSpec{{Name: "cpu"}},
+			}},
+		},
+		Status: morphlingv1alpha1.ProfilingExperimentStatus{
+			CurrentOptimalTrial: morphlingv1alpha1.TrialResult{
+				TunableParameters:        []morphlingv1alpha1.ParameterAssignment{{Name: "cpu", Value: "2", Category: morphlingv1alpha1.CategoryResource}},
+				ObjectiveMetricsObserved: []morphlingv1alpha1.Metric{{Name: "qps", Value: "120"}},
+			},
+		},
+	}
+	trials := []morphlingv1alpha1.Trial{{
+		ObjectMeta: metav1.ObjectMeta{Name: "pe-1", Namespace: "default"},
+		Spec: morphlingv1alpha1.TrialSpec{
+			SamplingResult: []morphlingv1alpha1.ParameterAssignment{{Name: "cpu", Value: "2"}},
+		},
+		Status: morphlingv1alpha1.TrialStatus{
+			TrialResult: &morphlingv1alpha1.TrialResult{ObjectiveMetricsObserved: []morphlingv1alpha1.Metric{{Name: "qps", Value: "120"}}},
+			Conditions:  []morphlingv1alpha1.TrialCondition{{Type: morphlingv1alpha1.TrialSucceeded}},
+		},
+	}}
+
+	out := &bytes.Buffer{}
+	assert.NoError(t, describeExperiment(out, pe, trials))
+	lines := strings.Split(out.String(), "\n")
+	assert.Contains(t, lines, "Objective:  maximize qps")
+	assert.Contains(t, lines, "  NAME  STATE      CPU  QPS  AGE")
+	assert.Contains(t, lines, "  pe-1  Succeeded  2    120  <none>")
+	assert.Contains(t, lines, "  cpu  2    (resource)")
+	assert.Contains(t, lines, "  qps  120  (metric)")
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+)
+
+// none is printed for absent values in tables
+const none = "<none>"
+
+func newTabWriter(w io.Writer) *tabwriter.Writer {
+	return tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+}
+
+func stdoutTable() *tabwriter.Writer {
+	return newTabWriter(os.Stdout)
+}
+
+// age formats the time elapsed since t the same way as kubectl
+func age(t metav1.Time) string {
+	if t.IsZero() {
+		return none
+	}
+	return duration.HumanDuration(time.Since(t.Time))
+}
+
+// experimentState returns the last condition of the experiment
+func experimentState(pe *morphlingv1alpha1.ProfilingExperiment) string {
+	if len(pe.Status.Conditions) == 0 {
+		return none
+	}
+	return string(pe.Status.Conditions[len(pe.Status.Conditions)-1].Type)
+}
+
+// trialState returns the last condition of the trial
+func trialState(trial *morphlingv1alpha1.Trial) string {
+	if len(trial.Status.Conditions) == 0 {
+		return none
+	}
+	return string(trial.Status.Conditions[len(trial.Status.Conditions)-1].Type)
+}
+
+// objectiveValue returns the observed value of the objective metric
+func objectiveValue(metrics []morphlingv1alpha1.Metric, name string) string {
+	for _, metric := range metrics {
+		if metric.Name == name {
+			return metric.Value
+		}
+	}
+	return none
+}
+
+// formatAssignments prints the assignments as name=value pairs sorted by name
+func formatAssignments(assignments []morphlingv1alpha1.ParameterAssignment) string {
+	if len(assignments) == 0 {
+		return none
+	}
+	pairs := make([]string, 0, len(assignments))
+	for _, a := range assignments {
+		pairs = append(pairs, a.Name+"="+a.Value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// parameterNames returns the names of the tunable parameters of the experiment in spec order
+func parameterNames(pe *morphlingv1alpha1.ProfilingExperiment) []string {
+	names := make([]string, 0)
+	for _, category := range pe.Spec.TunableParameters {
+		for _, p := range category.Parameters {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+func assignmentValue(assignments []morphlingv1alpha1.ParameterAssignment, name string) string {
+	for _, a := range assignments {
+		if a.Name == name {
+			return a.Value
+		}
+	}
+	return none
+}
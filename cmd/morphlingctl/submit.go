@@ -0,0 +1,191 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	corev1 "k8s.io/api/core/v1"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+)
+
+// parameterFlags collects the repeated -param flags, each formatted as <category>:<name>:<type>=<values>, where values
+// are comma separated for discrete and categorical parameters, or <min>..<max>[/<step>][~<distribution>] for int and
+// double ones
+type parameterFlags []morphlingv1alpha1.ParameterCategory
+
+var _ flag.Value = &parameterFlags{}
+
+func (p *parameterFlags) String() string {
+	return fmt.Sprint(*p)
+}
+
+func (p *parameterFlags) Set(value string) error {
+	i := strings.Index(value, "=")
+	if i < 0 {
+		return fmt.Errorf("invalid parameter %q, should be <category>:<name>:<type>=<values>", value)
+	}
+	fields := strings.Split(value[:i], ":")
+	if len(fields) != 3 {
+		return fmt.Errorf("invalid parameter %q, should be <category>:<name>:<type>=<values>", value)
+	}
+	category := morphlingv1alpha1.Category(fields[0])
+	spec := morphlingv1alpha1.ParameterSpec{Name: fields[1], ParameterType: morphlingv1alpha1.ParameterType(fields[2])}
+
+	values := value[i+1:]
+	switch spec.ParameterType {
+	case morphlingv1alpha1.ParameterTypeInt, morphlingv1alpha1.ParameterTypeDouble, morphlingv1alpha1.ParameterTypeQuantity:
+		bounds := values
+		if j := strings.Index(bounds, "~"); j >= 0 {
+			bounds, spec.FeasibleSpace.Distribution = bounds[:j], morphlingv1alpha1.Distribution(bounds[j+1:])
+		}
+		if j := strings.Index(bounds, "/"); j >= 0 {
+			bounds, spec.FeasibleSpace.Step = bounds[:j], bounds[j+1:]
+		}
+		minMax := strings.Split(bounds, "..")
+		if len(minMax) != 2 {
+			return fmt.Errorf("invalid range %q of parameter %s, should be <min>..<max>[/<step>][~<distribution>]", values, spec.Name)
+		}
+		spec.FeasibleSpace.Min, spec.FeasibleSpace.Max = minMax[0], minMax[1]
+	case morphlingv1alpha1.ParameterTypeDiscrete, morphlingv1alpha1.ParameterTypeCategorical:
+		spec.FeasibleSpace.List = strings.Split(values, ",")
+	default:
+		return fmt.Errorf("unknown type %q of parameter %s", spec.ParameterType, spec.Name)
+	}
+
+	for j := range *p {
+		if (*p)[j].Category == category {
+			(*p)[j].Parameters = append((*p)[j].Parameters, spec)
+			return nil
+		}
+	}
+	*p = append(*p, morphlingv1alpha1.ParameterCategory{Category: category, Parameters: []morphlingv1alpha1.ParameterSpec{spec}})
+	return nil
+}
+
+// runSubmit creates an experiment from a YAML file, with fields overridden by flags, or from flags only
+func runSubmit(args []string) error {
+	fs := newFlagSet("submit", "")
+	file := fs.String("f", "", "YAML or JSON file of the experiment, - for stdin")
+	name := fs.String("name", "", "Name of the experiment, overrides the file")
+	namespace := fs.String("n", "", "Namespace of the experiment, overrides the file, default if neither is set")
+	algorithm := fs.String("algorithm", "", "Sampling algorithm, e.g., grid, random")
+	objectiveType := fs.String("objective-type", "", "Objective type, maximize or minimize")
+	objectiveMetric := fs.String("objective-metric", "", "Name of the objective metric, e.g., qps")
+	maxTrials := fs.Int("max-trials", 0, "Maximum number of trials")
+	parallelism := fs.Int("parallelism", 0, "Number of concurrent trials")
+	serviceImage := fs.String("service-image", "", "Image of the service under test, required without -f")
+	servicePort := fs.Int("service-port", 8500, "Port of the service under test, used without -f")
+	clientImage := fs.String("client-image", "", "Image of the stress test client, required without -f")
+	clientCommand := fs.String("client-command", "", "Command of the stress test client, space separated, used without -f")
+	params := parameterFlags{}
+	fs.Var(&params, "param", "Tunable parameter <category>:<name>:<type>=<values>, e.g., env:BATCH_SIZE:discrete=1,2,4 "+
+		"or resource:cpu:int=1..4/1, repeatable, appended to the ones of the file")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+
+	pe := &morphlingv1alpha1.ProfilingExperiment{}
+	if *file != "" {
+		var data []byte
+		var err error
+		if *file == "-" {
+			data, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			data, err = ioutil.ReadFile(*file)
+		}
+		if err != nil {
+			return err
+		}
+		if err := yaml.Unmarshal(data, pe); err != nil {
+			return fmt.Errorf("failed to parse %s: %v", *file, err)
+		}
+	} else {
+		if *serviceImage == "" || *clientImage == "" {
+			fs.Usage()
+			return fmt.Errorf("either -f or both -service-image and -client-image are required")
+		}
+		pe.Spec.ServicePodTemplate.Template.Spec = corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:  "service",
+				Image: *serviceImage,
+				Ports: []corev1.ContainerPort{{ContainerPort: int32(*servicePort)}},
+			}},
+			RestartPolicy: corev1.RestartPolicyAlways,
+		}
+		pe.Spec.ClientTemplate.Spec.Template.Spec = corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:    "client",
+				Image:   *clientImage,
+				Command: strings.Fields(*clientCommand),
+			}},
+			RestartPolicy: corev1.RestartPolicyNever,
+		}
+	}
+
+	// Overrides
+	if *name != "" {
+		pe.Name = *name
+	}
+	if *namespace != "" {
+		pe.Namespace = *namespace
+	}
+	if pe.Namespace == "" {
+		pe.Namespace = "default"
+	}
+	if *algorithm != "" {
+		pe.Spec.Algorithm.AlgorithmName = morphlingv1alpha1.AlgorithmName(*algorithm)
+	}
+	if *objectiveType != "" {
+		pe.Spec.Objective.Type = morphlingv1alpha1.ObjectiveType(*objectiveType)
+	}
+	if *objectiveMetric != "" {
+		pe.Spec.Objective.ObjectiveMetricName = *objectiveMetric
+	}
+	if *maxTrials > 0 {
+		n := int32(*maxTrials)
+		pe.Spec.MaxNumTrials = &n
+	}
+	if *parallelism > 0 {
+		n := int32(*parallelism)
+		pe.Spec.Parallelism = &n
+	}
+	pe.Spec.TunableParameters = append(pe.Spec.TunableParameters, params...)
+	if pe.Name == "" {
+		return fmt.Errorf("experiment name is required, set it in the file or with -name")
+	}
+	if len(pe.Spec.TunableParameters) == 0 {
+		return fmt.Errorf("no tunable parameters, set them in the file or with -param")
+	}
+
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	if err := c.Create(context.Background(), pe); err != nil {
+		return err
+	}
+	fmt.Printf("experiment %s/%s submitted\n", pe.Namespace, pe.Name)
+	return nil
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	"github.com/alibaba/morphling/pkg/controllers/util"
+)
+
+// runKillTrial marks a trial as killed, the trial controller then cleans up its service and client job
+func runKillTrial(args []string) error {
+	fs := newFlagSet("kill-trial", "<trial>")
+	namespace := fs.String("n", "default", "Namespace of the trial")
+	positional, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	trial := &morphlingv1alpha1.Trial{}
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: *namespace, Name: positional[0]}, trial); err != nil {
+		return err
+	}
+	if err := util.KillTrial(c, trial, morphlingv1alpha1.TrialKilledByUser, "Trial is killed by user"); err != nil {
+		return err
+	}
+	fmt.Printf("trial %s/%s killed\n", trial.Namespace, trial.Name)
+	return nil
+}
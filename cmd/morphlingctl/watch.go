@@ -0,0 +1,87 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	"github.com/alibaba/morphling/pkg/controllers/util"
+)
+
+// runWatch polls the experiment and prints its condition changes, trial state transitions and new optimal trials,
+// until the experiment completes
+func runWatch(args []string) error {
+	fs := newFlagSet("watch", "<experiment>")
+	namespace := fs.String("n", "default", "Namespace of the experiment")
+	interval := fs.Duration("interval", 2*time.Second, "Polling interval")
+	positional, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	var lastState string
+	var lastOptimal morphlingv1alpha1.TrialResult
+	trialStates := map[string]string{}
+	for {
+		pe, trials, err := getExperimentWithTrials(c, *namespace, positional[0])
+		if err != nil {
+			return err
+		}
+		metricName := pe.Spec.Objective.ObjectiveMetricName
+
+		for i := range trials {
+			trial := &trials[i]
+			state := trialState(trial)
+			if trialStates[trial.Name] == state {
+				continue
+			}
+			trialStates[trial.Name] = state
+			detail := formatAssignments(trial.Spec.SamplingResult)
+			if util.IsCompletedTrial(trial) && trial.Status.TrialResult != nil {
+				detail = fmt.Sprintf("%s=%s", metricName, objectiveValue(trial.Status.TrialResult.ObjectiveMetricsObserved, metricName))
+			}
+			printEvent("trial", trial.Name, state, detail)
+		}
+
+		if optimal := pe.Status.CurrentOptimalTrial; len(optimal.TunableParameters) > 0 && !equality.Semantic.DeepEqual(optimal, lastOptimal) {
+			lastOptimal = optimal
+			printEvent("optimal", pe.Name, objectiveValue(optimal.ObjectiveMetricsObserved, metricName), formatAssignments(optimal.TunableParameters))
+		}
+
+		if state := experimentState(pe); state != lastState {
+			lastState = state
+			printEvent("experiment", pe.Name, state, fmt.Sprintf("%d/%d trials succeeded", pe.Status.TrialsSucceeded, pe.Status.TrialsTotal))
+		}
+		if util.IsCompletedExperiment(pe) {
+			return nil
+		}
+		time.Sleep(*interval)
+	}
+}
+
+func printEvent(kind, name, state, detail string) {
+	fmt.Printf("%s  %-10s  %-40s  %-10s  %s\n", time.Now().Format("15:04:05"), kind, name, state, detail)
+}
@@ -5,6 +5,7 @@ import (
 
 	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"k8s.io/klog"
@@ -24,7 +25,7 @@ type ClientMgr struct {
 	scheme     *runtime.Scheme
 	ctrlCache  cache.Cache
 	ctrlClient client.Client
-	//kubeClient clientset.Interface
+	kubeClient kubernetes.Interface
 }
 
 func Init() *ClientMgr {
@@ -52,6 +53,13 @@ func Init() *ClientMgr {
 		Writer:       c,
 		StatusClient: c,
 	}
+
+	// The typed clientset is needed for subresources the ctrl client does not serve, e.g., pod logs.
+	kubeClient, err := kubernetes.NewForConfig(cmgr.config)
+	if err != nil {
+		klog.Fatal(err)
+	}
+	cmgr.kubeClient = kubeClient
 	return cmgr
 }
 
@@ -66,6 +74,15 @@ func (c *ClientMgr) GetCtrlClient() client.Client {
 	return c.ctrlClient
 }
 
+func (c *ClientMgr) GetKubeClient() kubernetes.Interface {
+	return c.kubeClient
+}
+
+// GetInformer returns the shared informer of the object kind, events are delivered once the cache is started
+func (c *ClientMgr) GetInformer(obj runtime.Object) (cache.Informer, error) {
+	return c.ctrlCache.GetInformer(context.Background(), obj)
+}
+
 // IndexField is Used for filtering Pods from PodList
 func (c *ClientMgr) IndexField(obj runtime.Object, field string, extractValue client.IndexerFunc) error {
 	return c.ctrlCache.IndexField(context.Background(), obj, field, extractValue)
@@ -2,10 +2,10 @@ package handlers
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
 	clientmgr "github.com/alibaba/morphling/console/backend/pkg/client"
 	"github.com/alibaba/morphling/console/backend/pkg/constant"
 	"github.com/alibaba/morphling/console/backend/pkg/utils"
@@ -255,10 +255,23 @@ func (handler *DataHandler) GetConfig() (utils.MorphlingConfig, error) {
 		HsfClientYaml:   config["hsf-client-yaml"],
 		HttpServiceYaml: config["http-service-yaml"],
 		HsfServiceYaml:  config["hsf-service-yaml"],
-		//AlgorithmNames:  nil,
 	}
-	if err := json.Unmarshal([]byte(config["algorithm-names"]), &dataConfig.AlgorithmNames); err != nil {
-		return utils.MorphlingConfig{}, fmt.Errorf("failed to get algorithm-names from morphling-data-config, err: %v", err)
+	if dataConfig.AlgorithmNames, err = handler.GetAlgorithmNames(); err != nil {
+		return utils.MorphlingConfig{}, err
 	}
 	return dataConfig, nil
 }
+
+// Get the names of the algorithms registered by SamplingAlgorithms
+func (handler *DataHandler) GetAlgorithmNames() ([]string, error) {
+	algorithms := &morphlingv1alpha1.SamplingAlgorithmList{}
+	if err := handler.client.List(context.Background(), algorithms); err != nil {
+		return nil, fmt.Errorf("failed to list sampling algorithms, err: %v", err)
+	}
+	names := make([]string, 0, len(algorithms.Items))
+	for i := range algorithms.Items {
+		names = append(names, string(algorithms.Items[i].GetAlgorithmName()))
+	}
+	sort.Strings(names)
+	return names, nil
+}
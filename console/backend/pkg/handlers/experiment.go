@@ -6,39 +6,47 @@ import (
 	"fmt"
 	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
 	clientmgr "github.com/alibaba/morphling/console/backend/pkg/client"
-	"github.com/alibaba/morphling/console/backend/pkg/constant"
 	"github.com/alibaba/morphling/console/backend/pkg/utils"
-	"github.com/alibaba/morphling/pkg/controllers/consts"
+	"github.com/alibaba/morphling/pkg/controllers/trial/dbclient"
+	"github.com/alibaba/morphling/pkg/export"
 	"github.com/ghodss/yaml"
 	"k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog"
-	"math"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sort"
+	"strings"
 )
 
 func NewExperimentHandler(cmgr *clientmgr.ClientMgr) *ExperimentHandler {
 
-	return &ExperimentHandler{client: cmgr.GetCtrlClient()}
+	return NewExperimentHandlerForClient(cmgr.GetCtrlClient(), dbclient.NewTrialDBClient())
+}
+
+// NewExperimentHandlerForClient returns an experiment handler reading experiments and trials with the client
+func NewExperimentHandlerForClient(c client.Client, dbClient dbclient.DBClient) *ExperimentHandler {
+	return &ExperimentHandler{client: c, dbClient: dbClient}
 }
 
 type ExperimentHandler struct {
-	client client.Client
+	client   client.Client
+	dbClient dbclient.DBClient
+}
+
+// ExportExperiment collects trials of the experiment joined with the results stored in db-manager, for export
+func (handler *ExperimentHandler) ExportExperiment(ns, name string) ([]export.Record, error) {
+	return export.Collect(context.Background(), handler.client, handler.dbClient, ns, name)
 }
 
-// GetExperimentList Get experiments
+// GetExperimentList Get experiments, filtered by labels, name prefix, status and start time, then sorted and paginated
 func (handler *ExperimentHandler) GetExperimentList(query *utils.Query) ([]utils.ProfilingExperimentInfo, error) {
 	ctrlClient := handler.client
 
 	peInfoList := make([]utils.ProfilingExperimentInfo, 0)
 
 	// Check Legal
-	if query.StartTime.IsZero() || query.EndTime.IsZero() {
-		return peInfoList, fmt.Errorf("filter time format is incorrect")
+	if !query.StartTime.IsZero() && !query.EndTime.IsZero() && query.EndTime.Before(query.StartTime) {
+		return peInfoList, fmt.Errorf("filter end time should not be earlier than start time")
 	}
 
 	// Options
@@ -46,6 +54,9 @@ func (handler *ExperimentHandler) GetExperimentList(query *utils.Query) ([]utils
 	if query.Namespace != "" && query.Namespace != "All" {
 		options.Namespace = query.Namespace
 	}
+	if query.LabelSelector != nil {
+		options.LabelSelector = query.LabelSelector
+	}
 
 	// List pe
 	expList := &morphlingv1alpha1.ProfilingExperimentList{}
@@ -54,76 +65,41 @@ func (handler *ExperimentHandler) GetExperimentList(query *utils.Query) ([]utils
 	}
 
 	// Filter
-	for _, pe := range expList.Items {
+	rows := make([]experimentRow, 0, len(expList.Items))
+	for i := range expList.Items {
+		pe := &expList.Items[i]
 
 		// Time
-		if pe.Status.StartTime != nil && (pe.Status.StartTime.Time.After(query.EndTime) || pe.Status.StartTime.Time.Before(query.StartTime)) {
+		startTime := getExperimentStartTime(pe)
+		if !query.StartTime.IsZero() && startTime.Before(query.StartTime) {
+			continue
+		}
+		if !query.EndTime.IsZero() && startTime.After(query.EndTime) {
 			continue
 		}
 
 		// Status
-		if query.Status != "" {
-			if pe.Status.Conditions == nil || pe.Status.Conditions[len(pe.Status.Conditions)-1].Type != query.Status {
-				continue
-			}
+		if query.Status != "" && getExperimentStatus(pe) != query.Status {
+			continue
 		}
 
 		// Name
-		if query.Name != "" {
-			if pe.Name != query.Name {
-				continue
-			}
+		if query.Name != "" && !strings.HasPrefix(pe.Name, query.Name) {
+			continue
 		}
 
 		// Selected
-		newPeInfo := utils.ProfilingExperimentInfo{
-			Name:               pe.Name,
-			ExperimentUserID:   constant.DefaultUserId,
-			ExperimentUserName: constant.DefaultUserName,
-			ExperimentStatus:   pe.Status.Conditions[len(pe.Status.Conditions)-1].Type,
-			Namespace:          pe.Namespace,
-			CreateTime:         pe.Status.StartTime.Time.Local().Format(constant.JobInfoTimeFormat),
-		}
-		if pe.Status.CompletionTime != nil {
-			newPeInfo.EndTime = pe.Status.CompletionTime.Time.Local().Format(constant.JobInfoTimeFormat)
-			newPeInfo.DurationTime = utils.GetTimeDiffer(pe.Status.StartTime.Time, pe.Status.CompletionTime.Time)
-		} else {
-			newPeInfo.DurationTime = utils.GetTimeDiffer(pe.Status.StartTime.Time.Local(), metav1.Now().Time)
-		}
-		peInfoList = append(peInfoList, newPeInfo)
+		rows = append(rows, newExperimentRow(pe))
 	}
 
-	// count
-	if len(peInfoList) > 1 {
-		// Order by create timestamp.
-		sort.SliceStable(peInfoList, func(i, j int) bool {
-			if peInfoList[i].CreateTime == (peInfoList[j].CreateTime) {
-				return peInfoList[i].Name < peInfoList[j].Name
-			}
-			return peInfoList[i].CreateTime > (peInfoList[j].CreateTime)
-		})
+	// Sort and paginate
+	rows, err := sortAndPaginateExperimentRows(rows, query.Sort, query.Pagination)
+	if err != nil {
+		return peInfoList, err
 	}
-
-	if query.Pagination != nil && len(peInfoList) > 1 {
-		query.Pagination.Count = len(peInfoList)
-		count := query.Pagination.Count
-		pageNum := query.Pagination.PageNum
-		pageSize := query.Pagination.PageSize
-		startIdx := pageSize * (pageNum - 1)
-		if startIdx < 0 {
-			startIdx = 0
-		}
-		if startIdx > len(peInfoList)-1 {
-			startIdx = len(peInfoList) - 1
-		}
-		endIdx := len(peInfoList)
-		if count > 0 {
-			endIdx = int(math.Min(float64(startIdx+pageSize), float64(endIdx)))
-		}
-		klog.Infof("list jobs with pagination, start index: %d, end index: %d", startIdx, endIdx)
-		peInfoList = peInfoList[startIdx:endIdx]
+	for _, row := range rows {
+		peInfoList = append(peInfoList, row.info)
 	}
-
 	return peInfoList, nil
 }
 
@@ -137,36 +113,33 @@ func (handler *ExperimentHandler) GetExperimentDetail(query *utils.Query) (utils
 		return utils.ProfilingExperimentDetail{}, err
 	}
 
+	row := newExperimentRow(pe)
 	peInfo := utils.ProfilingExperimentDetail{
 		Name:               pe.Name,
 		ExperimentUserID:   "",
 		ExperimentUserName: "",
-		ExperimentStatus:   pe.Status.Conditions[len(pe.Status.Conditions)-1].Type,
+		ExperimentStatus:   row.info.ExperimentStatus,
 		Namespace:          pe.Namespace,
-		CreateTime:         pe.Status.StartTime.Time.Local().Format(constant.JobInfoTimeFormat),
-		//EndTime:            "",
-		//DurationTime:       "",
-		TrialsTotal:     pe.Status.TrialsTotal,
-		TrialsSucceeded: pe.Status.TrialsSucceeded,
-		AlgorithmName:   string(pe.Spec.Algorithm.AlgorithmName),
-		MaxNumTrials:    *pe.Spec.MaxNumTrials,
-		Objective:       string(pe.Spec.Objective.Type) + " " + pe.Spec.Objective.ObjectiveMetricName,
-		Parallelism:     *pe.Spec.Parallelism,
+		CreateTime:         row.info.CreateTime,
+		EndTime:            row.info.EndTime,
+		DurationTime:       row.info.DurationTime,
+		TrialsTotal:        pe.Status.TrialsTotal,
+		TrialsSucceeded:    pe.Status.TrialsSucceeded,
+		AlgorithmName:      string(pe.Spec.Algorithm.AlgorithmName),
+		Objective:          string(pe.Spec.Objective.Type) + " " + pe.Spec.Objective.ObjectiveMetricName,
 		//Parameters:         nil,
 		//Trials:             nil,
 		CurrentOptimalTrials: make([]utils.CurrentOptimalTrial, 0),
 	}
-
-	// EndTime and DurationTime
-	if pe.Status.CompletionTime != nil {
-		peInfo.EndTime = pe.Status.CompletionTime.Time.Local().Format(constant.JobInfoTimeFormat)
-		peInfo.DurationTime = utils.GetTimeDiffer(pe.Status.StartTime.Time, pe.Status.CompletionTime.Time)
-	} else {
-		peInfo.DurationTime = utils.GetTimeDiffer(pe.Status.StartTime.Time.Local(), metav1.Now().Time)
+	if pe.Spec.MaxNumTrials != nil {
+		peInfo.MaxNumTrials = *pe.Spec.MaxNumTrials
+	}
+	if pe.Spec.Parallelism != nil {
+		peInfo.Parallelism = *pe.Spec.Parallelism
 	}
 
 	// CurrentOptimalTrial
-	if pe.Status.CurrentOptimalTrial.TunableParameters != nil {
+	if pe.Status.CurrentOptimalTrial.TunableParameters != nil && len(pe.Status.CurrentOptimalTrial.ObjectiveMetricsObserved) > 0 {
 		peInfo.CurrentOptimalTrials = append(peInfo.CurrentOptimalTrials, utils.CurrentOptimalTrial{
 			ObjectiveName:  pe.Status.CurrentOptimalTrial.ObjectiveMetricsObserved[0].Name,
 			ObjectiveValue: pe.Status.CurrentOptimalTrial.ObjectiveMetricsObserved[0].Value,
@@ -211,71 +184,16 @@ func (handler *ExperimentHandler) GetExperimentDetail(query *utils.Query) (utils
 }
 
 func (handler *ExperimentHandler) getTrialList(name, ns string) ([]utils.TrialSpec, error) {
-	ctrlClient := handler.client
-
-	// Get trials
-	trialList := &morphlingv1alpha1.TrialList{}
-	expLabels := map[string]string{consts.LabelExperimentName: name}
-	listOpt := &client.ListOptions{}
-	sel := labels.SelectorFromSet(expLabels)
-	listOpt.LabelSelector = sel
-	listOpt.Namespace = ns
-
-	if err := ctrlClient.List(context.Background(), trialList, listOpt); err != nil {
+	trials, err := listTrials(handler.client, name, ns)
+	if err != nil {
 		return nil, err
 	}
 
 	trialSpecList := make([]utils.TrialSpec, 0)
-
-	for _, trial := range trialList.Items {
-		succeeded := false
-		for _, condition := range trial.Status.Conditions {
-			if condition.Type == morphlingv1alpha1.TrialSucceeded &&
-				condition.Status == corev1.ConditionTrue {
-				succeeded = true
-			}
-			if condition.Type == morphlingv1alpha1.TrialFailed &&
-				condition.Status == corev1.ConditionTrue {
-				succeeded = true
-			}
-		}
-		var lastTrialCondition string
-		if len(trial.Status.Conditions) > 0 {
-			lastTrialCondition = string(trial.Status.Conditions[len(trial.Status.Conditions)-1].Type)
-		}
-
-		newTrial := utils.TrialSpec{
-			Name:       trial.Name,
-			Status:     lastTrialCondition,
-			CreateTime: trial.Status.StartTime.Time.Local().Format(constant.JobInfoTimeFormat),
-			//ObjectiveName:    trial.Status.TrialResult.ObjectiveMetricsObserved[0].Name,
-			//ObjectiveValue:   trial.Status.TrialResult.ObjectiveMetricsObserved[0].Value,
-			//ParameterSamples: nil,
-		}
-
-		if succeeded {
-			newTrial.ObjectiveName = trial.Status.TrialResult.ObjectiveMetricsObserved[0].Name
-			newTrial.ObjectiveValue = trial.Status.TrialResult.ObjectiveMetricsObserved[0].Value
-		}
-
-		if trial.Spec.SamplingResult != nil {
-			parameterSamples := map[string]string{}
-			for _, samplingResults := range trial.Spec.SamplingResult {
-				parameterSamples[samplingResults.Name] = samplingResults.Value
-			}
-			newTrial.ParameterSamples = parameterSamples
-		}
-		trialSpecList = append(trialSpecList, newTrial)
-	}
-	if len(trialSpecList) > 1 {
-		// Order by create timestamp.
-		sort.SliceStable(trialSpecList, func(i, j int) bool {
-			if trialSpecList[i].Status == (trialSpecList[j].Status) {
-				return trialSpecList[i].CreateTime < trialSpecList[j].CreateTime
-			}
-			return trialSpecList[i].Status > (trialSpecList[j].Status)
-		})
+	for i := range trials {
+		trialSpecList = append(trialSpecList, convertTrialSpec(&trials[i]))
 	}
+	sortTrialSpecs(trialSpecList)
 
 	return trialSpecList, nil
 
@@ -305,6 +223,10 @@ func stringifyFeasibleSpace(space morphlingv1alpha1.FeasibleSpace) string {
 		stringfyResult += startString + "step: " + space.Step
 		startString = ", "
 	}
+	if space.Distribution != "" {
+		stringfyResult += startString + "distribution: " + string(space.Distribution)
+		startString = ", "
+	}
 
 	return stringfyResult
 
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	"github.com/alibaba/morphling/console/backend/pkg/constant"
+	"github.com/alibaba/morphling/console/backend/pkg/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sort"
+	"time"
+)
+
+// Columns of the experiment list that could be sorted by, named after the json fields of ProfilingExperimentInfo
+const (
+	SortByName         = "name"
+	SortByNamespace    = "namespace"
+	SortByStatus       = "peStatus"
+	SortByCreateTime   = "createTime"
+	SortByEndTime      = "endTime"
+	SortByDurationTime = "durationTime"
+)
+
+// sortByCreationTimestamp orders by the immutable creation timestamp, which continue tokens are keyed on when the list
+// is sorted by create time, since the start time shown as create time is set once the experiment starts
+const sortByCreationTimestamp = "creationTimestamp"
+
+// sortKeyTimeFormat keeps the lexical order of sort keys the same as the chronological order
+const sortKeyTimeFormat = "20060102150405.000000000"
+
+// IsValidSortBy checks whether the experiment list could be sorted by the column
+func IsValidSortBy(sortBy string) bool {
+	switch sortBy {
+	case SortByName, SortByNamespace, SortByStatus, SortByCreateTime, SortByEndTime, SortByDurationTime:
+		return true
+	}
+	return false
+}
+
+// IsPageableSortBy checks whether the experiment list could be paged through with continue tokens when sorted by the
+// column. The status, end time and duration change as the experiments run, so that pages sorted by them could skip or
+// repeat experiments.
+func IsPageableSortBy(sortBy string) bool {
+	switch sortBy {
+	case SortByName, SortByNamespace, SortByCreateTime:
+		return true
+	}
+	return false
+}
+
+// experimentRow is an experiment in the list, together with the raw values of its columns for sorting
+type experimentRow struct {
+	info              utils.ProfilingExperimentInfo
+	creationTimestamp time.Time
+	createTime        time.Time
+	endTime           time.Time
+	duration          time.Duration
+}
+
+// continueToken marks the last experiment of the previous page
+type continueToken struct {
+	SortBy    string `json:"sortBy"`
+	Desc      bool   `json:"desc"`
+	Key       string `json:"key"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// newExperimentRow converts an experiment into a list row, experiments which have not started yet are supported
+func newExperimentRow(pe *morphlingv1alpha1.ProfilingExperiment) experimentRow {
+	row := experimentRow{
+		info: utils.ProfilingExperimentInfo{
+			Name:               pe.Name,
+			ExperimentUserID:   constant.DefaultUserId,
+			ExperimentUserName: constant.DefaultUserName,
+			ExperimentStatus:   getExperimentStatus(pe),
+			Namespace:          pe.Namespace,
+		},
+		creationTimestamp: pe.CreationTimestamp.Time,
+		createTime:        getExperimentStartTime(pe),
+	}
+	row.info.CreateTime = row.createTime.Local().Format(constant.JobInfoTimeFormat)
+
+	if pe.Status.CompletionTime != nil {
+		row.endTime = pe.Status.CompletionTime.Time
+		row.info.EndTime = row.endTime.Local().Format(constant.JobInfoTimeFormat)
+		row.duration = row.endTime.Sub(row.createTime)
+		row.info.DurationTime = utils.GetTimeDiffer(row.createTime, row.endTime)
+	} else if pe.Status.StartTime != nil {
+		now := metav1.Now().Time
+		row.duration = now.Sub(row.createTime)
+		row.info.DurationTime = utils.GetTimeDiffer(row.createTime, now)
+	}
+	return row
+}
+
+// getExperimentStatus returns the last condition of the experiment, or empty if it has not been reconciled yet
+func getExperimentStatus(pe *morphlingv1alpha1.ProfilingExperiment) morphlingv1alpha1.ProfilingConditionType {
+	if len(pe.Status.Conditions) == 0 {
+		return ""
+	}
+	return pe.Status.Conditions[len(pe.Status.Conditions)-1].Type
+}
+
+// getExperimentStartTime returns the start time of the experiment, falling back to the creation time if it has not started
+func getExperimentStartTime(pe *morphlingv1alpha1.ProfilingExperiment) time.Time {
+	if pe.Status.StartTime != nil {
+		return pe.Status.StartTime.Time
+	}
+	return pe.CreationTimestamp.Time
+}
+
+func (row *experimentRow) sortKey(sortBy string) string {
+	switch sortBy {
+	case SortByName:
+		return row.info.Name
+	case SortByNamespace:
+		return row.info.Namespace
+	case SortByStatus:
+		return string(row.info.ExperimentStatus)
+	case SortByEndTime:
+		if row.endTime.IsZero() {
+			return ""
+		}
+		return row.endTime.UTC().Format(sortKeyTimeFormat)
+	case SortByDurationTime:
+		return fmt.Sprintf("%020d", row.duration.Nanoseconds())
+	case sortByCreationTimestamp:
+		return row.creationTimestamp.UTC().Format(sortKeyTimeFormat)
+	default:
+		return row.createTime.UTC().Format(sortKeyTimeFormat)
+	}
+}
+
+// lessExperiment orders by the sort key, ties are broken by namespace and name so that the order is total
+func lessExperiment(key1, ns1, name1, key2, ns2, name2 string, desc bool) bool {
+	if key1 != key2 {
+		if desc {
+			return key1 > key2
+		}
+		return key1 < key2
+	}
+	if ns1 != ns2 {
+		return ns1 < ns2
+	}
+	return name1 < name2
+}
+
+func sortExperimentRows(rows []experimentRow, sortBy string, desc bool) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		return lessExperiment(rows[i].sortKey(sortBy), rows[i].info.Namespace, rows[i].info.Name,
+			rows[j].sortKey(sortBy), rows[j].info.Namespace, rows[j].info.Name, desc)
+	})
+}
+
+// sortOptions returns the sort column and order, experiments are ordered by create time descending by default
+func sortOptions(querySort *utils.QuerySort) (string, bool) {
+	if querySort == nil || querySort.SortBy == "" {
+		return SortByCreateTime, true
+	}
+	return querySort.SortBy, querySort.Desc
+}
+
+// pagingSortBy returns the column continue tokens are keyed on, which must not change between pages
+func pagingSortBy(sortBy string) string {
+	if sortBy == SortByCreateTime {
+		return sortByCreationTimestamp
+	}
+	return sortBy
+}
+
+// sortAndPaginateExperimentRows sorts the rows and returns the requested page, and sets the count and next token on the
+// pagination. Paging through with continue tokens is only supported for the columns accepted by IsPageableSortBy.
+func sortAndPaginateExperimentRows(rows []experimentRow, querySort *utils.QuerySort, pagination *utils.QueryPagination) ([]experimentRow, error) {
+	sortBy, desc := sortOptions(querySort)
+	if pagination == nil {
+		sortExperimentRows(rows, sortBy, desc)
+		return rows, nil
+	}
+	pagination.Count = len(rows)
+
+	// Page through with continue tokens
+	if pagination.Limit > 0 || pagination.Continue != "" {
+		if !IsPageableSortBy(sortBy) {
+			return nil, fmt.Errorf("experiments sorted by %s could not be paged through with continue tokens", sortBy)
+		}
+		return paginateExperimentRowsByToken(rows, pagingSortBy(sortBy), desc, pagination)
+	}
+
+	// Page through with page number and size
+	sortExperimentRows(rows, sortBy, desc)
+	if pagination.PageSize <= 0 {
+		return rows, nil
+	}
+	startIdx := pagination.PageSize * (pagination.PageNum - 1)
+	if startIdx < 0 {
+		startIdx = 0
+	}
+	if startIdx > len(rows) {
+		startIdx = len(rows)
+	}
+	endIdx := startIdx + pagination.PageSize
+	if endIdx > len(rows) {
+		endIdx = len(rows)
+	}
+	return rows[startIdx:endIdx], nil
+}
+
+// paginateExperimentRowsByToken sorts the rows by the paging column, and returns the page after the continue token
+func paginateExperimentRowsByToken(rows []experimentRow, sortBy string, desc bool, pagination *utils.QueryPagination) ([]experimentRow, error) {
+	sortExperimentRows(rows, sortBy, desc)
+	startIdx := 0
+	if pagination.Continue != "" {
+		token, err := decodeContinueToken(pagination.Continue)
+		if err != nil {
+			return nil, err
+		}
+		if token.SortBy != sortBy || token.Desc != desc {
+			return nil, fmt.Errorf("continue token was issued for a different sort order")
+		}
+		// Rows are sorted, skip the ones not after the last row of the previous page
+		startIdx = sort.Search(len(rows), func(i int) bool {
+			return lessExperiment(token.Key, token.Namespace, token.Name,
+				rows[i].sortKey(sortBy), rows[i].info.Namespace, rows[i].info.Name, desc)
+		})
+	}
+	endIdx := len(rows)
+	if pagination.Limit > 0 && startIdx+pagination.Limit < endIdx {
+		endIdx = startIdx + pagination.Limit
+	}
+	page := rows[startIdx:endIdx]
+	pagination.NextContinue = ""
+	if endIdx < len(rows) {
+		last := page[len(page)-1]
+		pagination.NextContinue = encodeContinueToken(continueToken{
+			SortBy:    sortBy,
+			Desc:      desc,
+			Key:       last.sortKey(sortBy),
+			Namespace: last.info.Namespace,
+			Name:      last.info.Name,
+		})
+	}
+	return page, nil
+}
+
+func encodeContinueToken(token continueToken) string {
+	data, _ := json.Marshal(token)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeContinueToken(encoded string) (continueToken, error) {
+	token := continueToken{}
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return token, fmt.Errorf("invalid continue token: %v", err)
+	}
+	if err := json.Unmarshal(data, &token); err != nil {
+		return token, fmt.Errorf("invalid continue token: %v", err)
+	}
+	return token, nil
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	"github.com/alibaba/morphling/console/backend/pkg/utils"
+	"github.com/alibaba/morphling/pkg/controllers/util"
+)
+
+var baseTime = time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+
+// newTestExperiment returns an experiment created minutes after the base time, completed after duration if positive,
+// and not started if negative
+func newTestExperiment(name string, minutes int, duration time.Duration, labels map[string]string) *morphlingv1alpha1.ProfilingExperiment {
+	pe := &morphlingv1alpha1.ProfilingExperiment{ObjectMeta: metav1.ObjectMeta{
+		Name:              name,
+		Namespace:         "default",
+		Labels:            labels,
+		CreationTimestamp: metav1.NewTime(baseTime.Add(time.Duration(minutes) * time.Minute)),
+	}}
+	if duration < 0 {
+		return pe
+	}
+	startTime := pe.CreationTimestamp
+	pe.Status.StartTime = &startTime
+	util.MarkExperimentStatusCreated(pe, "created")
+	if duration > 0 {
+		completionTime := metav1.NewTime(startTime.Add(duration))
+		pe.Status.CompletionTime = &completionTime
+		util.MarkExperimentStatusSucceeded(pe, "succeeded")
+	}
+	return pe
+}
+
+func newTestExperimentHandler(t *testing.T, experiments ...*morphlingv1alpha1.ProfilingExperiment) *ExperimentHandler {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, morphlingv1alpha1.AddToScheme(scheme))
+	objs := make([]runtime.Object, 0, len(experiments))
+	for _, pe := range experiments {
+		objs = append(objs, pe)
+	}
+	return &ExperimentHandler{client: fake.NewFakeClientWithScheme(scheme, objs...)}
+}
+
+func experimentNames(infos []utils.ProfilingExperimentInfo) []string {
+	names := make([]string, 0, len(infos))
+	for _, info := range infos {
+		names = append(names, info.Name)
+	}
+	return names
+}
+
+func TestGetExperimentListFilter(t *testing.T) {
+	handler := newTestExperimentHandler(t,
+		newTestExperiment("bert-a", 0, time.Minute, map[string]string{"model": "bert"}),
+		newTestExperiment("bert-b", 1, 0, map[string]string{"model": "bert"}),
+		newTestExperiment("resnet-a", 2, -1, map[string]string{"model": "resnet"}),
+		newTestExperiment("gpt-a", 3, time.Minute, nil),
+	)
+
+	// Label selector
+	selector, err := labels.Parse("model=bert")
+	assert.NoError(t, err)
+	infos, err := handler.GetExperimentList(&utils.Query{LabelSelector: selector})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bert-b", "bert-a"}, experimentNames(infos))
+
+	selector, err = labels.Parse("model")
+	assert.NoError(t, err)
+	infos, err = handler.GetExperimentList(&utils.Query{LabelSelector: selector, Status: morphlingv1alpha1.ProfilingSucceeded})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bert-a"}, experimentNames(infos))
+
+	// Name prefix, including experiments that have not started yet
+	infos, err = handler.GetExperimentList(&utils.Query{Name: "resnet"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"resnet-a"}, experimentNames(infos))
+	assert.Empty(t, infos[0].ExperimentStatus)
+	assert.Empty(t, infos[0].DurationTime)
+}
+
+func TestGetExperimentListSort(t *testing.T) {
+	handler := newTestExperimentHandler(t,
+		newTestExperiment("exp-a", 0, 3*time.Minute, nil),
+		newTestExperiment("exp-b", 1, time.Minute, nil),
+		newTestExperiment("exp-c", 2, 2*time.Minute, nil),
+		newTestExperiment("exp-d", 3, -1, nil),
+	)
+	cases := []struct {
+		sort     *utils.QuerySort
+		expected []string
+	}{
+		{nil, []string{"exp-d", "exp-c", "exp-b", "exp-a"}},
+		{&utils.QuerySort{SortBy: SortByName}, []string{"exp-a", "exp-b", "exp-c", "exp-d"}},
+		{&utils.QuerySort{SortBy: SortByEndTime, Desc: true}, []string{"exp-c", "exp-a", "exp-b", "exp-d"}},
+		{&utils.QuerySort{SortBy: SortByDurationTime}, []string{"exp-d", "exp-b", "exp-c", "exp-a"}},
+	}
+	for _, c := range cases {
+		infos, err := handler.GetExperimentList(&utils.Query{Sort: c.sort})
+		assert.NoError(t, err)
+		assert.Equal(t, c.expected, experimentNames(infos), "sort %+v", c.sort)
+	}
+}
+
+func TestGetExperimentListPaging(t *testing.T) {
+	experiments := []*morphlingv1alpha1.ProfilingExperiment{
+		newTestExperiment("exp-a", 0, 0, nil),
+		newTestExperiment("exp-b", 1, -1, nil),
+		newTestExperiment("exp-c", 2, 0, nil),
+		newTestExperiment("exp-d", 3, 0, nil),
+		newTestExperiment("exp-e", 4, 0, nil),
+	}
+	handler := newTestExperimentHandler(t, experiments...)
+	sortByName := &utils.QuerySort{SortBy: SortByName, Desc: true}
+
+	// Page numbers
+	pagination := &utils.QueryPagination{PageNum: 2, PageSize: 2}
+	infos, err := handler.GetExperimentList(&utils.Query{Sort: &utils.QuerySort{SortBy: SortByName}, Pagination: pagination})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"exp-c", "exp-d"}, experimentNames(infos))
+	assert.Equal(t, 5, pagination.Count)
+
+	// Continue tokens follow the requested sort across pages
+	var seen []string
+	pagination = &utils.QueryPagination{Limit: 2}
+	for {
+		infos, err = handler.GetExperimentList(&utils.Query{Sort: sortByName, Pagination: pagination})
+		assert.NoError(t, err)
+		seen = append(seen, experimentNames(infos)...)
+		if pagination.NextContinue == "" {
+			break
+		}
+		pagination = &utils.QueryPagination{Limit: 2, Continue: pagination.NextContinue}
+	}
+	assert.Equal(t, []string{"exp-e", "exp-d", "exp-c", "exp-b", "exp-a"}, seen)
+
+	// Pages sorted by create time are keyed on the creation timestamp, so that no experiment is skipped or repeated when
+	// one starts between pages
+	pagination = &utils.QueryPagination{Limit: 2}
+	infos, err = handler.GetExperimentList(&utils.Query{Pagination: pagination})
+	assert.NoError(t, err)
+	seen = experimentNames(infos)
+	assert.Equal(t, []string{"exp-e", "exp-d"}, seen)
+
+	pe := experiments[1].DeepCopy()
+	startTime := metav1.NewTime(baseTime.Add(time.Hour))
+	pe.Status.StartTime = &startTime
+	assert.NoError(t, handler.client.Status().Update(context.TODO(), pe))
+
+	for pagination.NextContinue != "" {
+		pagination = &utils.QueryPagination{Limit: 2, Continue: pagination.NextContinue}
+		infos, err = handler.GetExperimentList(&utils.Query{Pagination: pagination})
+		assert.NoError(t, err)
+		seen = append(seen, experimentNames(infos)...)
+	}
+	assert.Equal(t, []string{"exp-e", "exp-d", "exp-c", "exp-b", "exp-a"}, seen)
+
+	// Columns which change as the experiments run could not be paged through with tokens
+	pagination = &utils.QueryPagination{Limit: 2}
+	_, err = handler.GetExperimentList(&utils.Query{Sort: &utils.QuerySort{SortBy: SortByDurationTime}, Pagination: pagination})
+	assert.Error(t, err)
+
+	// Tokens are bound to the sort order
+	pagination = &utils.QueryPagination{Limit: 2}
+	_, err = handler.GetExperimentList(&utils.Query{Pagination: pagination})
+	assert.NoError(t, err)
+	pagination = &utils.QueryPagination{Limit: 2, Continue: pagination.NextContinue}
+	_, err = handler.GetExperimentList(&utils.Query{Sort: &utils.QuerySort{SortBy: SortByName}, Pagination: pagination})
+	assert.Error(t, err)
+}
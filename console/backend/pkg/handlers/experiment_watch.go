@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	clientmgr "github.com/alibaba/morphling/console/backend/pkg/client"
+	"github.com/alibaba/morphling/console/backend/pkg/constant"
+	"github.com/alibaba/morphling/console/backend/pkg/utils"
+	"github.com/alibaba/morphling/pkg/controllers/consts"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sync"
+)
+
+// Types of the events pushed to experiment watchers
+const (
+	EventExperimentStatus  = "experimentStatus"
+	EventExperimentDeleted = "experimentDeleted"
+	EventOptimalTrial      = "optimalTrial"
+	EventTrialStatus       = "trialStatus"
+	EventTrialResult       = "trialResult"
+)
+
+// subscriberBufferSize bounds the events queued for a slow watcher, further events are dropped until it catches up
+const subscriberBufferSize = 64
+
+type subscriber struct {
+	namespace string
+	name      string
+	events    chan utils.ExperimentEvent
+}
+
+// WatchHandler fans out the changes of experiments and trials, observed by the shared informers, to the console watchers
+type WatchHandler struct {
+	lock        sync.RWMutex
+	nextID      int
+	subscribers map[int]*subscriber
+}
+
+func NewWatchHandler(cmgr *clientmgr.ClientMgr) *WatchHandler {
+	expInformer, err := cmgr.GetInformer(&morphlingv1alpha1.ProfilingExperiment{})
+	if err != nil {
+		klog.Errorf("NewWatchHandler Failed to get experiment informer: %v", err)
+		return nil
+	}
+	trialInformer, err := cmgr.GetInformer(&morphlingv1alpha1.Trial{})
+	if err != nil {
+		klog.Errorf("NewWatchHandler Failed to get trial informer: %v", err)
+		return nil
+	}
+	return NewWatchHandlerForInformers(expInformer, trialInformer)
+}
+
+// NewWatchHandlerForInformers returns a watch handler observing the experiment and trial informers
+func NewWatchHandlerForInformers(expInformer, trialInformer cache.Informer) *WatchHandler {
+	handler := &WatchHandler{subscribers: make(map[int]*subscriber)}
+	expInformer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		UpdateFunc: handler.onExperimentUpdate,
+		DeleteFunc: handler.onExperimentDelete,
+	})
+	trialInformer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc:    handler.onTrialAdd,
+		UpdateFunc: handler.onTrialUpdate,
+	})
+	return handler
+}
+
+// Subscribe registers a watcher of the experiment, the returned channel is closed upon Unsubscribe
+func (handler *WatchHandler) Subscribe(ns, name string) (int, <-chan utils.ExperimentEvent) {
+	handler.lock.Lock()
+	defer handler.lock.Unlock()
+
+	id := handler.nextID
+	handler.nextID++
+	sub := &subscriber{namespace: ns, name: name, events: make(chan utils.ExperimentEvent, subscriberBufferSize)}
+	handler.subscribers[id] = sub
+	return id, sub.events
+}
+
+// Unsubscribe removes the watcher
+func (handler *WatchHandler) Unsubscribe(id int) {
+	handler.lock.Lock()
+	defer handler.lock.Unlock()
+
+	if sub, ok := handler.subscribers[id]; ok {
+		close(sub.events)
+		delete(handler.subscribers, id)
+	}
+}
+
+// SubscriberCount returns the number of watchers
+func (handler *WatchHandler) SubscriberCount() int {
+	handler.lock.RLock()
+	defer handler.lock.RUnlock()
+
+	return len(handler.subscribers)
+}
+
+func (handler *WatchHandler) publish(event utils.ExperimentEvent) {
+	handler.lock.RLock()
+	defer handler.lock.RUnlock()
+
+	event.Timestamp = metav1.Now().Local().Format(constant.JobInfoTimeFormat)
+	for id, sub := range handler.subscribers {
+		if sub.namespace != event.Namespace || sub.name != event.ExperimentName {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			klog.Warningf("watcher %d of experiment %s/%s is too slow, drop event %s", id, sub.namespace, sub.name, event.Type)
+		}
+	}
+}
+
+func (handler *WatchHandler) onExperimentUpdate(oldObj, newObj interface{}) {
+	oldExp, ok := oldObj.(*morphlingv1alpha1.ProfilingExperiment)
+	if !ok {
+		return
+	}
+	newExp, ok := newObj.(*morphlingv1alpha1.ProfilingExperiment)
+	if !ok {
+		return
+	}
+
+	// Condition changes
+	if oldCond, newCond := lastExperimentCondition(oldExp), lastExperimentCondition(newExp); newCond != nil &&
+		(oldCond == nil || oldCond.Type != newCond.Type || oldCond.Status != newCond.Status) {
+		handler.publish(utils.ExperimentEvent{
+			Type:           EventExperimentStatus,
+			Namespace:      newExp.Namespace,
+			ExperimentName: newExp.Name,
+			Status:         string(newCond.Type),
+			Message:        newCond.Message,
+		})
+	}
+
+	// A better trial is found
+	if newExp.Status.CurrentOptimalTrial.TunableParameters != nil &&
+		!equality.Semantic.DeepEqual(oldExp.Status.CurrentOptimalTrial, newExp.Status.CurrentOptimalTrial) {
+		handler.publish(utils.ExperimentEvent{
+			Type:             EventOptimalTrial,
+			Namespace:        newExp.Namespace,
+			ExperimentName:   newExp.Name,
+			ParameterSamples: parameterSamples(newExp.Status.CurrentOptimalTrial.TunableParameters),
+			Metrics:          trialMetrics(newExp.Status.CurrentOptimalTrial.ObjectiveMetricsObserved),
+		})
+	}
+}
+
+func (handler *WatchHandler) onExperimentDelete(obj interface{}) {
+	if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	exp, ok := obj.(*morphlingv1alpha1.ProfilingExperiment)
+	if !ok {
+		return
+	}
+	handler.publish(utils.ExperimentEvent{
+		Type:           EventExperimentDeleted,
+		Namespace:      exp.Namespace,
+		ExperimentName: exp.Name,
+	})
+}
+
+func (handler *WatchHandler) onTrialAdd(obj interface{}) {
+	trial, ok := obj.(*morphlingv1alpha1.Trial)
+	if !ok {
+		return
+	}
+	handler.publish(newTrialStatusEvent(trial))
+}
+
+func (handler *WatchHandler) onTrialUpdate(oldObj, newObj interface{}) {
+	oldTrial, ok := oldObj.(*morphlingv1alpha1.Trial)
+	if !ok {
+		return
+	}
+	newTrial, ok := newObj.(*morphlingv1alpha1.Trial)
+	if !ok {
+		return
+	}
+
+	// State transitions
+	if lastTrialConditionType(oldTrial) != lastTrialConditionType(newTrial) {
+		handler.publish(newTrialStatusEvent(newTrial))
+	}
+
+	// New trial results
+	if newTrial.Status.TrialResult != nil && len(newTrial.Status.TrialResult.ObjectiveMetricsObserved) > 0 &&
+		!equality.Semantic.DeepEqual(oldTrial.Status.TrialResult, newTrial.Status.TrialResult) {
+		handler.publish(utils.ExperimentEvent{
+			Type:             EventTrialResult,
+			Namespace:        newTrial.Namespace,
+			ExperimentName:   newTrial.Labels[consts.LabelExperimentName],
+			TrialName:        newTrial.Name,
+			Status:           lastTrialConditionType(newTrial),
+			ParameterSamples: parameterSamples(newTrial.Spec.SamplingResult),
+			Metrics:          trialMetrics(newTrial.Status.TrialResult.ObjectiveMetricsObserved),
+		})
+	}
+}
+
+func newTrialStatusEvent(trial *morphlingv1alpha1.Trial) utils.ExperimentEvent {
+	event := utils.ExperimentEvent{
+		Type:             EventTrialStatus,
+		Namespace:        trial.Namespace,
+		ExperimentName:   trial.Labels[consts.LabelExperimentName],
+		TrialName:        trial.Name,
+		Status:           lastTrialConditionType(trial),
+		ParameterSamples: parameterSamples(trial.Spec.SamplingResult),
+	}
+	if len(trial.Status.Conditions) > 0 {
+		event.Message = trial.Status.Conditions[len(trial.Status.Conditions)-1].Message
+	}
+	return event
+}
+
+func lastExperimentCondition(exp *morphlingv1alpha1.ProfilingExperiment) *morphlingv1alpha1.ProfilingCondition {
+	if len(exp.Status.Conditions) == 0 {
+		return nil
+	}
+	return &exp.Status.Conditions[len(exp.Status.Conditions)-1]
+}
+
+func lastTrialConditionType(trial *morphlingv1alpha1.Trial) string {
+	if len(trial.Status.Conditions) == 0 {
+		return ""
+	}
+	return string(trial.Status.Conditions[len(trial.Status.Conditions)-1].Type)
+}
+
+func parameterSamples(assignments []morphlingv1alpha1.ParameterAssignment) map[string]string {
+	samples := map[string]string{}
+	for _, assignment := range assignments {
+		samples[assignment.Name] = assignment.Value
+	}
+	return samples
+}
+
+func trialMetrics(metrics []morphlingv1alpha1.Metric) []utils.TrialMetric {
+	res := make([]utils.TrialMetric, 0, len(metrics))
+	for _, metric := range metrics {
+		res = append(res, utils.TrialMetric{Name: metric.Name, Value: metric.Value})
+	}
+	return res
+}
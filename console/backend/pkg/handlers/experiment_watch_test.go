@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	toolscache "k8s.io/client-go/tools/cache"
+	fcache "k8s.io/client-go/tools/cache/testing"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	"github.com/alibaba/morphling/console/backend/pkg/utils"
+	"github.com/alibaba/morphling/pkg/controllers/consts"
+	"github.com/alibaba/morphling/pkg/controllers/util"
+)
+
+// newTestInformer runs a shared informer of the objects of the fake source until stop is closed
+func newTestInformer(t *testing.T, obj runtime.Object, stop <-chan struct{}) (*fcache.FakeControllerSource, toolscache.SharedIndexInformer) {
+	source := fcache.NewFakeControllerSource()
+	informer := toolscache.NewSharedIndexInformer(source, obj, 0, toolscache.Indexers{})
+	go informer.Run(stop)
+	assert.True(t, toolscache.WaitForCacheSync(stop, informer.HasSynced))
+	return source, informer
+}
+
+func newWatchedTrial(ns, experiment, name string) *morphlingv1alpha1.Trial {
+	return &morphlingv1alpha1.Trial{ObjectMeta: metav1.ObjectMeta{
+		Name:      name,
+		Namespace: ns,
+		Labels:    map[string]string{consts.LabelExperimentName: experiment},
+	}}
+}
+
+func nextEvent(t *testing.T, events <-chan utils.ExperimentEvent) utils.ExperimentEvent {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received")
+	}
+	return utils.ExperimentEvent{}
+}
+
+func assertNoEvent(t *testing.T, events <-chan utils.ExperimentEvent) {
+	select {
+	case event := <-events:
+		t.Errorf("unexpected event %+v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestWatchHandlerFanOut(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+	expSource, expInformer := newTestInformer(t, &morphlingv1alpha1.ProfilingExperiment{}, stop)
+	trialSource, trialInformer := newTestInformer(t, &morphlingv1alpha1.Trial{}, stop)
+	handler := NewWatchHandlerForInformers(expInformer, trialInformer)
+
+	_, events1 := handler.Subscribe("default", "exp")
+	_, events2 := handler.Subscribe("default", "exp")
+	_, otherNamespace := handler.Subscribe("other", "exp")
+	_, otherName := handler.Subscribe("default", "other")
+	assert.Equal(t, 4, handler.SubscriberCount())
+
+	// Trial events are fanned out to all watchers of its experiment in its namespace
+	trial := newWatchedTrial("default", "exp", "exp-1-0")
+	trial.Spec.SamplingResult = []morphlingv1alpha1.ParameterAssignment{{Name: "cpu", Value: "2"}}
+	trialSource.Add(trial)
+	for _, events := range []<-chan utils.ExperimentEvent{events1, events2} {
+		event := nextEvent(t, events)
+		assert.Equal(t, EventTrialStatus, event.Type)
+		assert.Equal(t, "exp-1-0", event.TrialName)
+		assert.Equal(t, map[string]string{"cpu": "2"}, event.ParameterSamples)
+	}
+
+	trial = trial.DeepCopy()
+	util.MarkTrialStatusSucceeded(trial, corev1.ConditionTrue, "succeeded")
+	trial.Status.TrialResult = &morphlingv1alpha1.TrialResult{ObjectiveMetricsObserved: []morphlingv1alpha1.Metric{{Name: "qps", Value: "10"}}}
+	trialSource.Modify(trial)
+	for _, events := range []<-chan utils.ExperimentEvent{events1, events2} {
+		event := nextEvent(t, events)
+		assert.Equal(t, EventTrialStatus, event.Type)
+		assert.Equal(t, string(morphlingv1alpha1.TrialSucceeded), event.Status)
+		event = nextEvent(t, events)
+		assert.Equal(t, EventTrialResult, event.Type)
+		assert.Equal(t, []utils.TrialMetric{{Name: "qps", Value: "10"}}, event.Metrics)
+	}
+
+	// Experiment condition changes and deletion
+	pe := &morphlingv1alpha1.ProfilingExperiment{ObjectMeta: metav1.ObjectMeta{Name: "exp", Namespace: "default"}}
+	expSource.Add(pe)
+	pe = pe.DeepCopy()
+	util.MarkExperimentStatusRunning(pe, "running")
+	expSource.Modify(pe)
+	for _, events := range []<-chan utils.ExperimentEvent{events1, events2} {
+		event := nextEvent(t, events)
+		assert.Equal(t, EventExperimentStatus, event.Type)
+		assert.Equal(t, string(morphlingv1alpha1.ProfilingRunning), event.Status)
+	}
+	expSource.Delete(pe)
+	for _, events := range []<-chan utils.ExperimentEvent{events1, events2} {
+		assert.Equal(t, EventExperimentDeleted, nextEvent(t, events).Type)
+	}
+
+	// Watchers of other experiments or namespaces receive nothing
+	assertNoEvent(t, otherNamespace)
+	assertNoEvent(t, otherName)
+}
+
+func TestWatchHandlerUnsubscribe(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+	_, expInformer := newTestInformer(t, &morphlingv1alpha1.ProfilingExperiment{}, stop)
+	trialSource, trialInformer := newTestInformer(t, &morphlingv1alpha1.Trial{}, stop)
+	handler := NewWatchHandlerForInformers(expInformer, trialInformer)
+
+	id1, events1 := handler.Subscribe("default", "exp")
+	_, events2 := handler.Subscribe("default", "exp")
+	handler.Unsubscribe(id1)
+	assert.Equal(t, 1, handler.SubscriberCount())
+	_, ok := <-events1
+	assert.False(t, ok)
+
+	// Events are still delivered to the remaining watcher, and unsubscribing twice is a no-op
+	trialSource.Add(newWatchedTrial("default", "exp", "exp-1-0"))
+	assert.Equal(t, "exp-1-0", nextEvent(t, events2).TrialName)
+	handler.Unsubscribe(id1)
+	assert.Equal(t, 1, handler.SubscriberCount())
+}
@@ -3,7 +3,6 @@ package handlers
 import (
 	"context"
 	"fmt"
-	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
 	clientmgr "github.com/alibaba/morphling/console/backend/pkg/client"
 	"github.com/alibaba/morphling/console/backend/pkg/utils"
 	"github.com/google/go-github/v39/github"
@@ -99,7 +98,7 @@ spec:
     lsv.AssociatedExperimentSpec.Objective.ObjectiveMetricName,
     lsv.AssociatedExperimentSpec.Parallelism,
     lsv.AssociatedExperimentSpec.MaxNumTrials,
-    utils.generateTunableParametersYAML(lsv.AssociatedExperimentSpec.TunableParameters),
+    utils.GenerateTunableParametersYAML(lsv.AssociatedExperimentSpec.TunableParameters),
     lsv.ModelName,
 )
 
@@ -109,13 +108,53 @@ spec:
 	klog.Infof("GitHub repo info - Owner: %s, Repo: %s, Branch: %s",
 		gitHubRepoInfo.Owner, gitHubRepoInfo.Repo, gitHubRepoInfo.Branch)
 
-	if err := utils.pushToGitHub(filePath, yamlData, gitHubRepoInfo); err != nil {
+	if err := pushToGitHub(filePath, yamlData, gitHubRepoInfo); err != nil {
 		return fmt.Errorf("failed to push to GitHub: %v", err)
 	}
 
 	return nil
 }
 
+func pushToGitHub(filePath string, content []byte, gitHubRepoInfo utils.GitHubRepoInfo) error {
+	// Setup GitHub client
+	ctx := context.Background()
 
+	client := github.NewClient(oauth2.NewClient(ctx, oauth2.StaticTokenSource(
+		&oauth2.Token{AccessToken: gitHubRepoInfo.AccessToken},
+	)))
 
+	// Check if file exists
+	fileContent, _, _, err := client.Repositories.GetContents(
+		ctx,
+		gitHubRepoInfo.Owner,
+		gitHubRepoInfo.Repo,
+		filePath,
+		&github.RepositoryContentGetOptions{Ref: gitHubRepoInfo.Branch},
+	)
+	if err != nil && !strings.Contains(err.Error(), "404") {
+		klog.Errorf("error checking file existence: %v", err)
+		return err
+	}
+
+	// Prepare commit message
+	commitMessage := fmt.Sprintf("Update %s", filePath)
+	var sha *string
+	if fileContent != nil {
+		sha = fileContent.SHA
+	}
+
+	// Create or update file
+	_, _, err = client.Repositories.CreateFile(ctx, gitHubRepoInfo.Owner, gitHubRepoInfo.Repo, filePath, &github.RepositoryContentFileOptions{
+		Message: &commitMessage,
+		Content: content,
+		SHA:     sha,
+		Branch:  &gitHubRepoInfo.Branch,
+	})
 
+	if err != nil {
+		klog.Errorf("error pushing file to GitHub: %v", err)
+		return err
+	}
+
+	return nil
+}
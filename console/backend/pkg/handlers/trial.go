@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	clientmgr "github.com/alibaba/morphling/console/backend/pkg/client"
+	"github.com/alibaba/morphling/console/backend/pkg/constant"
+	"github.com/alibaba/morphling/console/backend/pkg/utils"
+	"github.com/alibaba/morphling/pkg/controllers/consts"
+	"github.com/alibaba/morphling/pkg/controllers/util"
+	"io"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog"
+	"math"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
+	"strings"
+)
+
+const (
+	// TrialRoleService is the role of the pods running the service under test
+	TrialRoleService = "service"
+	// TrialRoleClient is the role of the pods running the client-side stress test job
+	TrialRoleClient = "client"
+)
+
+func NewTrialHandler(cmgr *clientmgr.ClientMgr) *TrialHandler {
+	return &TrialHandler{client: cmgr.GetCtrlClient(), kubeClient: cmgr.GetKubeClient()}
+}
+
+type TrialHandler struct {
+	client     client.Client
+	kubeClient kubernetes.Interface
+}
+
+// GetTrialList Get trials of an experiment
+func (handler *TrialHandler) GetTrialList(query *utils.TrialQuery) ([]utils.TrialSpec, error) {
+	if query.ExperimentName == "" {
+		return nil, fmt.Errorf("experiment name should not be empty")
+	}
+
+	trials, err := listTrials(handler.client, query.ExperimentName, query.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	// Filter
+	trialSpecList := make([]utils.TrialSpec, 0)
+	for i := range trials {
+		trial := &trials[i]
+
+		// Status
+		if query.Status != "" {
+			lastCondition, err := util.GetLastConditionType(trial)
+			if err != nil || lastCondition != query.Status {
+				continue
+			}
+		}
+
+		// Name
+		if query.Name != "" && !strings.HasPrefix(trial.Name, query.Name) {
+			continue
+		}
+
+		trialSpecList = append(trialSpecList, convertTrialSpec(trial))
+	}
+	sortTrialSpecs(trialSpecList)
+
+	if query.Pagination != nil {
+		query.Pagination.Count = len(trialSpecList)
+		startIdx := query.Pagination.PageSize * (query.Pagination.PageNum - 1)
+		if startIdx < 0 {
+			startIdx = 0
+		}
+		if startIdx > len(trialSpecList) {
+			startIdx = len(trialSpecList)
+		}
+		endIdx := len(trialSpecList)
+		if query.Pagination.PageSize > 0 {
+			endIdx = int(math.Min(float64(startIdx+query.Pagination.PageSize), float64(endIdx)))
+		}
+		trialSpecList = trialSpecList[startIdx:endIdx]
+	}
+	return trialSpecList, nil
+}
+
+// GetTrialDetail Get trial detail, including all conditions and observed metrics
+func (handler *TrialHandler) GetTrialDetail(ns, name string) (utils.TrialDetail, error) {
+	trial := &morphlingv1alpha1.Trial{}
+	if err := handler.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: ns}, trial); err != nil {
+		return utils.TrialDetail{}, err
+	}
+
+	spec := convertTrialSpec(trial)
+	detail := utils.TrialDetail{
+		Name:             trial.Name,
+		Namespace:        trial.Namespace,
+		ExperimentName:   trial.Labels[consts.LabelExperimentName],
+		Status:           spec.Status,
+		CreateTime:       spec.CreateTime,
+		ObjectiveName:    spec.ObjectiveName,
+		ObjectiveValue:   spec.ObjectiveValue,
+		ParameterSamples: spec.ParameterSamples,
+		Metrics:          make([]utils.TrialMetric, 0),
+		Conditions:       make([]utils.TrialCondition, 0),
+		ServiceName:      util.GetServiceName(trial),
+		ClientJobName:    util.GetStressTestJobName(trial),
+	}
+
+	// EndTime and DurationTime
+	if trial.Status.StartTime != nil {
+		if trial.Status.CompletionTime != nil {
+			detail.EndTime = trial.Status.CompletionTime.Time.Local().Format(constant.JobInfoTimeFormat)
+			detail.DurationTime = utils.GetTimeDiffer(trial.Status.StartTime.Time, trial.Status.CompletionTime.Time)
+		} else {
+			detail.DurationTime = utils.GetTimeDiffer(trial.Status.StartTime.Time, metav1.Now().Time)
+		}
+	}
+
+	// Metrics
+	if trial.Status.TrialResult != nil {
+		for _, metric := range trial.Status.TrialResult.ObjectiveMetricsObserved {
+			detail.Metrics = append(detail.Metrics, utils.TrialMetric{Name: metric.Name, Value: metric.Value})
+		}
+	}
+
+	// Conditions
+	for _, condition := range trial.Status.Conditions {
+		detail.Conditions = append(detail.Conditions, utils.TrialCondition{
+			Type:           string(condition.Type),
+			Status:         string(condition.Status),
+			Message:        condition.Message,
+			LastUpdateTime: condition.LastUpdateTime.Time.Local().Format(constant.JobInfoTimeFormat),
+		})
+	}
+	return detail, nil
+}
+
+// GetTrialLogs opens a log stream of the client or service pod of a trial
+func (handler *TrialHandler) GetTrialLogs(query *utils.TrialLogQuery) (io.ReadCloser, error) {
+	trial := &morphlingv1alpha1.Trial{}
+	if err := handler.client.Get(context.TODO(), types.NamespacedName{Name: query.Name, Namespace: query.Namespace}, trial); err != nil {
+		return nil, err
+	}
+
+	var podLabels map[string]string
+	switch query.Role {
+	case TrialRoleService:
+		podLabels = map[string]string{consts.LabelDeploymentName: util.GetServiceDeploymentName(trial)}
+	case TrialRoleClient:
+		// Pods of a job are labeled with the job name by the job controller
+		podLabels = map[string]string{"job-name": util.GetStressTestJobName(trial)}
+	default:
+		return nil, fmt.Errorf("unknown trial pod role %q, should be %s or %s", query.Role, TrialRoleService, TrialRoleClient)
+	}
+
+	podList := &corev1.PodList{}
+	listOpt := &client.ListOptions{LabelSelector: labels.SelectorFromSet(podLabels), Namespace: query.Namespace}
+	if err := handler.client.List(context.Background(), podList, listOpt); err != nil {
+		return nil, err
+	}
+	if len(podList.Items) == 0 {
+		return nil, fmt.Errorf("no %s pod found for trial %s/%s, it may have been cleaned up", query.Role, query.Namespace, query.Name)
+	}
+
+	// Prefer the latest pod, as the previous ones of a job may have been retried
+	sort.SliceStable(podList.Items, func(i, j int) bool {
+		return podList.Items[j].CreationTimestamp.Before(&podList.Items[i].CreationTimestamp)
+	})
+	pod := podList.Items[0]
+
+	logOptions := &corev1.PodLogOptions{
+		Container: query.Container,
+		Follow:    query.Follow,
+		TailLines: query.TailLines,
+	}
+	klog.Infof("stream logs of pod %s/%s for trial %s", pod.Namespace, pod.Name, query.Name)
+	return handler.kubeClient.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, logOptions).Stream(context.Background())
+}
+
+// KillTrial marks a running trial as killed, the trial controller then cleans up its service and client job
+func (handler *TrialHandler) KillTrial(ns, name string) error {
+	trial := &morphlingv1alpha1.Trial{}
+	if err := handler.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: ns}, trial); err != nil {
+		return err
+	}
+	return util.KillTrial(handler.client, trial, morphlingv1alpha1.TrialKilledByUser, "Trial is killed by user")
+}
+
+// listTrials lists trials belonging to the experiment
+func listTrials(ctrlClient client.Client, name, ns string) ([]morphlingv1alpha1.Trial, error) {
+	trialList := &morphlingv1alpha1.TrialList{}
+	expLabels := map[string]string{consts.LabelExperimentName: name}
+	listOpt := &client.ListOptions{}
+	sel := labels.SelectorFromSet(expLabels)
+	listOpt.LabelSelector = sel
+	listOpt.Namespace = ns
+
+	if err := ctrlClient.List(context.Background(), trialList, listOpt); err != nil {
+		return nil, err
+	}
+	return trialList.Items, nil
+}
+
+// convertTrialSpec converts a trial to the summary shown in trial tables
+func convertTrialSpec(trial *morphlingv1alpha1.Trial) utils.TrialSpec {
+	var lastTrialCondition string
+	if len(trial.Status.Conditions) > 0 {
+		lastTrialCondition = string(trial.Status.Conditions[len(trial.Status.Conditions)-1].Type)
+	}
+
+	newTrial := utils.TrialSpec{
+		Name:   trial.Name,
+		Status: lastTrialCondition,
+	}
+	if trial.Status.StartTime != nil {
+		newTrial.CreateTime = trial.Status.StartTime.Time.Local().Format(constant.JobInfoTimeFormat)
+	}
+
+	if (util.IsSucceededTrial(trial) || util.IsFailedTrial(trial)) &&
+		trial.Status.TrialResult != nil && len(trial.Status.TrialResult.ObjectiveMetricsObserved) > 0 {
+		newTrial.ObjectiveName = trial.Status.TrialResult.ObjectiveMetricsObserved[0].Name
+		newTrial.ObjectiveValue = trial.Status.TrialResult.ObjectiveMetricsObserved[0].Value
+	}
+
+	if trial.Spec.SamplingResult != nil {
+		parameterSamples := map[string]string{}
+		for _, samplingResults := range trial.Spec.SamplingResult {
+			parameterSamples[samplingResults.Name] = samplingResults.Value
+		}
+		newTrial.ParameterSamples = parameterSamples
+	}
+	return newTrial
+}
+
+// sortTrialSpecs orders trials by status, then by create timestamp
+func sortTrialSpecs(trialSpecList []utils.TrialSpec) {
+	if len(trialSpecList) > 1 {
+		sort.SliceStable(trialSpecList, func(i, j int) bool {
+			if trialSpecList[i].Status == (trialSpecList[j].Status) {
+				return trialSpecList[i].CreateTime < trialSpecList[j].CreateTime
+			}
+			return trialSpecList[i].Status > (trialSpecList[j].Status)
+		})
+	}
+}
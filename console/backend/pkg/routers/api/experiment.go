@@ -6,8 +6,11 @@ import (
 	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
 	"github.com/alibaba/morphling/console/backend/pkg/handlers"
 	"github.com/alibaba/morphling/console/backend/pkg/utils"
+	"github.com/alibaba/morphling/pkg/export"
 	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/klog"
+	"net/http"
 	"strconv"
 	"time"
 )
@@ -29,9 +32,35 @@ func (ctrl *ExperimentAPIsController) RegisterRoutes(routes *gin.RouterGroup) {
 	experiment.POST("/submitYaml", ctrl.submitYaml)
 	experiment.POST("/submitPars", ctrl.submitPars)
 	experiment.DELETE("/:namespace/:name", ctrl.deleteExperiment)
+	experiment.GET("/export/:namespace/:name", ctrl.exportExperiment)
 
 }
 
+// exportExperiment downloads the trials of an experiment, with sampled parameters and observed metrics, as csv, jsonl or parquet
+func (ctrl *ExperimentAPIsController) exportExperiment(c *gin.Context) {
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+	format, err := export.ParseFormat(c.DefaultQuery("format", string(export.FormatCSV)))
+	if err != nil {
+		handleErr(c, err.Error())
+		return
+	}
+
+	klog.Infof("get /experiment/export with parameters: namespace=%s, name=%s, format=%s", namespace, name, format)
+	records, err := ctrl.experimentHandler.ExportExperiment(namespace, name)
+	if err != nil {
+		handleErr(c, fmt.Sprintf("failed to export experiment, err=%v", err))
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-%s.%s", namespace, name, format))
+	c.Header("Content-Type", format.ContentType())
+	c.Status(http.StatusOK)
+	if err := export.Write(c.Writer, format, records); err != nil {
+		klog.Errorf("failed to write export of experiment %s/%s, err=%v", namespace, name, err)
+	}
+}
+
 func (ctrl *ExperimentAPIsController) deleteExperiment(c *gin.Context) {
 	namespace := c.Param("namespace")
 	name := c.Param("name")
@@ -75,7 +104,7 @@ func (ctrl *ExperimentAPIsController) submitPars(c *gin.Context) {
 
 func (ctrl *ExperimentAPIsController) getExperimentList(c *gin.Context) {
 	var (
-		ns, name, status, curPageNum, curPageSize string
+		ns, name, status, selector, sortBy, order, curPageNum, curPageSize, limit, continueToken string
 	)
 	query := utils.Query{}
 
@@ -86,9 +115,6 @@ func (ctrl *ExperimentAPIsController) getExperimentList(c *gin.Context) {
 			return
 		}
 		query.StartTime = t
-	} else {
-		handleErr(c, "start_time should not be empty")
-		return
 	}
 
 	if endTime := c.Query("end_time"); endTime != "" {
@@ -98,8 +124,6 @@ func (ctrl *ExperimentAPIsController) getExperimentList(c *gin.Context) {
 			return
 		}
 		query.EndTime = t
-	} else {
-		query.EndTime = time.Now()
 	}
 
 	if ns = c.Query("namespace"); ns != "" {
@@ -111,16 +135,40 @@ func (ctrl *ExperimentAPIsController) getExperimentList(c *gin.Context) {
 	if status = c.Query("status"); status != "" {
 		query.Status = morphlingv1alpha1.ProfilingConditionType(status)
 	}
+	if selector = c.Query("label_selector"); selector != "" {
+		sel, err := labels.Parse(selector)
+		if err != nil {
+			handleErr(c, fmt.Sprintf("failed to parse url parameter[label_selector=%s], err=%s", selector, err))
+			return
+		}
+		query.LabelSelector = sel
+	}
+
+	if sortBy = c.Query("sort_by"); sortBy != "" {
+		if !handlers.IsValidSortBy(sortBy) {
+			handleErr(c, fmt.Sprintf("experiments could not be sorted by column[sort_by=%s]", sortBy))
+			return
+		}
+		query.Sort = &utils.QuerySort{SortBy: sortBy, Desc: true}
+	}
+	if order = c.Query("order"); order != "" {
+		if order != "asc" && order != "desc" {
+			handleErr(c, fmt.Sprintf("failed to parse url parameter[order=%s], should be asc or desc", order))
+			return
+		}
+		if query.Sort == nil {
+			query.Sort = &utils.QuerySort{SortBy: handlers.SortByCreateTime}
+		}
+		query.Sort.Desc = order == "desc"
+	}
 
+	query.Pagination = &utils.QueryPagination{}
 	if curPageNum = c.Query("current_page"); curPageNum != "" {
 		pageNum, err := strconv.Atoi(curPageNum)
 		if err != nil {
 			handleErr(c, fmt.Sprintf("failed to parse url parameter[current_page=%s], err=%s", curPageNum, err))
 			return
 		}
-		if query.Pagination == nil {
-			query.Pagination = &utils.QueryPagination{}
-		}
 		query.Pagination.PageNum = pageNum
 	}
 	if curPageSize = c.Query("page_size"); curPageSize != "" {
@@ -129,14 +177,26 @@ func (ctrl *ExperimentAPIsController) getExperimentList(c *gin.Context) {
 			handleErr(c, fmt.Sprintf("failed to parse url parameter[page_size=%s], err=%s", curPageSize, err))
 			return
 		}
-		if query.Pagination == nil {
-			query.Pagination = &utils.QueryPagination{}
-		}
 		query.Pagination.PageSize = pageSize
 	}
+	if limit = c.Query("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l < 0 {
+			handleErr(c, fmt.Sprintf("failed to parse url parameter[limit=%s], should be a non-negative integer", limit))
+			return
+		}
+		query.Pagination.Limit = l
+	}
+	if continueToken = c.Query("continue"); continueToken != "" {
+		query.Pagination.Continue = continueToken
+	}
+	if (query.Pagination.Limit > 0 || query.Pagination.Continue != "") && query.Sort != nil && !handlers.IsPageableSortBy(query.Sort.SortBy) {
+		handleBadRequest(c, fmt.Sprintf("experiments sorted by column[sort_by=%s] could not be paged through with limit or continue, sort by name, namespace or createTime instead", query.Sort.SortBy))
+		return
+	}
 
-	klog.Infof("get /experiment/list with parameters: namespace=%s, name=%s, status=%s, pageNum=%s, pageSize=%s",
-		ns, name, status, curPageNum, curPageSize)
+	klog.Infof("get /experiment/list with parameters: namespace=%s, name=%s, status=%s, labelSelector=%s, sortBy=%s, order=%s, pageNum=%s, pageSize=%s, limit=%s",
+		ns, name, status, selector, sortBy, order, curPageNum, curPageSize, limit)
 
 	peInfos, err := ctrl.experimentHandler.GetExperimentList(&query) // will change the content, e.g., query.Pagination.Count = len(dmoJobs)
 
@@ -145,8 +205,9 @@ func (ctrl *ExperimentAPIsController) getExperimentList(c *gin.Context) {
 		return
 	}
 	utils.Succeed(c, map[string]interface{}{
-		"peInfos": peInfos,
-		"total":   query.Pagination.Count,
+		"peInfos":  peInfos,
+		"total":    query.Pagination.Count,
+		"continue": query.Pagination.NextContinue,
 	})
 }
 
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"fmt"
+	"github.com/alibaba/morphling/console/backend/pkg/handlers"
+	"github.com/alibaba/morphling/console/backend/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"io"
+	"k8s.io/klog"
+	"time"
+)
+
+// heartbeatInterval keeps idle event streams alive through proxies
+const heartbeatInterval = 30 * time.Second
+
+func NewExperimentWatchAPIsController(experimentHandler *handlers.ExperimentHandler, watchHandler *handlers.WatchHandler) *ExperimentWatchAPIsController {
+	return &ExperimentWatchAPIsController{
+		experimentHandler: experimentHandler,
+		watchHandler:      watchHandler,
+	}
+}
+
+type ExperimentWatchAPIsController struct {
+	experimentHandler *handlers.ExperimentHandler
+	watchHandler      *handlers.WatchHandler
+}
+
+func (ctrl *ExperimentWatchAPIsController) RegisterRoutes(routes *gin.RouterGroup) {
+	experiment := routes.Group("/experiment")
+	experiment.GET("/watch/:namespace/:name", ctrl.watchExperiment)
+}
+
+// watchExperiment streams the experiment progress as server-sent events: a snapshot of the experiment detail first,
+// then the condition changes, trial state transitions and new trial results as they happen.
+func (ctrl *ExperimentWatchAPIsController) watchExperiment(c *gin.Context) {
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+
+	if ctrl.watchHandler == nil {
+		handleErr(c, "experiment watch is not available")
+		return
+	}
+	klog.Infof("get /experiment/watch with parameters: namespace=%s, name=%s", namespace, name)
+
+	// Subscribe before taking the snapshot, so that no change is missed in between
+	id, events := ctrl.watchHandler.Subscribe(namespace, name)
+	defer ctrl.watchHandler.Unsubscribe(id)
+
+	peInfo, err := ctrl.experimentHandler.GetExperimentDetail(&utils.Query{Name: name, Namespace: namespace})
+	if err != nil {
+		handleErr(c, fmt.Sprintf("failed to get experiment detail from backend, err=%v", err))
+		return
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Header("X-Accel-Buffering", "no")
+	c.SSEvent("snapshot", peInfo)
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.Type, event)
+			return event.Type != handlers.EventExperimentDeleted
+		case t := <-heartbeat.C:
+			c.SSEvent("heartbeat", t.Unix())
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+	klog.Infof("stop watching experiment %s/%s", namespace, name)
+}
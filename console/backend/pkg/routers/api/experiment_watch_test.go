@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	toolscache "k8s.io/client-go/tools/cache"
+	fcache "k8s.io/client-go/tools/cache/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	"github.com/alibaba/morphling/console/backend/pkg/handlers"
+	"github.com/alibaba/morphling/pkg/controllers/consts"
+)
+
+// streamRecorder is a response recorder safe to read while the stream is written, which notifies the handler when the
+// client disconnects as gin requires
+type streamRecorder struct {
+	*httptest.ResponseRecorder
+	lock   sync.Mutex
+	closed chan bool
+}
+
+func newStreamRecorder() *streamRecorder {
+	return &streamRecorder{ResponseRecorder: httptest.NewRecorder(), closed: make(chan bool, 1)}
+}
+
+func (r *streamRecorder) Write(data []byte) (int, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.ResponseRecorder.Write(data)
+}
+
+func (r *streamRecorder) WriteString(data string) (int, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.ResponseRecorder.WriteString(data)
+}
+
+func (r *streamRecorder) Flush() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.ResponseRecorder.Flush()
+}
+
+func (r *streamRecorder) CloseNotify() <-chan bool {
+	return r.closed
+}
+
+func (r *streamRecorder) body() string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.ResponseRecorder.Body.String()
+}
+
+type watchTest struct {
+	watchHandler *handlers.WatchHandler
+	expSource    *fcache.FakeControllerSource
+	trialSource  *fcache.FakeControllerSource
+	engine       *gin.Engine
+}
+
+func newWatchTest(t *testing.T, stop <-chan struct{}) *watchTest {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, morphlingv1alpha1.AddToScheme(scheme))
+	pe := &morphlingv1alpha1.ProfilingExperiment{ObjectMeta: metav1.ObjectMeta{Name: "exp", Namespace: "default"}}
+	c := fake.NewFakeClientWithScheme(scheme, pe)
+
+	wt := &watchTest{expSource: fcache.NewFakeControllerSource(), trialSource: fcache.NewFakeControllerSource()}
+	expInformer := toolscache.NewSharedIndexInformer(wt.expSource, &morphlingv1alpha1.ProfilingExperiment{}, 0, toolscache.Indexers{})
+	trialInformer := toolscache.NewSharedIndexInformer(wt.trialSource, &morphlingv1alpha1.Trial{}, 0, toolscache.Indexers{})
+	go expInformer.Run(stop)
+	go trialInformer.Run(stop)
+	assert.True(t, toolscache.WaitForCacheSync(stop, expInformer.HasSynced, trialInformer.HasSynced))
+	wt.expSource.Add(pe)
+
+	wt.watchHandler = handlers.NewWatchHandlerForInformers(expInformer, trialInformer)
+	gin.SetMode(gin.TestMode)
+	wt.engine = gin.New()
+	NewExperimentWatchAPIsController(handlers.NewExperimentHandlerForClient(c, nil), wt.watchHandler).RegisterRoutes(wt.engine.Group("/api/v1alpha1"))
+	return wt
+}
+
+// serve streams the watch of the experiment in the background, the returned channel is closed once the handler returns
+func (wt *watchTest) serve(ctx context.Context, rec *streamRecorder) <-chan struct{} {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1alpha1/experiment/watch/default/exp", nil).WithContext(ctx)
+	done := make(chan struct{})
+	go func() {
+		wt.engine.ServeHTTP(rec, req)
+		close(done)
+	}()
+	return done
+}
+
+func waitFor(t *testing.T, msg string, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", msg)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWatchExperimentStream(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+	wt := newWatchTest(t, stop)
+
+	// Two clients watch the experiment at once, and receive the snapshot first
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	defer cancel1()
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	defer cancel2()
+	rec1, rec2 := newStreamRecorder(), newStreamRecorder()
+	done1, done2 := wt.serve(ctx1, rec1), wt.serve(ctx2, rec2)
+	waitFor(t, "watchers", func() bool { return wt.watchHandler.SubscriberCount() == 2 })
+	waitFor(t, "snapshots", func() bool {
+		return strings.HasPrefix(rec1.body(), "event:snapshot\n") && strings.HasPrefix(rec2.body(), "event:snapshot\n")
+	})
+
+	// Trial events of the experiment are streamed to both, the ones of other experiments are not
+	wt.trialSource.Add(&morphlingv1alpha1.Trial{ObjectMeta: metav1.ObjectMeta{
+		Name: "other-1-0", Namespace: "default", Labels: map[string]string{consts.LabelExperimentName: "other"},
+	}})
+	wt.trialSource.Add(&morphlingv1alpha1.Trial{ObjectMeta: metav1.ObjectMeta{
+		Name: "exp-1-0", Namespace: "default", Labels: map[string]string{consts.LabelExperimentName: "exp"},
+	}})
+	for _, rec := range []*streamRecorder{rec1, rec2} {
+		rec := rec
+		waitFor(t, "trial event", func() bool { return strings.Contains(rec.body(), `"trialName":"exp-1-0"`) })
+		assert.Contains(t, rec.body(), "event:"+handlers.EventTrialStatus+"\n")
+		assert.NotContains(t, rec.body(), "other-1-0")
+	}
+	assert.Equal(t, "text/event-stream", rec1.Header().Get("Content-Type"))
+
+	// A client disconnects, its watcher is removed while the other keeps streaming
+	cancel1()
+	rec1.closed <- true
+	waitFor(t, "disconnected stream", func() bool {
+		select {
+		case <-done1:
+			return true
+		default:
+			return false
+		}
+	})
+	assert.Equal(t, 1, wt.watchHandler.SubscriberCount())
+
+	// The stream ends once the experiment is deleted
+	wt.expSource.Delete(&morphlingv1alpha1.ProfilingExperiment{ObjectMeta: metav1.ObjectMeta{Name: "exp", Namespace: "default"}})
+	waitFor(t, "ended stream", func() bool {
+		select {
+		case <-done2:
+			return true
+		default:
+			return false
+		}
+	})
+	assert.Contains(t, rec2.body(), "event:"+handlers.EventExperimentDeleted+"\n")
+	assert.NotContains(t, rec1.body(), handlers.EventExperimentDeleted)
+	assert.Equal(t, 0, wt.watchHandler.SubscriberCount())
+}
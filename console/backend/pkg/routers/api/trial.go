@@ -0,0 +1,170 @@
+package api
+
+import (
+	"fmt"
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	"github.com/alibaba/morphling/console/backend/pkg/handlers"
+	"github.com/alibaba/morphling/console/backend/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"io"
+	"k8s.io/klog"
+	"net/http"
+	"strconv"
+)
+
+func NewTrialAPIsController(trialHandler *handlers.TrialHandler) *TrialAPIsController {
+	return &TrialAPIsController{
+		trialHandler: trialHandler,
+	}
+}
+
+type TrialAPIsController struct {
+	trialHandler *handlers.TrialHandler
+}
+
+func (ctrl *TrialAPIsController) RegisterRoutes(routes *gin.RouterGroup) {
+	trial := routes.Group("/trial")
+	trial.GET("/list", ctrl.getTrialList)
+	trial.GET("/detail", ctrl.getTrialDetail)
+	trial.GET("/logs/:namespace/:name", ctrl.getTrialLogs)
+	trial.POST("/kill/:namespace/:name", ctrl.killTrial)
+}
+
+func (ctrl *TrialAPIsController) getTrialList(c *gin.Context) {
+	var (
+		ns, experimentName, name, status, curPageNum, curPageSize string
+	)
+	query := utils.TrialQuery{}
+
+	if ns = c.Query("namespace"); ns != "" {
+		query.Namespace = ns
+	}
+	if experimentName = c.Query("experiment"); experimentName != "" {
+		query.ExperimentName = experimentName
+	} else {
+		handleErr(c, "experiment should not be empty")
+		return
+	}
+	if name = c.Query("name"); name != "" {
+		query.Name = name
+	}
+	if status = c.Query("status"); status != "" {
+		query.Status = morphlingv1alpha1.TrialConditionType(status)
+	}
+
+	if curPageNum = c.Query("current_page"); curPageNum != "" {
+		pageNum, err := strconv.Atoi(curPageNum)
+		if err != nil {
+			handleErr(c, fmt.Sprintf("failed to parse url parameter[current_page=%s], err=%s", curPageNum, err))
+			return
+		}
+		if query.Pagination == nil {
+			query.Pagination = &utils.QueryPagination{}
+		}
+		query.Pagination.PageNum = pageNum
+	}
+	if curPageSize = c.Query("page_size"); curPageSize != "" {
+		pageSize, err := strconv.Atoi(curPageSize)
+		if err != nil {
+			handleErr(c, fmt.Sprintf("failed to parse url parameter[page_size=%s], err=%s", curPageSize, err))
+			return
+		}
+		if query.Pagination == nil {
+			query.Pagination = &utils.QueryPagination{}
+		}
+		query.Pagination.PageSize = pageSize
+	}
+
+	klog.Infof("get /trial/list with parameters: namespace=%s, experiment=%s, name=%s, status=%s, pageNum=%s, pageSize=%s",
+		ns, experimentName, name, status, curPageNum, curPageSize)
+
+	trials, err := ctrl.trialHandler.GetTrialList(&query)
+	if err != nil {
+		handleErr(c, fmt.Sprintf("failed to list trials from backend, err=%v", err))
+		return
+	}
+	total := len(trials)
+	if query.Pagination != nil {
+		total = query.Pagination.Count
+	}
+	utils.Succeed(c, map[string]interface{}{
+		"trials": trials,
+		"total":  total,
+	})
+}
+
+func (ctrl *TrialAPIsController) getTrialDetail(c *gin.Context) {
+	ns := c.Query("namespace")
+	name := c.Query("name")
+
+	klog.Infof("get /trial/detail with parameters: namespace=%s, name=%s", ns, name)
+
+	trialInfo, err := ctrl.trialHandler.GetTrialDetail(ns, name)
+	if err != nil {
+		handleErr(c, fmt.Sprintf("failed to get trial detail from backend, err=%v", err))
+		return
+	}
+	utils.Succeed(c, map[string]interface{}{
+		"trialInfo": trialInfo,
+	})
+}
+
+func (ctrl *TrialAPIsController) getTrialLogs(c *gin.Context) {
+	query := utils.TrialLogQuery{
+		Namespace: c.Param("namespace"),
+		Name:      c.Param("name"),
+		Role:      c.DefaultQuery("role", handlers.TrialRoleService),
+		Container: c.Query("container"),
+	}
+	if follow := c.Query("follow"); follow != "" {
+		f, err := strconv.ParseBool(follow)
+		if err != nil {
+			handleErr(c, fmt.Sprintf("failed to parse url parameter[follow=%s], err=%s", follow, err))
+			return
+		}
+		query.Follow = f
+	}
+	if tailLines := c.Query("tail_lines"); tailLines != "" {
+		lines, err := strconv.ParseInt(tailLines, 10, 64)
+		if err != nil {
+			handleErr(c, fmt.Sprintf("failed to parse url parameter[tail_lines=%s], err=%s", tailLines, err))
+			return
+		}
+		query.TailLines = &lines
+	}
+
+	klog.Infof("get /trial/logs with parameters: namespace=%s, name=%s, role=%s, container=%s, follow=%v",
+		query.Namespace, query.Name, query.Role, query.Container, query.Follow)
+
+	stream, err := ctrl.trialHandler.GetTrialLogs(&query)
+	if err != nil {
+		handleErr(c, fmt.Sprintf("failed to get trial logs, err=%v", err))
+		return
+	}
+	defer stream.Close()
+
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	buf := make([]byte, 4096)
+	c.Stream(func(w io.Writer) bool {
+		n, err := stream.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return false
+			}
+		}
+		return err == nil
+	})
+}
+
+func (ctrl *TrialAPIsController) killTrial(c *gin.Context) {
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+
+	klog.Infof("post /trial/kill with parameters: namespace=%s, name=%s", namespace, name)
+	if err := ctrl.trialHandler.KillTrial(namespace, name); err != nil {
+		handleErr(c, fmt.Sprintf("failed to kill trial, err: %s", err))
+		return
+	}
+	utils.Succeed(c, nil)
+}
@@ -72,12 +72,14 @@ func InitRouter(cmgr *clientmgr.ClientMgr) *gin.Engine {
 	// Create handlers
 
 	experimentHandler := handlers.NewExperimentHandler(cmgr)
+	trialHandler := handlers.NewTrialHandler(cmgr)
+	watchHandler := handlers.NewWatchHandler(cmgr)
 	dataHandler := handlers.NewDataHandler(cmgr)
 	llmServiceVersionHandler := handlers.NewLLMServiceVersionHandler(cmgr)
 
 	// Register api v1 customized routers.
 	apiV1Routes := r.Group(constant.ApiV1Routes)
-	apiControllers := defaultAPIs(dataHandler, experimentHandler, llmServiceVersionHandler)
+	apiControllers := defaultAPIs(dataHandler, experimentHandler, trialHandler, watchHandler, llmServiceVersionHandler)
 	for _, ctrl := range apiControllers {
 		ctrl.RegisterRoutes(apiV1Routes)
 	}
@@ -85,10 +87,12 @@ func InitRouter(cmgr *clientmgr.ClientMgr) *gin.Engine {
 	return r
 }
 
-func defaultAPIs(dataHandler *handlers.DataHandler, experimentHandler *handlers.ExperimentHandler, llmServiceVersionHandler *handlers.LLMServiceVersionHandler) []APIController {
+func defaultAPIs(dataHandler *handlers.DataHandler, experimentHandler *handlers.ExperimentHandler, trialHandler *handlers.TrialHandler, watchHandler *handlers.WatchHandler, llmServiceVersionHandler *handlers.LLMServiceVersionHandler) []APIController {
 	return []APIController{
 		api.NewDataAPIsController(dataHandler),
 		api.NewExperimentAPIsController(experimentHandler),
+		api.NewExperimentWatchAPIsController(experimentHandler, watchHandler),
+		api.NewTrialAPIsController(trialHandler),
 		api.NewLLMServiceVersionAPIsController(llmServiceVersionHandler),
 	}
 }
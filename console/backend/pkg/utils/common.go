@@ -2,12 +2,15 @@ package utils
 
 import (
 	"bytes"
+	"fmt"
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
 	"github.com/gin-gonic/gin"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/kubernetes/pkg/quota/v1"
 	"math"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -26,6 +29,14 @@ func Failed(c *gin.Context, msg string) {
 	c.Set("failed", true)
 }
 
+func BadRequest(c *gin.Context, msg string) {
+	c.JSONP(http.StatusBadRequest, gin.H{
+		"code": "400",
+		"data": msg,
+	})
+	c.Set("failed", true)
+}
+
 // ComputePodSpecResourceRequest returns the requested resource of the PodSpec
 func ComputePodSpecResourceRequest(spec *v1.PodSpec) v1.ResourceList {
 	result := v1.ResourceList{}
@@ -64,7 +75,8 @@ func GetTimeDiffer(startTime time.Time, endTime time.Time) (differ string) {
 }
 
 
-func generateTunableParametersYAML(params []morphlingv1alpha1.ParameterCategory) string {
+// GenerateTunableParametersYAML renders the tunable parameters as the tunableParameters field of an experiment spec
+func GenerateTunableParametersYAML(params []morphlingv1alpha1.ParameterCategory) string {
     var result strings.Builder
     result.WriteString("  tunableParameters:\n")
     
@@ -92,6 +104,9 @@ func generateTunableParametersYAML(params []morphlingv1alpha1.ParameterCategory)
                 if param.FeasibleSpace.Step != "" {
                     result.WriteString(fmt.Sprintf("            step: \"%s\"\n", param.FeasibleSpace.Step))
                 }
+                if param.FeasibleSpace.Distribution != "" {
+                    result.WriteString(fmt.Sprintf("            distribution: %s\n", param.FeasibleSpace.Distribution))
+                }
             }
         }
     }
@@ -2,6 +2,7 @@ package utils
 
 import (
 	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/labels"
 	"time"
 )
 
@@ -76,6 +77,48 @@ type TrialSpec struct {
 	CreateTime       string            `json:"createTime"`
 }
 
+type TrialCondition struct {
+	Type           string `json:"type"`
+	Status         string `json:"status"`
+	Message        string `json:"message"`
+	LastUpdateTime string `json:"lastUpdateTime"`
+}
+
+type TrialMetric struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type TrialDetail struct {
+	Name             string            `json:"name"`
+	Namespace        string            `json:"namespace"`
+	ExperimentName   string            `json:"experimentName"`
+	Status           string            `json:"Status"`
+	CreateTime       string            `json:"createTime"`
+	EndTime          string            `json:"endTime"`
+	DurationTime     string            `json:"durationTime"`
+	ObjectiveName    string            `json:"objectiveName"`
+	ObjectiveValue   string            `json:"objectiveValue"`
+	ParameterSamples map[string]string `json:"parameterSamples"`
+	Metrics          []TrialMetric     `json:"metrics"`
+	Conditions       []TrialCondition  `json:"conditions"`
+	ServiceName      string            `json:"serviceName"`
+	ClientJobName    string            `json:"clientJobName"`
+}
+
+// ExperimentEvent is a change of an experiment or its trials, pushed to the console as it happens
+type ExperimentEvent struct {
+	Type             string            `json:"type"`
+	Namespace        string            `json:"namespace"`
+	ExperimentName   string            `json:"experimentName"`
+	TrialName        string            `json:"trialName,omitempty"`
+	Status           string            `json:"status,omitempty"`
+	Message          string            `json:"message,omitempty"`
+	ParameterSamples map[string]string `json:"parameterSamples,omitempty"`
+	Metrics          []TrialMetric     `json:"metrics,omitempty"`
+	Timestamp        string            `json:"timestamp"`
+}
+
 type CurrentOptimalTrial struct {
 	ObjectiveName    string            `json:"objectiveName"`
 	ObjectiveValue   string            `json:"objectiveValue"`
@@ -104,19 +147,52 @@ type ProfilingExperimentDetail struct {
 }
 
 type Query struct {
+	// Name is matched as a prefix of experiment names
 	Name      string
 	Namespace string
 	//Region     string
-	Status     morphlingv1alpha1.ProfilingConditionType
-	StartTime  time.Time
-	EndTime    time.Time
-	Pagination *QueryPagination
+	Status        morphlingv1alpha1.ProfilingConditionType
+	StartTime     time.Time
+	EndTime       time.Time
+	LabelSelector labels.Selector
+	Sort          *QuerySort
+	Pagination    *QueryPagination
+}
+
+type QuerySort struct {
+	// SortBy is the json name of the column to sort by, e.g., createTime
+	SortBy string
+	Desc   bool
+}
+
+type TrialQuery struct {
+	Name           string
+	Namespace      string
+	ExperimentName string
+	Status         morphlingv1alpha1.TrialConditionType
+	Pagination     *QueryPagination
+}
+
+type TrialLogQuery struct {
+	Name      string
+	Namespace string
+	// Role is the pod role of the trial, either client or service.
+	Role      string
+	Container string
+	Follow    bool
+	TailLines *int64
 }
 
 type QueryPagination struct {
 	PageNum  int
 	PageSize int
 	Count    int
+	// Limit and Continue page through the list with tokens, they take precedence over PageNum. Tokens are only
+	// supported for lists sorted by name, namespace or create time, which do not change between pages.
+	Limit    int
+	Continue string
+	// NextContinue is the token of the next page, empty if this is the last page
+	NextContinue string
 }
 
 type GitHubRepoInfo struct {
@@ -1,20 +1,52 @@
 import base64
+import itertools
 import logging
+import math
 import operator
 
 import numpy as np
 
 from api.v1alpha1.grpc_proto.grpc_algorithm.python3 import api_pb2
+from pkg.algorithm.v1alpha1.grid.search_space import (Constraint, is_active,
+                                                      parse_number)
 
 logger = logging.getLogger(__name__)
 
+MAX_SAMPLING_ATTEMPTS = 10000
+
 
 class Parameter:
-    def __init__(self, name, space_list):
+    def __init__(self, name, space_list, conditions=(), feasible_range=None):
         self.name = name
         self.space_list = space_list
         self.space_list.sort()
         self.length = int(len(self.space_list))
+        self.conditions = list(conditions)
+        self.feasible_range = feasible_range
+        # A double parameter without a step has its bounds only
+        self.continuous = self.length == 0 and feasible_range is not None
+
+    def sample(self):
+        log_uniform = (
+            self.feasible_range is not None
+            and self.feasible_range.distribution == api_pb2.LOG_UNIFORM
+        )
+        if log_uniform:
+            value = math.exp(
+                np.random.uniform(
+                    math.log(self.feasible_range.min), math.log(self.feasible_range.max)
+                )
+            )
+        elif self.continuous:
+            value = np.random.uniform(self.feasible_range.min, self.feasible_range.max)
+        else:
+            return self.space_list[np.random.randint(self.length)]
+        if self.continuous:
+            return repr(float(value))
+        # Quantized parameters, quantities included, take the nearest value of the feasible space
+        return min(
+            self.space_list, key=lambda v: abs(parse_number(self.name, v) - value)
+        )
 
     def __str__(self):
         return "Parameter(name: {}, list: {})".format(
@@ -37,7 +69,12 @@ class BaseSamplingService(object):
         self.space = []
         self.space_size = 1
         for _par in request.parameters:
-            new_par = Parameter(_par.name, _par.feasible_space)
+            new_par = Parameter(
+                _par.name,
+                _par.feasible_space,
+                _par.conditions,
+                _par.feasible_range if _par.HasField("feasible_range") else None,
+            )
             self.space.append(new_par)
             self.space_size *= new_par.length
         self.space_size = int(self.space_size)
@@ -47,8 +84,59 @@ class BaseSamplingService(object):
 
         for _trial in request.existing_results:
             self.existing_trials[
-                num2str(_trial.parameter_assignments, self.num_pars)
+                num2str(
+                    _trial.parameter_assignments, len(_trial.parameter_assignments)
+                )
             ] = _trial.object_value
+        # Pending trials have no results yet, but must not be sampled again
+        for _pending in request.pending_assignments:
+            self.existing_trials[
+                num2str(_pending.key_values, len(_pending.key_values))
+            ] = -1
+
+        # Conditional parameters and constraints shrink the space to the feasible assignments
+        self.constraints = [Constraint(c) for c in request.constraints]
+        self.feasible = None
+        if any(par.continuous for par in self.space):
+            self.space_size = math.inf
+        elif self.constraints or any(par.conditions for par in self.space):
+            self.feasible = self.feasible_assignments()
+            self.space_size = int(len(self.feasible))
+
+    def activate(self, values):
+        """Map the parameters, in the order of self.space, to the values unless inactive."""
+        active = {}
+        changed = True
+        while changed:
+            changed = False
+            for par, value in zip(self.space, values):
+                if par.name not in active and is_active(par.conditions, active):
+                    active[par.name] = value
+                    changed = True
+        return active
+
+    def to_assignments(self, active):
+        return [
+            api_pb2.KeyValue(key=par.name, value=active[par.name])
+            for par in self.space
+            if par.name in active
+        ]
+
+    def feasible_assignments(self):
+        """List the distinct assignments of the active parameters satisfying the constraints,
+        in the order of the grid search."""
+        feasible = []
+        seen = set()
+        for values in itertools.product(*[par.space_list for par in self.space]):
+            active = self.activate(values)
+            if not all(c.satisfied(active) for c in self.constraints):
+                continue
+            assignments = self.to_assignments(active)
+            key = num2str(assignments, len(assignments))
+            if key not in seen:
+                seen.add(key)
+                feasible.append(assignments)
+        return feasible
 
     def get_assignment(self, request):
         logger.info("-" * 100 + "\n")
@@ -61,7 +149,9 @@ class BaseSamplingService(object):
             return self.get_assignment_random(request)
         return []
 
-    def grid_index_search(self, index):
+    def grid_assignments(self, index):
+        if self.feasible is not None:
+            return self.feasible[index]
         assignments = []
         for i in range(self.num_pars):
             sub_space_size = 1
@@ -75,35 +165,70 @@ class BaseSamplingService(object):
                     key=self.space[i].name, value=self.space[i].space_list[index_]
                 )
             )
-        assert num2str(assignments, self.num_pars) not in self.existing_trials
-        self.existing_trials[num2str(assignments, self.num_pars)] = -1
         return assignments
 
+    def grid_index_search(self, index):
+        """Return the first assignments of the grid from index on which are not tried yet, and the index after them,
+        or None once all assignments are tried."""
+        size = len(self.feasible) if self.feasible is not None else self.space_size
+        while index < size:
+            assignments = self.grid_assignments(index)
+            index += 1
+            key = num2str(assignments, len(assignments))
+            if key not in self.existing_trials:
+                self.existing_trials[key] = -1
+                return assignments, index
+        return None, index
+
+    def exhausted(self):
+        """Return whether all assignments of a finite search space are tried."""
+        if self.space_size == math.inf or len(self.existing_trials) < self.space_size:
+            return False
+        assignments, _ = self.grid_index_search(0)
+        if assignments is None:
+            return True
+        # Untried assignments are only probed, not sampled
+        del self.existing_trials[num2str(assignments, len(assignments))]
+        return False
+
     def random_index_search(self):
-        while True:
-            assignments = []
-            for i in range(self.num_pars):
-                assignments.append(
-                    api_pb2.KeyValue(
-                        key=self.space[i].name,
-                        value=self.space[i].space_list[
-                            np.random.randint(self.space[i].length)
-                        ],
-                    )
-                )
-            if num2str(assignments, self.num_pars) not in self.existing_trials:
-                break
-        assert num2str(assignments, self.num_pars) not in self.existing_trials
-        self.existing_trials[num2str(assignments, self.num_pars)] = -1
-        return assignments
+        """Return random assignments which are not tried yet, or None once all assignments are tried."""
+        if self.feasible is not None:
+            candidates = [
+                assignments
+                for assignments in self.feasible
+                if num2str(assignments, len(assignments)) not in self.existing_trials
+            ]
+            if not candidates:
+                return None
+            assignments = candidates[np.random.randint(len(candidates))]
+            self.existing_trials[num2str(assignments, len(assignments))] = -1
+            return assignments
+        if self.exhausted():
+            return None
+        for _ in range(MAX_SAMPLING_ATTEMPTS):
+            active = self.activate([par.sample() for par in self.space])
+            if not all(c.satisfied(active) for c in self.constraints):
+                continue
+            assignments = self.to_assignments(active)
+            if num2str(assignments, len(assignments)) not in self.existing_trials:
+                self.existing_trials[num2str(assignments, len(assignments))] = -1
+                return assignments
+        raise ValueError(
+            "no new feasible assignment after {} attempts".format(MAX_SAMPLING_ATTEMPTS)
+        )
 
     def get_assignment_grid(self, request):
         assignments_set = []
-        next_assignment_index = int(len(request.existing_results))
+        # Walk the grid in order, skipping the assignments already tried
+        next_assignment_index = 0
         for _ in range(request.required_sampling):
-            assignments = self.grid_index_search(next_assignment_index)
+            assignments, next_assignment_index = self.grid_index_search(
+                next_assignment_index
+            )
+            if assignments is None:
+                break
             assignments_set.append(api_pb2.ParameterAssignments(key_values=assignments))
-            next_assignment_index += 1
             for assignment in assignments:
                 logger.info(
                     "Name = {}, Value = {}, ".format(assignment.key, assignment.value)
@@ -118,6 +243,8 @@ class BaseSamplingService(object):
         assignments_set = []
         for _ in range(request.required_sampling):
             assignments = self.random_index_search()
+            if assignments is None:
+                break
             assignments_set.append(api_pb2.ParameterAssignments(key_values=assignments))
             for assignment in assignments:
                 logger.info(
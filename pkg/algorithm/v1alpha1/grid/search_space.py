@@ -0,0 +1,158 @@
+import operator
+import re
+
+_QUANTITY_SUFFIXES = {
+    "m": 1e-3,
+    "k": 1e3,
+    "M": 1e6,
+    "G": 1e9,
+    "T": 1e12,
+    "P": 1e15,
+    "E": 1e18,
+    "Ki": 2 ** 10,
+    "Mi": 2 ** 20,
+    "Gi": 2 ** 30,
+    "Ti": 2 ** 40,
+    "Pi": 2 ** 50,
+    "Ei": 2 ** 60,
+}
+
+_COMPARISONS = {
+    "<": operator.lt,
+    "<=": operator.le,
+    ">": operator.gt,
+    ">=": operator.ge,
+    "==": operator.eq,
+    "!=": operator.ne,
+}
+
+_ARITHMETICS = {
+    "+": operator.add,
+    "-": operator.sub,
+    "*": operator.mul,
+    "/": operator.truediv,
+}
+
+_TOKEN = re.compile(
+    r"\s*(?:(?P<number>(?:\d+\.?\d*|\.\d+)(?:[eE][+-]?\d+)?)"
+    r"|(?P<name>[A-Za-z_][A-Za-z0-9_.]*)"
+    r"|'(?P<quoted>[^']*)'"
+    r"|(?P<op><=|>=|==|!=|[<>+\-*/()]))"
+)
+
+
+def parse_number(name, value):
+    """Parse the value of a parameter as a number or a resource quantity, e.g. 500m or 2Gi."""
+    try:
+        return float(value)
+    except ValueError:
+        pass
+    for suffix in sorted(_QUANTITY_SUFFIXES, key=len, reverse=True):
+        if value.endswith(suffix):
+            try:
+                return float(value[: -len(suffix)]) * _QUANTITY_SUFFIXES[suffix]
+            except ValueError:
+                break
+    raise ValueError("value {} of parameter {} is not a number".format(value, name))
+
+
+class Constraint:
+    """A cross-parameter constraint comparing two arithmetic expressions, e.g. cpu * replicas <= 32."""
+
+    def __init__(self, expr):
+        self.expr = expr
+        self.tokens = self._tokenize(expr)
+        self.pos = 0
+        self.parameters = []
+        self.left = self._parse_sum()
+        op = self._next()
+        if op not in _COMPARISONS:
+            raise ValueError(
+                "invalid constraint {}: expected a comparison, got {}".format(expr, op)
+            )
+        self.op = _COMPARISONS[op]
+        self.right = self._parse_sum()
+        if self._next() is not None:
+            raise ValueError("invalid constraint {}".format(expr))
+
+    def __str__(self):
+        return self.expr
+
+    def satisfied(self, values):
+        """Evaluate the constraint. Constraints on inactive parameters are satisfied."""
+        if any(name not in values for name in self.parameters):
+            return True
+        return self.op(self.left(values), self.right(values))
+
+    @staticmethod
+    def _tokenize(expr):
+        tokens = []
+        pos = 0
+        while pos < len(expr.rstrip()):
+            m = _TOKEN.match(expr, pos)
+            if m is None:
+                raise ValueError("invalid constraint {}".format(expr))
+            if m.group("number") is not None:
+                tokens.append(("number", float(m.group("number"))))
+            elif m.group("name") is not None:
+                tokens.append(("name", m.group("name")))
+            elif m.group("quoted") is not None:
+                tokens.append(("name", m.group("quoted")))
+            else:
+                tokens.append(("op", m.group("op")))
+            pos = m.end()
+        return tokens
+
+    def _peek(self):
+        if self.pos < len(self.tokens):
+            return self.tokens[self.pos][1]
+        return None
+
+    def _next(self):
+        token = self._peek()
+        self.pos += 1
+        return token
+
+    def _binary(self, parse_operand, ops):
+        left = parse_operand()
+        while self._peek() in ops:
+            fn = _ARITHMETICS[self._next()]
+            right = parse_operand()
+            left = (lambda f, l, r: lambda values: f(l(values), r(values)))(
+                fn, left, right
+            )
+        return left
+
+    def _parse_sum(self):
+        return self._binary(self._parse_product, ("+", "-"))
+
+    def _parse_product(self):
+        return self._binary(self._parse_factor, ("*", "/"))
+
+    def _parse_factor(self):
+        if self.pos >= len(self.tokens):
+            raise ValueError("invalid constraint {}".format(self.expr))
+        kind, value = self.tokens[self.pos]
+        self.pos += 1
+        if kind == "number":
+            return lambda values: value
+        if kind == "name":
+            self.parameters.append(value)
+            return lambda values: parse_number(value, values[value])
+        if value == "-":
+            operand = self._parse_factor()
+            return lambda values: -operand(values)
+        if value == "(":
+            expr = self._parse_sum()
+            if self._next() != ")":
+                raise ValueError("invalid constraint {}".format(self.expr))
+            return expr
+        raise ValueError("invalid constraint {}".format(self.expr))
+
+
+def is_active(conditions, values):
+    """Whether all conditions of a parameter hold for the assigned values."""
+    for condition in conditions:
+        if values.get(condition.parameter) not in condition.values:
+            return False
+    return True
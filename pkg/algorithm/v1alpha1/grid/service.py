@@ -5,6 +5,7 @@ import grpc
 from api.v1alpha1.grpc_proto.grpc_algorithm.python3 import (api_pb2,
                                                             api_pb2_grpc)
 from pkg.algorithm.v1alpha1.grid.base_service import BaseSamplingService
+from pkg.algorithm.v1alpha1.grid.session import SessionStore
 from pkg.algorithm.v1alpha1.internal.base_health_service import HealthServicer
 
 logger = logging.getLogger(__name__)
@@ -20,9 +21,36 @@ def _set_validate_context_error(context, error_message):
     return api_pb2.SamplingValidationResponse()
 
 
+def _set_session_not_found_error(context, session_id, response):
+    context.set_code(grpc.StatusCode.NOT_FOUND)
+    context.set_details("session {} not found".format(session_id))
+    logger.info("session %s not found", session_id)
+    return response
+
+
+def _continuous_parameters(parameters):
+    return [
+        par.name
+        for par in parameters
+        if par.parameter_type == api_pb2.DOUBLE and not par.feasible_space
+    ]
+
+
 class BaseService(api_pb2_grpc.SuggestionServicer, HealthServicer):
-    def __init__(self):
+    def __init__(self, sessions=None):
         super(BaseService, self).__init__()
+        self.sessions = sessions if sessions is not None else SessionStore()
+
+    def CreateSession(self, request, context):
+        self.sessions.create(request.session_id, request.spec)
+        return api_pb2.CreateSessionResponse()
+
+    def ReportResult(self, request, context):
+        if not self.sessions.report(request.session_id, request.results):
+            return _set_session_not_found_error(
+                context, request.session_id, api_pb2.ReportResultResponse()
+            )
+        return api_pb2.ReportResultResponse()
 
     def ValidateAlgorithmSettings(self, request, context):
         algorithm_name = request.algorithm_name
@@ -30,24 +58,53 @@ class BaseService(api_pb2_grpc.SuggestionServicer, HealthServicer):
             return _set_validate_context_error(
                 context, "algorithm {} is not supported".format(algorithm_name)
             )
+        continuous = _continuous_parameters(request.parameters)
+        if algorithm_name == "grid" and continuous:
+            return _set_validate_context_error(
+                context,
+                "grid search requires a step for double parameters {}".format(
+                    ", ".join(continuous)
+                ),
+            )
         return api_pb2.SamplingValidationResponse()
 
     def GetSuggestions(self, request, context):
+        if request.session_id:
+            # The request of a session is sampled with the spec and the results of the session
+            session = self.sessions.get(request.session_id)
+            if session is None:
+                return _set_session_not_found_error(
+                    context, request.session_id, api_pb2.SamplingResponse()
+                )
+            session_request = api_pb2.SamplingRequest()
+            session_request.CopyFrom(session)
+            session_request.required_sampling = request.required_sampling
+            session_request.pending_assignments.extend(request.pending_assignments)
+            request = session_request
 
         if request.algorithm_name in support_algorithms:
+            continuous = _continuous_parameters(request.parameters)
+            if request.algorithm_name == "grid" and continuous:
+                return _set_validate_context_error(
+                    context,
+                    "grid search requires a step for double parameters {}".format(
+                        ", ".join(continuous)
+                    ),
+                )
             service = BaseSamplingService(request)
-            if request.required_sampling + int(len(request.existing_results)) > min(
-                service.space_size, request.sampling_number_specified
-            ):
+            tried = len(request.existing_results) + len(request.pending_assignments)
+            if request.required_sampling + tried > request.sampling_number_specified:
                 return _set_validate_context_error(
                     context,
-                    "space size {} is not enough to provide another {} samplings".format(
-                        min(service.space_size, request.sampling_number_specified),
-                        request.required_sampling,
+                    "max trial count {} is not enough to provide another {} samplings".format(
+                        request.sampling_number_specified, request.required_sampling
                     ),
                 )
+            # The assignments left are returned once fewer than required, with the space reported exhausted
             new_assignments = service.get_assignment(request)
-            return api_pb2.SamplingResponse(assignments_set=new_assignments)
+            return api_pb2.SamplingResponse(
+                assignments_set=new_assignments, exhausted=service.exhausted()
+            )
         else:
             return _set_validate_context_error(
                 context, "algorithm {} is not supported".format(request.algorithm_name)
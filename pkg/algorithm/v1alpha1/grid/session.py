@@ -0,0 +1,102 @@
+import logging
+import os
+import threading
+
+import grpc
+
+from api.v1alpha1.grpc_proto.grpc_algorithm.python3 import api_pb2
+from api.v1alpha1.grpc_proto.grpc_storage.python3 import (session_pb2,
+                                                          session_pb2_grpc)
+
+logger = logging.getLogger(__name__)
+
+
+class SessionStore:
+    """Keeps the sessions of experiments, each as a SamplingRequest of the spec and the results of its experiment.
+
+    Sessions are persisted through the db-manager if a storage stub is given, so that they survive restarts of the
+    algorithm server, and are loaded from it on the first request after a restart.
+    """
+
+    def __init__(self, storage=None):
+        self._storage = storage
+        self._sessions = {}
+        self._lock = threading.RLock()
+
+    def create(self, session_id, spec):
+        """Creates the session, replacing the spec and the results of an existing one"""
+        session = api_pb2.SamplingRequest()
+        session.CopyFrom(spec)
+        session.session_id = ""
+        session.required_sampling = 0
+        del session.pending_assignments[:]
+        with self._lock:
+            self._save(session_id, session)
+
+    def report(self, session_id, results):
+        """Appends the results of trials not reported yet, returning False if the session does not exist"""
+        with self._lock:
+            session = self.get(session_id)
+            if session is None:
+                return False
+            updated = api_pb2.SamplingRequest()
+            updated.CopyFrom(session)
+            reported = {r.trial_name for r in session.existing_results if r.trial_name}
+            for result in results:
+                if result.trial_name and result.trial_name in reported:
+                    continue
+                updated.existing_results.append(result)
+                reported.add(result.trial_name)
+            self._save(session_id, updated)
+            return True
+
+    def get(self, session_id):
+        """Returns the session, or None if it does not exist"""
+        with self._lock:
+            session = self._sessions.get(session_id)
+            if session is None and self._storage is not None:
+                session = self._load(session_id)
+                if session is not None:
+                    self._sessions[session_id] = session
+            return session
+
+    def _save(self, session_id, session):
+        if self._storage is not None:
+            self._storage.SaveSession(
+                session_pb2.SaveSessionRequest(
+                    session_id=session_id, state=session.SerializeToString()
+                )
+            )
+        self._sessions[session_id] = session
+
+    def _load(self, session_id):
+        try:
+            reply = self._storage.GetSession(
+                session_pb2.GetSessionRequest(session_id=session_id)
+            )
+        except grpc.RpcError as e:
+            if e.code() == grpc.StatusCode.NOT_FOUND:
+                return None
+            raise
+        logger.info("session %s loaded from the storage", session_id)
+        return api_pb2.SamplingRequest.FromString(reply.state)
+
+
+def storage_stub(endpoint, tls_dir=None):
+    """Returns the stub of the sessions of the db-manager, with the certificates in tls_dir if set"""
+    if not tls_dir:
+        return session_pb2_grpc.SessionStub(grpc.insecure_channel(endpoint))
+
+    def read(name):
+        path = os.path.join(tls_dir, name)
+        if not os.path.exists(path):
+            return None
+        with open(path, "rb") as f:
+            return f.read()
+
+    credentials = grpc.ssl_channel_credentials(
+        root_certificates=read("ca.crt"),
+        private_key=read("tls.key"),
+        certificate_chain=read("tls.crt"),
+    )
+    return session_pb2_grpc.SessionStub(grpc.secure_channel(endpoint, credentials))
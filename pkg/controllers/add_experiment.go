@@ -25,6 +25,10 @@ import (
 func init() {
 	// AddToManagerFuncs is a list of functions to create controllers and add them to a manager.
 	SetupWithManagerMap[&v1alpha1.ProfilingExperiment{}] = func(mgr controllerruntime.Manager) error {
-		return experiment.NewReconciler(mgr).SetupWithManager(mgr)
+		r := experiment.NewReconciler(mgr)
+		if Recorder != nil {
+			r.Sampling = Recorder.Sampling(r.Sampling)
+		}
+		return r.SetupWithManager(mgr)
 	}
 }
@@ -24,6 +24,10 @@ import (
 
 func init() {
 	SetupWithManagerMap[&v1alpha1.Trial{}] = func(mgr controllerruntime.Manager) error {
-		return trial.NewReconciler(mgr).SetupWithManager(mgr)
+		r := trial.NewReconciler(mgr)
+		if Recorder != nil {
+			r.DBClient = Recorder.DBClient(r.DBClient)
+		}
+		return r.SetupWithManager(mgr)
 	}
 }
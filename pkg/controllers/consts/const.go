@@ -31,6 +31,21 @@ const (
 	DefaultServicePortName = "profile-service"
 	// DefaultMetricValue is the default trial result value, set for failed trials
 	DefaultMetricValue = "0.0"
+	// MetricReplicas is the metric of the replica count of trial service deployments
+	MetricReplicas = "replicas"
+	// MetricCost is the metric of the cost of the resources used by trial service pods, averaged over the client job
+	MetricCost = "cost"
+	// MetricCostPerPrefix prefixes the throughput metric name for the metric of the cost per throughput, e.g.,
+	// cost_per_qps
+	MetricCostPerPrefix = "cost_per_"
+	// MetricAllocatedCost is the metric of the cost of resources allocated to trial service pods, i.e., their requests
+	// or limits rather than their observed usage
+	MetricAllocatedCost = "allocated_cost"
+	// MetricAllocatedCostPerPrefix prefixes the throughput metric name for the metric of the allocated cost per
+	// throughput, e.g., allocated_cost_per_qps
+	MetricAllocatedCostPerPrefix = "allocated_cost_per_"
+	// DefaultThroughputMetricName is the default throughput metric to observe the costs per throughput
+	DefaultThroughputMetricName = "qps"
 	// DefaultSamplingService is the default algorithm k8s service name
 	DefaultSamplingService = "morphling-algorithm-server"
 	// DefaultSamplingPort is the default port of algorithm service.
@@ -21,11 +21,16 @@ import (
 	"k8s.io/klog"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/alibaba/morphling/pkg/recording"
 )
 
 // AddToManagerFuncs is a list of functions to add all Controllers to the Manager
 var SetupWithManagerMap = make(map[runtime.Object]func(mgr manager.Manager) error)
 
+// Recorder records the samplings and trial results of the controllers if set before AddToManager
+var Recorder *recording.Recorder
+
 // AddToManager adds all Controllers to the Manager
 func AddToManager(m manager.Manager) error {
 	for workload, f := range SetupWithManagerMap {
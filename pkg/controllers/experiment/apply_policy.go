@@ -0,0 +1,200 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	"github.com/alibaba/morphling/pkg/controllers/trial"
+	"github.com/alibaba/morphling/pkg/controllers/util"
+)
+
+// DefaultPodTemplatePath is where Deployment, StatefulSet and most workloads embed the pod template
+const DefaultPodTemplatePath = "spec.template"
+
+// +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;patch
+
+// reconcileApplyPolicy applies the optimal parameters to the target workload once the experiment completes, according
+// to the mode of the apply policy. It runs only once, the outcome is kept in the apply status. Transient errors are
+// returned to be retried, while the policy fails if the target is missing or the patch is invalid.
+func (r *ProfilingExperimentReconciler) reconcileApplyPolicy(instance *morphlingv1alpha1.ProfilingExperiment) error {
+	policy := instance.Spec.ApplyPolicy
+	if policy == nil || instance.Status.ApplyStatus != nil {
+		return nil
+	}
+	logger := log.WithValues("Experiment", types.NamespacedName{Name: instance.GetName(), Namespace: instance.GetNamespace()})
+
+	optimal := instance.Status.CurrentOptimalTrial.TunableParameters
+	status := &morphlingv1alpha1.ApplyStatus{LastUpdateTime: metav1.Now(), TunableParameters: optimal}
+	if !util.IsSucceededExperiment(instance) {
+		status.Phase = morphlingv1alpha1.ApplySkipped
+		status.Message = "Experiment did not succeed"
+		instance.Status.ApplyStatus = status
+		return nil
+	}
+	if len(optimal) == 0 {
+		status.Phase = morphlingv1alpha1.ApplyFailed
+		status.Message = "No optimal parameters found"
+		instance.Status.ApplyStatus = status
+		return nil
+	}
+
+	target, err := getApplyTarget(r.Client, policy, instance.Namespace)
+	if err != nil && !errors.IsNotFound(err) {
+		logger.Error(err, "Get apply target error")
+		return err
+	}
+	var patch []byte
+	if err == nil {
+		patch, err = renderApplyPatch(target, policy, optimal)
+	}
+	if err != nil {
+		logger.Error(err, "Render apply patch error")
+		r.recorder.Eventf(instance, corev1.EventTypeWarning, "ApplyFailed", "Failed to render the patch of the apply target: %v", err)
+		status.Phase = morphlingv1alpha1.ApplyFailed
+		status.Message = err.Error()
+		instance.Status.ApplyStatus = status
+		return nil
+	}
+	status.Patch = string(patch)
+	targetName := fmt.Sprintf("%s %s/%s", target.GetKind(), target.GetNamespace(), target.GetName())
+
+	switch policy.Mode {
+	case morphlingv1alpha1.ApplyModeOnSuccess:
+		if err := r.Patch(context.TODO(), target, client.RawPatch(types.MergePatchType, patch)); err != nil {
+			logger.Error(err, "Apply optimal parameters error", "target", targetName)
+			if !errors.IsNotFound(err) && !errors.IsInvalid(err) && !errors.IsBadRequest(err) {
+				return err
+			}
+			r.recorder.Eventf(instance, corev1.EventTypeWarning, "ApplyFailed", "Failed to apply optimal parameters to %s: %v", targetName, err)
+			status.Phase = morphlingv1alpha1.ApplyFailed
+			status.Message = err.Error()
+			break
+		}
+		logger.Info("Optimal parameters applied", "target", targetName)
+		r.recorder.Eventf(instance, corev1.EventTypeNormal, "Applied", "Optimal parameters are applied to %s", targetName)
+		status.Phase = morphlingv1alpha1.ApplyApplied
+		status.Message = fmt.Sprintf("Optimal parameters are applied to %s", targetName)
+	case morphlingv1alpha1.ApplyModePullRequest:
+		r.recorder.Eventf(instance, corev1.EventTypeNormal, "ApplyProposed", "Patch of %s is ready for a pull request", targetName)
+		status.Phase = morphlingv1alpha1.ApplyProposed
+		status.Message = fmt.Sprintf("Patch of %s is ready for a pull request", targetName)
+	default:
+		status.Phase = morphlingv1alpha1.ApplyPending
+		status.Message = fmt.Sprintf("Run morphlingctl apply-best to apply optimal parameters to %s", targetName)
+	}
+	instance.Status.ApplyStatus = status
+	return nil
+}
+
+// RenderApplyPatch embeds the assignments into the pod template of the target workload of the policy, the same way as
+// trial deployments, so that the applied config exactly matches what was tested. It returns the current target and
+// the JSON merge patch to apply, which changes nothing else of the target.
+func RenderApplyPatch(c client.Reader, policy *morphlingv1alpha1.ApplyPolicy, namespace string, assignments []morphlingv1alpha1.ParameterAssignment) (*unstructured.Unstructured, []byte, error) {
+	target, err := getApplyTarget(c, policy, namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	patch, err := renderApplyPatch(target, policy, assignments)
+	if err != nil {
+		return nil, nil, err
+	}
+	return target, patch, nil
+}
+
+// getApplyTarget returns the current target workload of the policy, in the namespace of the experiment by default
+func getApplyTarget(c client.Reader, policy *morphlingv1alpha1.ApplyPolicy, namespace string) (*unstructured.Unstructured, error) {
+	ref := policy.TargetRef
+	if ref.Namespace != "" {
+		namespace = ref.Namespace
+	}
+	target := &unstructured.Unstructured{}
+	target.SetGroupVersionKind(schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind))
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: ref.Name}, target); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// renderApplyPatch returns the JSON merge patch embedding the assignments into the pod template of the target
+func renderApplyPatch(target *unstructured.Unstructured, policy *morphlingv1alpha1.ApplyPolicy, assignments []morphlingv1alpha1.ParameterAssignment) ([]byte, error) {
+	ref := policy.TargetRef
+	path := ref.PodTemplatePath
+	if path == "" {
+		path = DefaultPodTemplatePath
+	}
+	fields := strings.Split(path, ".")
+	raw, found, err := unstructured.NestedMap(target.Object, fields...)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("pod template not found at %s of %s %s/%s", path, ref.Kind, target.GetNamespace(), target.GetName())
+	}
+	template := &corev1.PodTemplateSpec{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, template); err != nil {
+		return nil, err
+	}
+
+	// Diff against the round-tripped template, so that the patch has only the changes of the assignments
+	original, err := runtime.DefaultUnstructuredConverter.ToUnstructured(template)
+	if err != nil {
+		return nil, err
+	}
+	if err := trial.ApplyParameterAssignments(assignments, &template.Spec, policy.Container); err != nil {
+		return nil, err
+	}
+	patched, err := runtime.DefaultUnstructuredConverter.ToUnstructured(template)
+	if err != nil {
+		return nil, err
+	}
+
+	base, updated := target.DeepCopy(), target.DeepCopy()
+	if err := unstructured.SetNestedMap(base.Object, original, fields...); err != nil {
+		return nil, err
+	}
+	if err := unstructured.SetNestedMap(updated.Object, patched, fields...); err != nil {
+		return nil, err
+	}
+	// Scale the target if replicas are tuned
+	for _, a := range assignments {
+		if a.Category != morphlingv1alpha1.CategoryReplicas {
+			continue
+		}
+		replicas, err := strconv.ParseInt(a.Value, 10, 32)
+		if err != nil || replicas < 1 {
+			return nil, fmt.Errorf("invalid replicas %s of parameter %s", a.Value, a.Name)
+		}
+		if err := unstructured.SetNestedField(updated.Object, replicas, "spec", "replicas"); err != nil {
+			return nil, err
+		}
+	}
+	return client.MergeFrom(base).Data(updated)
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	"github.com/alibaba/morphling/pkg/controllers/util"
+)
+
+func TestRenderApplyPatch(t *testing.T) {
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "serving", Namespace: "prod"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{
+					{Name: "model", Image: "model:v1", Env: []corev1.EnvVar{{Name: "BATCH_SIZE", Value: "1"}}},
+					{Name: "sidecar", Image: "sidecar:v1"},
+				}},
+			},
+		},
+	}
+	c := fake.NewFakeClientWithScheme(scheme.Scheme, deploy)
+	policy := &morphlingv1alpha1.ApplyPolicy{
+		TargetRef: morphlingv1alpha1.ApplyTargetReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "serving"},
+		Container: "model",
+	}
+	assignments := []morphlingv1alpha1.ParameterAssignment{
+		{Name: "BATCH_SIZE", Value: "32", Category: morphlingv1alpha1.CategoryEnv},
+		{Name: "cpu", Value: "4", Category: morphlingv1alpha1.CategoryResource},
+	}
+
+	target, patch, err := RenderApplyPatch(c, policy, "prod", assignments)
+	assert.NoError(t, err)
+	assert.Equal(t, "serving", target.GetName())
+
+	// Only the containers of the pod template are patched
+	decoded := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(patch, &decoded))
+	assert.Equal(t, []string{"spec"}, keys(decoded))
+	spec := decoded["spec"].(map[string]interface{})
+	assert.Equal(t, []string{"template"}, keys(spec))
+
+	updated := &appsv1.Deployment{}
+	assert.NoError(t, json.Unmarshal(patch, updated))
+	containers := updated.Spec.Template.Spec.Containers
+	assert.Len(t, containers, 2)
+	assert.Equal(t, []corev1.EnvVar{{Name: "BATCH_SIZE", Value: "32"}}, containers[0].Env)
+	assert.Equal(t, "4", containers[0].Resources.Limits.Cpu().String())
+	assert.Equal(t, "4", containers[0].Resources.Requests.Cpu().String())
+	assert.Empty(t, containers[1].Env)
+
+	// Without the container, parameters are applied to the containers they were profiled in, which the target lacks
+	policy.Container = ""
+	profiled := []morphlingv1alpha1.ParameterAssignment{
+		{Name: "BATCH_SIZE", Value: "32", Category: morphlingv1alpha1.CategoryEnv, Containers: []string{"server"}},
+	}
+	_, _, err = RenderApplyPatch(c, policy, "prod", profiled)
+	assert.Error(t, err)
+	profiled[0].Containers = []string{"model"}
+	_, patch, err = RenderApplyPatch(c, policy, "prod", profiled)
+	assert.NoError(t, err)
+	updated = &appsv1.Deployment{}
+	assert.NoError(t, json.Unmarshal(patch, updated))
+	assert.Equal(t, []corev1.EnvVar{{Name: "BATCH_SIZE", Value: "32"}}, updated.Spec.Template.Spec.Containers[0].Env)
+	assert.Empty(t, updated.Spec.Template.Spec.Containers[1].Env)
+	policy.Container = "model"
+
+	// Targets without a pod template at the path are rejected
+	policy.TargetRef.PodTemplatePath = "spec.jobTemplate"
+	_, _, err = RenderApplyPatch(c, policy, "prod", assignments)
+	assert.Error(t, err)
+}
+
+// unavailableClient fails patches as if the API server were unavailable
+type unavailableClient struct {
+	client.Client
+}
+
+func (c *unavailableClient) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	return errors.NewServiceUnavailable("unavailable")
+}
+
+func TestReconcileApplyPolicy(t *testing.T) {
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "serving", Namespace: "prod"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "model", Image: "model:v1"}}},
+			},
+		},
+	}
+	newInstance := func(name string) *morphlingv1alpha1.ProfilingExperiment {
+		pe := &morphlingv1alpha1.ProfilingExperiment{
+			ObjectMeta: metav1.ObjectMeta{Name: "exp", Namespace: "prod"},
+			Spec: morphlingv1alpha1.ProfilingExperimentSpec{ApplyPolicy: &morphlingv1alpha1.ApplyPolicy{
+				TargetRef: morphlingv1alpha1.ApplyTargetReference{APIVersion: "apps/v1", Kind: "Deployment", Name: name},
+				Mode:      morphlingv1alpha1.ApplyModeOnSuccess,
+			}},
+		}
+		pe.Status.CurrentOptimalTrial.TunableParameters = []morphlingv1alpha1.ParameterAssignment{
+			{Name: "cpu", Value: "4", Category: morphlingv1alpha1.CategoryResource},
+		}
+		util.MarkExperimentStatusSucceeded(pe, "succeeded")
+		return pe
+	}
+	c := fake.NewFakeClientWithScheme(scheme.Scheme, deploy)
+	recorder := record.NewFakeRecorder(10)
+
+	// Transient errors are retried, leaving the apply status unset
+	r := &ProfilingExperimentReconciler{Client: &unavailableClient{c}, recorder: recorder}
+	pe := newInstance("serving")
+	assert.Error(t, r.reconcileApplyPolicy(pe))
+	assert.Nil(t, pe.Status.ApplyStatus)
+
+	r.Client = c
+	assert.NoError(t, r.reconcileApplyPolicy(pe))
+	assert.Equal(t, morphlingv1alpha1.ApplyApplied, pe.Status.ApplyStatus.Phase)
+
+	// Missing targets fail the policy
+	pe = newInstance("missing")
+	assert.NoError(t, r.reconcileApplyPolicy(pe))
+	assert.Equal(t, morphlingv1alpha1.ApplyFailed, pe.Status.ApplyStatus.Phase)
+}
+
+func keys(m map[string]interface{}) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	return res
+}
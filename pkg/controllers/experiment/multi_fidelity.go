@@ -0,0 +1,260 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	"github.com/alibaba/morphling/pkg/controllers/util"
+)
+
+const defaultReductionFactor = 3
+
+func reductionFactor(spec *morphlingv1alpha1.MultiFidelitySpec) int32 {
+	if spec.ReductionFactor != nil {
+		return *spec.ReductionFactor
+	}
+	return defaultReductionFactor
+}
+
+// validateMultiFidelity checks the rungs of successive halving of the experiment are well defined
+func validateMultiFidelity(instance *morphlingv1alpha1.ProfilingExperiment) error {
+	spec := instance.Spec.MultiFidelity
+	if instance.Spec.MaxNumTrials == nil {
+		return fmt.Errorf("multiFidelity requires maxNumTrials, the number of configurations of the first rung")
+	}
+	if spec.Min <= 0 || spec.Max < spec.Min {
+		return fmt.Errorf("multiFidelity requires 0 < min <= max, got min %d and max %d", spec.Min, spec.Max)
+	}
+	if reductionFactor(spec) < 2 {
+		return fmt.Errorf("multiFidelity requires a reduction factor of at least 2, got %d", reductionFactor(spec))
+	}
+	return nil
+}
+
+// rungFidelities returns the fidelities of the rungs, from min multiplied by the reduction factor rung by rung up to
+// max, which is the fidelity of the last rung
+func rungFidelities(spec *morphlingv1alpha1.MultiFidelitySpec) []int32 {
+	var fidelities []int32
+	for f := int64(spec.Min); ; f *= int64(reductionFactor(spec)) {
+		if f >= int64(spec.Max) {
+			return append(fidelities, spec.Max)
+		}
+		fidelities = append(fidelities, int32(f))
+	}
+}
+
+// trialRung returns the rung of the trial, 0 for trials without fidelity
+func trialRung(trial *morphlingv1alpha1.Trial) int32 {
+	if trial.Spec.Fidelity == nil {
+		return 0
+	}
+	return trial.Spec.Fidelity.Rung
+}
+
+// promotedTrialName returns the name of the trial of the configuration of the trial in the rung, i.e., the name of its
+// trial in the first rung suffixed with the rung
+func promotedTrialName(trial *morphlingv1alpha1.Trial, rung int32) string {
+	name := trial.Name
+	if r := trialRung(trial); r > 0 {
+		name = strings.TrimSuffix(name, fmt.Sprintf("-r%d", r))
+	}
+	return fmt.Sprintf("%s-r%d", name, rung)
+}
+
+// rungSize returns the number of trials expected in the rung, i.e., maxNumTrials bounded by the search space for the
+// first rung, and the trials promoted from the former rung for the others
+func rungSize(instance *morphlingv1alpha1.ProfilingExperiment, rung int) int32 {
+	if rung > 0 {
+		return int32(len(instance.Status.Rungs[rung-1].Promoted))
+	}
+	size := *instance.Spec.MaxNumTrials
+	if space := instance.Status.SearchSpace; space != nil {
+		if space.Size > 0 && space.Size < size {
+			size = space.Size
+		}
+		// No more configurations are sampled once the sampler has none left
+		if space.Exhausted && len(instance.Status.PendingSamplings) == 0 && instance.Status.Rungs[0].Trials < size {
+			size = instance.Status.Rungs[0].Trials
+		}
+	}
+	return size
+}
+
+// updateRungs counts the trials of the rungs, and promotes the best trials of a rung once all its trials are completed
+func updateRungs(instance *morphlingv1alpha1.ProfilingExperiment, trials []morphlingv1alpha1.Trial) {
+	fidelities := rungFidelities(instance.Spec.MultiFidelity)
+	byRung := make([][]*morphlingv1alpha1.Trial, len(fidelities))
+	for i := range trials {
+		// Trials killed on suspension are profiled again on resumption, so they do not count toward their rungs
+		if util.GetTrialKilledReason(&trials[i]) == morphlingv1alpha1.TrialKilledOnSuspension {
+			continue
+		}
+		if rung := trialRung(&trials[i]); int(rung) < len(fidelities) {
+			byRung[rung] = append(byRung[rung], &trials[i])
+		}
+	}
+
+	rungs := make([]morphlingv1alpha1.RungStatus, len(fidelities))
+	for k := range rungs {
+		rungs[k] = morphlingv1alpha1.RungStatus{Rung: int32(k), Fidelity: fidelities[k], Trials: int32(len(byRung[k]))}
+		for _, trial := range byRung[k] {
+			if util.IsCompletedTrial(trial) {
+				rungs[k].Completed++
+			}
+		}
+		// Promotions are decided once
+		if k < len(instance.Status.Rungs) {
+			rungs[k].Promoted = instance.Status.Rungs[k].Promoted
+		}
+	}
+	instance.Status.Rungs = rungs
+
+	for k := 0; k < len(rungs)-1; k++ {
+		if rungs[k].Promoted != nil {
+			continue
+		}
+		size := rungSize(instance, k)
+		if size == 0 || rungs[k].Completed < size {
+			break
+		}
+		count := size / reductionFactor(instance.Spec.MultiFidelity)
+		if count < 1 {
+			count = 1
+		}
+		rungs[k].Promoted = promoteTrials(instance, byRung[k], int(count))
+		log.Info("Promote trials", "experiment", instance.Name, "rung", k, "promoted", rungs[k].Promoted)
+	}
+}
+
+// promoteTrials returns the names of the best succeeded trials with results, at most count of them
+func promoteTrials(instance *morphlingv1alpha1.ProfilingExperiment, trials []*morphlingv1alpha1.Trial, count int) []string {
+	type observed struct {
+		name  string
+		value float64
+	}
+	candidates := make([]observed, 0, len(trials))
+	for _, trial := range trials {
+		// Failed trials carry the default metric value, which must not compete with observed ones
+		if !util.IsSucceededTrial(trial) {
+			continue
+		}
+		if value := getObjectiveMetricValue(*trial, instance.Spec.Objective.ObjectiveMetricName); value != nil {
+			candidates = append(candidates, observed{name: trial.Name, value: *value})
+		}
+	}
+	maximize := instance.Spec.Objective.Type == morphlingv1alpha1.ObjectiveTypeMaximize
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].value != candidates[j].value {
+			return (candidates[i].value > candidates[j].value) == maximize
+		}
+		return candidates[i].name < candidates[j].name
+	})
+	promoted := make([]string, 0, count)
+	for i := 0; i < len(candidates) && i < count; i++ {
+		promoted = append(promoted, candidates[i].name)
+	}
+	return promoted
+}
+
+// rungsCompleted returns whether all trials of the last rung are completed, or a rung promoted no trial
+func rungsCompleted(instance *morphlingv1alpha1.ProfilingExperiment) bool {
+	rungs := instance.Status.Rungs
+	for k := range rungs {
+		if size := rungSize(instance, k); rungs[k].Completed < size {
+			return false
+		}
+		if k == len(rungs)-1 || (rungs[k].Promoted != nil && len(rungs[k].Promoted) == 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// reconcileRungs fills the free slots of parallelism with the trials of the lowest rung not created yet. Configurations
+// are sampled for the first rung, and the trials of a rung are created once the former rung has promoted its best
+// trials.
+func (r *ProfilingExperimentReconciler) reconcileRungs(instance *morphlingv1alpha1.ProfilingExperiment, trials []morphlingv1alpha1.Trial) error {
+	slots := *instance.Spec.Parallelism - instance.Status.TrialsPending - instance.Status.TrialsRunning
+	if slots <= 0 {
+		return nil
+	}
+	rungs := instance.Status.Rungs
+	for k := range rungs {
+		size := rungSize(instance, k)
+		if rungs[k].Trials < size {
+			if k == 0 {
+				addCount := size - rungs[k].Trials
+				if addCount > slots {
+					addCount = slots
+				}
+				return r.createTrials(instance, trials, addCount)
+			}
+			return r.createPromotedTrials(instance, trials, int32(k), slots)
+		}
+		if rungs[k].Completed < size {
+			return nil
+		}
+	}
+	return nil
+}
+
+// createPromotedTrials creates the trials of the rung of the configurations promoted from the former rung
+func (r *ProfilingExperimentReconciler) createPromotedTrials(instance *morphlingv1alpha1.ProfilingExperiment, trials []morphlingv1alpha1.Trial, rung int32, slots int32) error {
+	logger := log.WithValues("Experiment", types.NamespacedName{Name: instance.GetName(), Namespace: instance.GetNamespace()})
+
+	existing := make(map[string]*morphlingv1alpha1.Trial, len(trials))
+	for i := range trials {
+		existing[trials[i].Name] = &trials[i]
+	}
+	for _, name := range instance.Status.Rungs[rung-1].Promoted {
+		if slots <= 0 {
+			break
+		}
+		source := existing[name]
+		if source == nil {
+			logger.Info("Promoted trial not found", "trial", name)
+			continue
+		}
+		assignment := &morphlingv1alpha1.TrialAssignment{Name: promotedTrialName(source, rung), ParameterAssignments: source.Spec.SamplingResult}
+		if trial := existing[assignment.Name]; trial != nil {
+			if util.GetTrialKilledReason(trial) != morphlingv1alpha1.TrialKilledOnSuspension {
+				continue
+			}
+			// The trial killed on suspension takes the name of the promoted trial, which is created again once the
+			// deletion is observed
+			logger.Info("Delete promoted trial killed on suspension", "trial", trial.Name, "rung", rung)
+			if err := r.Delete(context.TODO(), trial); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+			continue
+		}
+		logger.Info("Create promoted trial", "trial", assignment.Name, "rung", rung)
+		if err := r.createTrialInstance(instance, assignment, rung); err != nil {
+			return err
+		}
+		slots--
+	}
+	return nil
+}
@@ -0,0 +1,269 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	"github.com/alibaba/morphling/pkg/controllers/util"
+)
+
+func TestRungFidelities(t *testing.T) {
+	eta := int32(2)
+	assert.Equal(t, []int32{10, 30, 90}, rungFidelities(&morphlingv1alpha1.MultiFidelitySpec{Min: 10, Max: 90}))
+	assert.Equal(t, []int32{1, 3, 9, 27, 81, 100}, rungFidelities(&morphlingv1alpha1.MultiFidelitySpec{Min: 1, Max: 100}))
+	assert.Equal(t, []int32{5, 10, 20, 25}, rungFidelities(&morphlingv1alpha1.MultiFidelitySpec{Min: 5, Max: 25, ReductionFactor: &eta}))
+	assert.Equal(t, []int32{5}, rungFidelities(&morphlingv1alpha1.MultiFidelitySpec{Min: 5, Max: 5}))
+}
+
+func TestValidateMultiFidelity(t *testing.T) {
+	maxNumTrials, eta := int32(9), int32(1)
+	pe := &morphlingv1alpha1.ProfilingExperiment{Spec: morphlingv1alpha1.ProfilingExperimentSpec{
+		MultiFidelity: &morphlingv1alpha1.MultiFidelitySpec{Min: 10, Max: 90},
+	}}
+	assert.Error(t, validateMultiFidelity(pe))
+	pe.Spec.MaxNumTrials = &maxNumTrials
+	assert.NoError(t, validateMultiFidelity(pe))
+	pe.Spec.MultiFidelity.Min = 100
+	assert.Error(t, validateMultiFidelity(pe))
+	pe.Spec.MultiFidelity.Min = 10
+	pe.Spec.MultiFidelity.ReductionFactor = &eta
+	assert.Error(t, validateMultiFidelity(pe))
+}
+
+func TestSuccessiveHalving(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, morphlingv1alpha1.AddToScheme(scheme))
+	maxNumTrials, parallelism := int32(9), int32(9)
+	pe := &morphlingv1alpha1.ProfilingExperiment{
+		ObjectMeta: metav1.ObjectMeta{Name: "exp", Namespace: "default", Generation: 1},
+		Spec: morphlingv1alpha1.ProfilingExperimentSpec{
+			TunableParameters: []morphlingv1alpha1.ParameterCategory{{
+				Category: morphlingv1alpha1.CategoryResource,
+				Parameters: []morphlingv1alpha1.ParameterSpec{{
+					Name:          "cpu",
+					ParameterType: morphlingv1alpha1.ParameterTypeDouble,
+					FeasibleSpace: morphlingv1alpha1.FeasibleSpace{Min: "1", Max: "9"},
+				}},
+			}},
+			Objective:     morphlingv1alpha1.ObjectiveSpec{Type: morphlingv1alpha1.ObjectiveTypeMaximize, ObjectiveMetricName: "qps"},
+			MaxNumTrials:  &maxNumTrials,
+			Parallelism:   &parallelism,
+			MultiFidelity: &morphlingv1alpha1.MultiFidelitySpec{Name: "DURATION", Min: 10, Max: 90},
+		},
+	}
+	sampling := &stubSampling{}
+	for i := 0; i < 9; i++ {
+		sampling.assignments = append(sampling.assignments, morphlingv1alpha1.TrialAssignment{ParameterAssignments: cpuAssignment(fmt.Sprint(i + 1))})
+	}
+	c := fake.NewFakeClientWithScheme(scheme)
+	r := &ProfilingExperimentReconciler{Client: c, Scheme: scheme, Sampling: sampling}
+
+	// The qps of a trial is its cpu, so that the configurations with the most cpus are promoted
+	completed := map[string]bool{}
+	reconcile := func() []morphlingv1alpha1.Trial {
+		trials := &morphlingv1alpha1.TrialList{}
+		assert.NoError(t, c.List(context.TODO(), trials, client.InNamespace("default")))
+		for i := range trials.Items {
+			trial := &trials.Items[i]
+			if completed[trial.Name] {
+				util.MarkTrialStatusSucceeded(trial, corev1.ConditionTrue, "succeeded")
+				trial.Status.TrialResult = &morphlingv1alpha1.TrialResult{ObjectiveMetricsObserved: []morphlingv1alpha1.Metric{
+					{Name: "qps", Value: trial.Spec.SamplingResult[0].Value},
+				}}
+			}
+		}
+		updateTrialsSummary(pe, trials)
+		assert.NoError(t, updateSearchSpace(pe, trials.Items))
+		updateRungs(pe, trials.Items)
+		updateExperimentStatusCondition(pe, trials.Items)
+		if !util.IsCompletedExperiment(pe) {
+			assert.NoError(t, r.ReconcileTrials(pe, trials.Items))
+		}
+		return trials.Items
+	}
+	completeAll := func(trials []morphlingv1alpha1.Trial) {
+		for _, trial := range trials {
+			completed[trial.Name] = true
+		}
+	}
+
+	// The first rung samples maxNumTrials configurations at the lowest fidelity
+	reconcile()
+	trials := reconcile()
+	assert.Len(t, trials, 9)
+	assert.Equal(t, &morphlingv1alpha1.TrialFidelity{Name: "DURATION", Value: 10, Rung: 0}, trials[0].Spec.Fidelity)
+	assert.Equal(t, []morphlingv1alpha1.RungStatus{
+		{Rung: 0, Fidelity: 10, Trials: 9},
+		{Rung: 1, Fidelity: 30},
+		{Rung: 2, Fidelity: 90},
+	}, pe.Status.Rungs)
+
+	// The best third of the rung is promoted once all its trials are completed
+	completeAll(trials)
+	reconcile()
+	assert.Equal(t, []string{"exp-1-8", "exp-1-7", "exp-1-6"}, pe.Status.Rungs[0].Promoted)
+	trials = reconcile()
+	assert.Len(t, trials, 12)
+	assert.Equal(t, int32(3), pe.Status.Rungs[1].Trials)
+	assert.Equal(t, 1, sampling.calls)
+	promoted := &morphlingv1alpha1.Trial{}
+	assert.NoError(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "exp-1-8-r1"}, promoted))
+	assert.Equal(t, &morphlingv1alpha1.TrialFidelity{Name: "DURATION", Value: 30, Rung: 1}, promoted.Spec.Fidelity)
+	assert.Equal(t, "9", promoted.Spec.SamplingResult[0].Value)
+
+	// The last rung profiles the best configuration at the highest fidelity
+	completeAll(trials)
+	reconcile()
+	trials = reconcile()
+	assert.Equal(t, []string{"exp-1-8-r1"}, pe.Status.Rungs[1].Promoted)
+	assert.Len(t, trials, 13)
+	assert.False(t, util.IsCompletedExperiment(pe))
+
+	completeAll(trials)
+	reconcile()
+	assert.True(t, util.IsSucceededExperiment(pe))
+	assert.Equal(t, int32(1), pe.Status.Rungs[2].Completed)
+}
+
+func TestPromoteSucceededTrials(t *testing.T) {
+	pe := &morphlingv1alpha1.ProfilingExperiment{Spec: morphlingv1alpha1.ProfilingExperimentSpec{
+		Objective: morphlingv1alpha1.ObjectiveSpec{Type: morphlingv1alpha1.ObjectiveTypeMinimize, ObjectiveMetricName: "latency"},
+	}}
+	newTrial := func(name, latency string, succeeded bool) *morphlingv1alpha1.Trial {
+		trial := &morphlingv1alpha1.Trial{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		trial.Status.TrialResult = &morphlingv1alpha1.TrialResult{ObjectiveMetricsObserved: []morphlingv1alpha1.Metric{
+			{Name: "latency", Value: latency},
+		}}
+		if succeeded {
+			util.MarkTrialStatusSucceeded(trial, corev1.ConditionTrue, "succeeded")
+		} else {
+			util.MarkTrialStatusFailed(trial, "failed")
+		}
+		return trial
+	}
+
+	// The failed trial with the default metric value is not the best under Minimize
+	trials := []*morphlingv1alpha1.Trial{
+		newTrial("exp-1-0", "0.3", true),
+		newTrial("exp-1-1", "0.0", false),
+		newTrial("exp-1-2", "0.2", true),
+	}
+	assert.Equal(t, []string{"exp-1-2"}, promoteTrials(pe, trials, 1))
+	assert.Equal(t, []string{"exp-1-2", "exp-1-0"}, promoteTrials(pe, trials, 3))
+}
+
+func TestSuccessiveHalvingSuspendAndResume(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, morphlingv1alpha1.AddToScheme(scheme))
+	maxNumTrials, parallelism, suspend := int32(3), int32(3), true
+	pe := &morphlingv1alpha1.ProfilingExperiment{
+		ObjectMeta: metav1.ObjectMeta{Name: "exp", Namespace: "default", Generation: 1},
+		Spec: morphlingv1alpha1.ProfilingExperimentSpec{
+			TunableParameters: []morphlingv1alpha1.ParameterCategory{{
+				Category: morphlingv1alpha1.CategoryResource,
+				Parameters: []morphlingv1alpha1.ParameterSpec{{
+					Name:          "cpu",
+					ParameterType: morphlingv1alpha1.ParameterTypeDouble,
+					FeasibleSpace: morphlingv1alpha1.FeasibleSpace{Min: "1", Max: "9"},
+				}},
+			}},
+			Objective:     morphlingv1alpha1.ObjectiveSpec{Type: morphlingv1alpha1.ObjectiveTypeMaximize, ObjectiveMetricName: "qps"},
+			MaxNumTrials:  &maxNumTrials,
+			Parallelism:   &parallelism,
+			MultiFidelity: &morphlingv1alpha1.MultiFidelitySpec{Name: "DURATION", Min: 10, Max: 30},
+		},
+	}
+	util.MarkExperimentStatusCreated(pe, "created")
+	sampling := &stubSampling{assignments: []morphlingv1alpha1.TrialAssignment{
+		{ParameterAssignments: cpuAssignment("1")},
+		{ParameterAssignments: cpuAssignment("2")},
+		{ParameterAssignments: cpuAssignment("3")},
+	}}
+	c := fake.NewFakeClientWithScheme(scheme)
+	r := &ProfilingExperimentReconciler{Client: c, Scheme: scheme, Sampling: sampling}
+
+	// The qps of a trial is its cpu
+	getTrial := func(name string) *morphlingv1alpha1.Trial {
+		trial := &morphlingv1alpha1.Trial{}
+		assert.NoError(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: name}, trial))
+		return trial
+	}
+	complete := func(name string) {
+		trial := getTrial(name)
+		util.MarkTrialStatusSucceeded(trial, corev1.ConditionTrue, "succeeded")
+		trial.Status.TrialResult = &morphlingv1alpha1.TrialResult{ObjectiveMetricsObserved: []morphlingv1alpha1.Metric{
+			{Name: "qps", Value: trial.Spec.SamplingResult[0].Value},
+		}}
+		assert.NoError(t, c.Status().Update(context.TODO(), trial))
+	}
+	suspendAndResume := func() {
+		suspend = true
+		pe.Spec.Suspend, pe.Spec.SuspendPolicy, pe.Generation = &suspend, morphlingv1alpha1.SuspendPolicyKill, pe.Generation+1
+		assert.NoError(t, r.ReconcileExperiment(pe))
+		assert.NoError(t, r.ReconcileExperiment(pe))
+		assert.True(t, util.IsSuspendedExperiment(pe))
+		suspend = false
+		pe.Generation++
+		assert.NoError(t, r.ReconcileExperiment(pe))
+		assert.True(t, util.IsRestartingExperiment(pe))
+	}
+
+	assert.NoError(t, r.ReconcileExperiment(pe))
+	assert.NoError(t, r.ReconcileExperiment(pe))
+	assert.Equal(t, int32(3), pe.Status.TrialsTotal)
+	complete("exp-1-0")
+	complete("exp-1-1")
+
+	// The trial of the first rung killed on suspension does not count toward it, its configuration is sampled again
+	suspendAndResume()
+	assert.True(t, util.IsKilledTrial(getTrial("exp-1-2")))
+	assert.Equal(t, int32(2), pe.Status.Rungs[0].Trials)
+	assert.NoError(t, r.ReconcileExperiment(pe))
+	assert.Equal(t, 2, sampling.calls)
+	assert.Equal(t, "3", getTrial("exp-3-5").Spec.SamplingResult[0].Value)
+	assert.NoError(t, r.ReconcileExperiment(pe))
+	assert.Equal(t, int32(3), pe.Status.Rungs[0].Trials)
+
+	// The promoted trial killed on suspension is deleted and created again in its rung
+	complete("exp-3-5")
+	assert.NoError(t, r.ReconcileExperiment(pe))
+	assert.Equal(t, []string{"exp-3-5"}, pe.Status.Rungs[0].Promoted)
+	assert.False(t, util.IsKilledTrial(getTrial("exp-3-5-r1")))
+	suspendAndResume()
+	assert.True(t, util.IsKilledTrial(getTrial("exp-3-5-r1")))
+	assert.Equal(t, int32(0), pe.Status.Rungs[1].Trials)
+	assert.NoError(t, r.ReconcileExperiment(pe))
+	assert.NoError(t, r.ReconcileExperiment(pe))
+	promoted := getTrial("exp-3-5-r1")
+	assert.False(t, util.IsKilledTrial(promoted))
+	assert.Equal(t, &morphlingv1alpha1.TrialFidelity{Name: "DURATION", Value: 30, Rung: 1}, promoted.Spec.Fidelity)
+
+	complete("exp-3-5-r1")
+	assert.NoError(t, r.ReconcileExperiment(pe))
+	assert.True(t, util.IsSucceededExperiment(pe))
+}
@@ -30,6 +30,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -38,6 +39,7 @@ import (
 
 	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
 	"github.com/alibaba/morphling/pkg/controllers/experiment/sampling_client"
+	"github.com/alibaba/morphling/pkg/controllers/grpcconn"
 	"github.com/alibaba/morphling/pkg/controllers/util"
 )
 
@@ -61,6 +63,19 @@ func NewReconciler(mgr manager.Manager) *ProfilingExperimentReconciler {
 	return r
 }
 
+// NewReconcilerForClient returns a reconciler of experiments in the client sampled by the sampling client, e.g., an
+// in-memory one of a simulation
+func NewReconcilerForClient(c client.Client, scheme *runtime.Scheme, recorder record.EventRecorder, sampling sampling_client.Sampling) *ProfilingExperimentReconciler {
+	r := &ProfilingExperimentReconciler{
+		Client:   c,
+		Scheme:   scheme,
+		recorder: recorder,
+		Sampling: sampling,
+	}
+	r.updateStatusHandler = r.updateStatus
+	return r
+}
+
 func (r *ProfilingExperimentReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	c, err := controller.New(ControllerName, mgr, controller.Options{Reconciler: r})
 	if err != nil {
@@ -72,6 +87,16 @@ func (r *ProfilingExperimentReconciler) SetupWithManager(mgr ctrl.Manager) error
 		log.Error(err, "Trial watch failed")
 		return err
 	}
+
+	// Compute the samplings in the background, and reconcile the experiment again once they are done
+	events := make(chan event.GenericEvent)
+	r.Sampling = sampling_client.NewAsync(r.Sampling, func(instance *morphlingv1alpha1.ProfilingExperiment) {
+		events <- event.GenericEvent{Meta: instance, Object: instance}
+	})
+	if err = c.Watch(&source.Channel{Source: events}, &handler.EnqueueRequestForObject{}); err != nil {
+		log.Error(err, "Sampling watch failed")
+		return err
+	}
 	log.Info("Experiment controller created")
 	return nil
 }
@@ -116,6 +141,7 @@ type ProfilingExperimentReconciler struct {
 
 // +kubebuilder:rbac:groups=morphling.kubedl.io,resources=profilingexperiments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=morphling.kubedl.io,resources=profilingexperiments/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=morphling.kubedl.io,resources=samplingalgorithms,verbs=get;list;watch
 // +kubebuilder:rbac:groups=morphling.kubedl.io,resources=trials,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=morphling.kubedl.io,resources=trials/status,verbs=get;update;patch
 
@@ -136,14 +162,15 @@ func (r *ProfilingExperimentReconciler) Reconcile(req ctrl.Request) (ctrl.Result
 		return reconcile.Result{}, err
 	}
 	instance := original.DeepCopy()
+	result := ctrl.Result{}
 
-	// Cleanup upon completion
-	if util.IsCompletedExperiment(instance) {
-		if !util.HasRunningTrials(instance) {
-			return reconcile.Result{}, nil
+	if util.IsCompletedExperiment(instance) && !util.HasRunningTrials(instance) {
+		// Apply the optimal parameters upon completion
+		if err := r.reconcileApplyPolicy(instance); err != nil {
+			logger.Error(err, "Reconcile apply policy error")
+			return reconcile.Result{}, err
 		}
-	}
-	if !util.IsCreatedExperiment(instance) {
+	} else if !util.IsCreatedExperiment(instance) {
 		// Create the experiment
 		if instance.Status.StartTime == nil {
 			now := metav1.Now()
@@ -154,7 +181,12 @@ func (r *ProfilingExperimentReconciler) Reconcile(req ctrl.Request) (ctrl.Result
 	} else {
 		// Reconcile experiment
 		err := r.ReconcileExperiment(instance)
-		if err != nil {
+		if retryAfter, ok := grpcconn.RetryAfter(err); ok {
+			// Retry later instead of blocking the worker, and keep the status of the trials up to date meanwhile
+			logger.Info("Algorithm server is unavailable", "error", err.Error(), "retryAfter", retryAfter)
+			r.recorder.Eventf(instance, corev1.EventTypeWarning, "SamplingUnavailable", "Failed to get samplings, retry after %v: %v", retryAfter, err)
+			result.RequeueAfter = retryAfter
+		} else if err != nil {
 			logger.Error(err, "Reconcile experiment error")
 			r.recorder.Eventf(instance, corev1.EventTypeWarning, "ReconcileFailed", "Failed to reconcile: %v", err)
 			return reconcile.Result{}, err
@@ -169,31 +201,65 @@ func (r *ProfilingExperimentReconciler) Reconcile(req ctrl.Request) (ctrl.Result
 			return reconcile.Result{}, err
 		}
 	}
-	return ctrl.Result{}, nil
+	return result, nil
 }
 
 // ReconcileExperiment is the main reconcile loop.
 func (r *ProfilingExperimentReconciler) ReconcileExperiment(instance *morphlingv1alpha1.ProfilingExperiment) error {
 	logger := log.WithValues("Experiment", types.NamespacedName{Name: instance.GetName(), Namespace: instance.GetNamespace()})
 
+	// Fail experiments whose rungs are ill-defined, since no trial can be created
+	if instance.Spec.MultiFidelity != nil {
+		if err := validateMultiFidelity(instance); err != nil {
+			now := metav1.Now()
+			util.MarkExperimentStatusFailed(instance, err.Error())
+			instance.Status.CompletionTime = &now
+			return nil
+		}
+	}
+
 	// Fetch trials
 	trials, err := r.fetchTrials(instance)
 	if err != nil {
 		logger.Error(err, "Fetch trials error")
+		return err
 	}
 
 	// Update trials results
 	if len(trials.Items) > 0 {
 		updateTrialsSummary(instance, trials)
 	}
+	if err := updateSearchSpace(instance, trials.Items); err != nil {
+		now := metav1.Now()
+		util.MarkExperimentStatusFailed(instance, err.Error())
+		instance.Status.CompletionTime = &now
+		return nil
+	}
+	if instance.Spec.MultiFidelity != nil {
+		updateRungs(instance, trials.Items)
+	}
+
+	// Restart on spec changes, and reconcile trials once the restart is observed
+	if observeSpecUpdate(instance, trials.Items) {
+		logger.Info("Experiment spec changed, restarting", "generation", instance.Generation)
+		return nil
+	}
 
 	// Update experiment status
 	if !util.IsCompletedExperiment(instance) {
-		updateExperimentStatusCondition(instance)
+		updateExperimentStatusCondition(instance, trials.Items)
+	}
+
+	// Create no trials while suspended
+	if !util.IsCompletedExperiment(instance) && util.IsSuspendedExperiment(instance) {
+		if instance.Spec.SuspendPolicy == morphlingv1alpha1.SuspendPolicyKill {
+			return r.killActiveTrials(instance, trials.Items)
+		}
+		return nil
 	}
 
 	// Reconcile trials
-	if !util.IsCompletedExperiment(instance) {
+	if !util.IsCompletedExperiment(instance) && !util.IsRestartingExperiment(instance) {
 		err := r.ReconcileTrials(instance, trials.Items)
 		if err != nil {
 			return err
@@ -0,0 +1,90 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sampling_client
+
+import (
+	"errors"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+)
+
+// ErrSamplingInProgress is returned by an asynchronous sampling while the samplings of the experiment are computed
+var ErrSamplingInProgress = errors.New("sampling in progress")
+
+// NotifyFunc is called once the samplings of the experiment are computed, e.g., to reconcile it again
+type NotifyFunc func(instance *morphlingv1alpha1.ProfilingExperiment)
+
+// Async computes the samplings of experiments in the background, so that reconciles do not block on the algorithm
+// server. The first call for an experiment starts the sampling and returns ErrSamplingInProgress, as do the calls
+// until it is done, after which the next call returns its result.
+type Async struct {
+	sampling Sampling
+	notify   NotifyFunc
+
+	mu    sync.Mutex
+	calls map[types.NamespacedName]*asyncCall
+}
+
+type asyncCall struct {
+	generation  int64
+	done        bool
+	assignments []morphlingv1alpha1.TrialAssignment
+	err         error
+}
+
+// NewAsync returns the asynchronous sampling of s, calling notify once a sampling is done
+func NewAsync(s Sampling, notify NotifyFunc) *Async {
+	return &Async{sampling: s, notify: notify, calls: map[types.NamespacedName]*asyncCall{}}
+}
+
+func (a *Async) GetSamplings(requestNum int32, instance *morphlingv1alpha1.ProfilingExperiment, currentCount int32, trials []morphlingv1alpha1.Trial) ([]morphlingv1alpha1.TrialAssignment, error) {
+	key := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if call := a.calls[key]; call != nil {
+		if !call.done {
+			return nil, ErrSamplingInProgress
+		}
+		delete(a.calls, key)
+		// Samplings of a former spec are dropped and sampled again
+		if call.generation == instance.Generation {
+			return call.assignments, call.err
+		}
+	}
+
+	call := &asyncCall{generation: instance.Generation}
+	a.calls[key] = call
+	instance = instance.DeepCopy()
+	trials = append([]morphlingv1alpha1.Trial(nil), trials...)
+	for i := range trials {
+		trials[i] = *trials[i].DeepCopy()
+	}
+	go func() {
+		assignments, err := a.sampling.GetSamplings(requestNum, instance, currentCount, trials)
+		a.mu.Lock()
+		call.assignments, call.err, call.done = assignments, err, true
+		a.mu.Unlock()
+		if a.notify != nil {
+			a.notify(instance)
+		}
+	}()
+	return nil, ErrSamplingInProgress
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sampling_client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+)
+
+// blockingSampling returns a sampling per call once released
+type blockingSampling struct {
+	release chan struct{}
+	calls   int
+}
+
+func (b *blockingSampling) GetSamplings(requestNum int32, instance *morphlingv1alpha1.ProfilingExperiment, currentCount int32, trials []morphlingv1alpha1.Trial) ([]morphlingv1alpha1.TrialAssignment, error) {
+	<-b.release
+	b.calls++
+	return []morphlingv1alpha1.TrialAssignment{{Name: instance.Name}}, nil
+}
+
+func TestAsync(t *testing.T) {
+	s := &blockingSampling{release: make(chan struct{})}
+	notified := make(chan string, 1)
+	a := NewAsync(s, func(instance *morphlingv1alpha1.ProfilingExperiment) { notified <- instance.Name })
+	pe := &morphlingv1alpha1.ProfilingExperiment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pe", Generation: 1}}
+
+	// The sampling is started by the first call, and in progress until done
+	for i := 0; i < 2; i++ {
+		_, err := a.GetSamplings(1, pe, 0, nil)
+		assert.Equal(t, ErrSamplingInProgress, err)
+	}
+	s.release <- struct{}{}
+	assert.Equal(t, "pe", <-notified)
+	assignments, err := a.GetSamplings(1, pe, 0, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []morphlingv1alpha1.TrialAssignment{{Name: "pe"}}, assignments)
+	assert.Equal(t, 1, s.calls)
+
+	// Samplings of a former generation are sampled again
+	_, err = a.GetSamplings(1, pe, 1, nil)
+	assert.Equal(t, ErrSamplingInProgress, err)
+	s.release <- struct{}{}
+	<-notified
+	pe.Generation = 2
+	_, err = a.GetSamplings(1, pe, 1, nil)
+	assert.Equal(t, ErrSamplingInProgress, err)
+	s.release <- struct{}{}
+	<-notified
+	_, err = a.GetSamplings(1, pe, 1, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, s.calls)
+}
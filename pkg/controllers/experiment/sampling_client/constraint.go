@@ -0,0 +1,322 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sampling_client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Constraint is a cross-parameter constraint comparing two arithmetic expressions, e.g., cpu * replicas <= 32.
+type Constraint struct {
+	expr        string
+	op          string
+	left, right node
+}
+
+// node is a node of an arithmetic expression: a number, a parameter or a binary operation.
+type node struct {
+	op          byte // 0 for leaves
+	number      float64
+	parameter   string
+	left, right *node
+}
+
+// ParseConstraint parses a constraint of the form <expression> <comparison> <expression>.
+func ParseConstraint(expr string) (*Constraint, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid constraint %q: %v", expr, err)
+	}
+	p := &exprParser{tokens: tokens}
+	c := &Constraint{expr: expr}
+	if c.left, err = p.parseSum(); err != nil {
+		return nil, fmt.Errorf("invalid constraint %q: %v", expr, err)
+	}
+	switch op := p.next(); op.text {
+	case "<", "<=", ">", ">=", "==", "!=":
+		c.op = op.text
+	default:
+		return nil, fmt.Errorf("invalid constraint %q: expected a comparison, got %q", expr, op.text)
+	}
+	if c.right, err = p.parseSum(); err != nil {
+		return nil, fmt.Errorf("invalid constraint %q: %v", expr, err)
+	}
+	if t := p.next(); t.kind != tokenEnd {
+		return nil, fmt.Errorf("invalid constraint %q: unexpected %q", expr, t.text)
+	}
+	return c, nil
+}
+
+func (c *Constraint) String() string {
+	return c.expr
+}
+
+// Parameters returns the names of the parameters the constraint refers to.
+func (c *Constraint) Parameters() []string {
+	names := make([]string, 0)
+	var collect func(n *node)
+	collect = func(n *node) {
+		if n == nil {
+			return
+		}
+		if n.op == 0 && n.parameter != "" {
+			names = append(names, n.parameter)
+		}
+		collect(n.left)
+		collect(n.right)
+	}
+	collect(&c.left)
+	collect(&c.right)
+	return names
+}
+
+// Satisfied evaluates the constraint with the parameter values. A constraint referring to a parameter
+// without a value, i.e., an inactive conditional parameter, is satisfied.
+func (c *Constraint) Satisfied(values map[string]string) (bool, error) {
+	for _, name := range c.Parameters() {
+		if _, ok := values[name]; !ok {
+			return true, nil
+		}
+	}
+	left, err := c.left.eval(values)
+	if err != nil {
+		return false, err
+	}
+	right, err := c.right.eval(values)
+	if err != nil {
+		return false, err
+	}
+	switch c.op {
+	case "<":
+		return left < right, nil
+	case "<=":
+		return left <= right, nil
+	case ">":
+		return left > right, nil
+	case ">=":
+		return left >= right, nil
+	case "==":
+		return left == right, nil
+	default:
+		return left != right, nil
+	}
+}
+
+func (n *node) eval(values map[string]string) (float64, error) {
+	switch n.op {
+	case 0:
+		if n.parameter == "" {
+			return n.number, nil
+		}
+		return parseNumber(n.parameter, values[n.parameter])
+	case 'n':
+		v, err := n.left.eval(values)
+		return -v, err
+	}
+	left, err := n.left.eval(values)
+	if err != nil {
+		return 0, err
+	}
+	right, err := n.right.eval(values)
+	if err != nil {
+		return 0, err
+	}
+	switch n.op {
+	case '+':
+		return left + right, nil
+	case '-':
+		return left - right, nil
+	case '*':
+		return left * right, nil
+	default:
+		if right == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		return left / right, nil
+	}
+}
+
+// parseNumber parses the value of a parameter as a number or a resource quantity, e.g., 500m or 2Gi.
+func parseNumber(name, value string) (float64, error) {
+	if v, err := strconv.ParseFloat(value, 64); err == nil {
+		return v, nil
+	}
+	q, err := resource.ParseQuantity(value)
+	if err != nil {
+		return 0, fmt.Errorf("value %q of parameter %s is not a number", value, name)
+	}
+	return float64(q.MilliValue()) / 1000, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenNumber
+	tokenName
+	tokenOp
+)
+
+type exprToken struct {
+	kind tokenKind
+	text string
+}
+
+func tokenize(expr string) ([]exprToken, error) {
+	tokens := make([]exprToken, 0)
+	rs := []rune(expr)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			j := i
+			for j < len(rs) && (unicode.IsDigit(rs[j]) || rs[j] == '.' || rs[j] == 'e' || rs[j] == 'E' ||
+				((rs[j] == '+' || rs[j] == '-') && (rs[j-1] == 'e' || rs[j-1] == 'E'))) {
+				j++
+			}
+			tokens = append(tokens, exprToken{tokenNumber, string(rs[i:j])})
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(rs) && (unicode.IsLetter(rs[j]) || unicode.IsDigit(rs[j]) || rs[j] == '_' || rs[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, exprToken{tokenName, string(rs[i:j])})
+			i = j
+		case r == '\'':
+			j := i + 1
+			for j < len(rs) && rs[j] != '\'' {
+				j++
+			}
+			if j == len(rs) {
+				return nil, fmt.Errorf("unterminated quoted name")
+			}
+			tokens = append(tokens, exprToken{tokenName, string(rs[i+1 : j])})
+			i = j + 1
+		case strings.ContainsRune("<>=!", r):
+			op := string(r)
+			if i+1 < len(rs) && rs[i+1] == '=' {
+				op += "="
+			}
+			if op == "=" || op == "!" {
+				return nil, fmt.Errorf("unknown operator %q", op)
+			}
+			tokens = append(tokens, exprToken{tokenOp, op})
+			i += len(op)
+		case strings.ContainsRune("+-*/()", r):
+			tokens = append(tokens, exprToken{tokenOp, string(r)})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected character %q", r)
+		}
+	}
+	return tokens, nil
+}
+
+type exprParser struct {
+	tokens []exprToken
+	pos    int
+}
+
+func (p *exprParser) peek() exprToken {
+	if p.pos >= len(p.tokens) {
+		return exprToken{kind: tokenEnd}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() exprToken {
+	t := p.peek()
+	if t.kind != tokenEnd {
+		p.pos++
+	}
+	return t
+}
+
+func (p *exprParser) parseSum() (node, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return node{}, err
+	}
+	for t := p.peek(); t.kind == tokenOp && (t.text == "+" || t.text == "-"); t = p.peek() {
+		p.next()
+		right, err := p.parseProduct()
+		if err != nil {
+			return node{}, err
+		}
+		l := left
+		left = node{op: t.text[0], left: &l, right: &right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseProduct() (node, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return node{}, err
+	}
+	for t := p.peek(); t.kind == tokenOp && (t.text == "*" || t.text == "/"); t = p.peek() {
+		p.next()
+		right, err := p.parseFactor()
+		if err != nil {
+			return node{}, err
+		}
+		l := left
+		left = node{op: t.text[0], left: &l, right: &right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseFactor() (node, error) {
+	t := p.next()
+	switch {
+	case t.kind == tokenNumber:
+		v, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return node{}, fmt.Errorf("invalid number %q", t.text)
+		}
+		return node{number: v}, nil
+	case t.kind == tokenName:
+		return node{parameter: t.text}, nil
+	case t.kind == tokenOp && t.text == "-":
+		operand, err := p.parseFactor()
+		if err != nil {
+			return node{}, err
+		}
+		return node{op: 'n', left: &operand}, nil
+	case t.kind == tokenOp && t.text == "(":
+		n, err := p.parseSum()
+		if err != nil {
+			return node{}, err
+		}
+		if t := p.next(); t.text != ")" {
+			return node{}, fmt.Errorf("expected \")\", got %q", t.text)
+		}
+		return n, nil
+	case t.kind == tokenEnd:
+		return node{}, fmt.Errorf("unexpected end of expression")
+	default:
+		return node{}, fmt.Errorf("unexpected %q", t.text)
+	}
+}
@@ -18,13 +18,14 @@ package sampling_client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	grpcapi "github.com/alibaba/morphling/api/v1alpha1/grpc_proto/grpc_algorithm/go"
-	"github.com/alibaba/morphling/pkg/controllers/consts"
+	"github.com/alibaba/morphling/pkg/controllers/grpcconn"
 	"google.golang.org/grpc"
-	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"strconv"
-	"time"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -32,24 +33,46 @@ import (
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 
 	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	"github.com/alibaba/morphling/pkg/controllers/util"
 )
 
+// ErrSearchSpaceExhausted is returned along with the last samplings, possibly none, once the algorithm server has no
+// more assignments to suggest
+var ErrSearchSpaceExhausted = errors.New("search space exhausted")
+
 type Sampling interface {
 	GetSamplings(numRequests int32, instance *morphlingv1alpha1.ProfilingExperiment, currentCount int32, trials []morphlingv1alpha1.Trial) ([]morphlingv1alpha1.TrialAssignment, error)
 }
 
 var (
-	log     = logf.Log.WithName("sampling_client-client")
-	timeout = 60 * time.Second
+	log = logf.Log.WithName("sampling_client-client")
 )
 
+// ObserveFunc is called with every request to the algorithm server and its response, which is nil on errors. The
+// request is the stateless one, with all results, even if it is sent through a session.
+type ObserveFunc func(instance *morphlingv1alpha1.ProfilingExperiment, request *grpcapi.SamplingRequest, response *grpcapi.SamplingResponse)
+
 type General struct {
 	scheme *runtime.Scheme
 	client.Client
+	endpoint string
+	conns    *grpcconn.Manager
+	sessions *sessions
+	observe  ObserveFunc
 }
 
 func New(scheme *runtime.Scheme, client client.Client) Sampling {
-	return &General{scheme: scheme, Client: client}
+	return &General{scheme: scheme, Client: client, endpoint: grpcconn.Default.Config().AlgorithmEndpoint, conns: grpcconn.Default, sessions: newSessions()}
+}
+
+// Observe sets the function observing the requests to the algorithm server and the responses, e.g., to record them
+func (g *General) Observe(fn ObserveFunc) {
+	g.observe = fn
+}
+
+// NewForEndpoint returns a client of the algorithm server served at the endpoint, e.g., a local one in simulations
+func NewForEndpoint(scheme *runtime.Scheme, client client.Client, endpoint string) Sampling {
+	return &General{scheme: scheme, Client: client, endpoint: endpoint, conns: grpcconn.Default, sessions: newSessions()}
 }
 
 func (g *General) GetSamplings(requestNum int32, instance *morphlingv1alpha1.ProfilingExperiment, currentCount int32, trials []morphlingv1alpha1.Trial) ([]morphlingv1alpha1.TrialAssignment, error) {
@@ -70,28 +93,28 @@ func (g *General) GetSamplings(requestNum int32, instance *morphlingv1alpha1.Pro
 		return nil, err
 	}
 
-	endpoint := getAlgorithmServerEndpoint() //"localhost:9996"
-	conn, err := grpc.Dial(endpoint, grpc.WithInsecure())
+	endpoint, err := g.resolveEndpoint(instance)
 	if err != nil {
 		return nil, err
 	}
-	defer conn.Close()
-
-	clientGRPC := grpcapi.NewSuggestionClient(conn)
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-
-	request, err := newSamplingRequest(requestNum, instance, currentCount, trials)
+	request, err := NewSamplingRequest(requestNum, instance, currentCount, trials)
 	if err != nil {
 		return nil, err
 	}
 
-	response, err := clientGRPC.GetSuggestions(ctx, request, grpc.WaitForReady(true))
+	var response *grpcapi.SamplingResponse
+	err = g.conns.Invoke(endpoint, func(ctx context.Context, conn *grpc.ClientConn) (err error) {
+		response, err = g.sessions.getSuggestions(ctx, grpcapi.NewSuggestionClient(conn), endpoint, instance, request)
+		return err
+	})
+	if g.observe != nil {
+		g.observe(instance, request, response)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	if len(response.AssignmentsSet) != int(requestNum) {
+	if len(response.AssignmentsSet) > int(requestNum) || (len(response.AssignmentsSet) < int(requestNum) && !response.Exhausted) {
 		err := fmt.Errorf("the response contains unexpected trials")
 		logger.Error(err, "The response contains unexpected trials", "requestNum", requestNum, "response", response)
 		return nil, err
@@ -101,16 +124,64 @@ func (g *General) GetSamplings(requestNum int32, instance *morphlingv1alpha1.Pro
 	logger.V(0).Info("Getting samplings", "endpoint", endpoint, "response", response.String(), "request", request)
 	assignment := make([]morphlingv1alpha1.TrialAssignment, 0)
 	for _, t := range response.AssignmentsSet {
-		assignment = append(assignment,
-			morphlingv1alpha1.TrialAssignment{
-				Name:                 fmt.Sprintf("%s-%s", instance.Name, utilrand.String(8)), // grid id
-				ParameterAssignments: composeParameterAssignments(t.KeyValues, instance.Spec.TunableParameters),
-			})
+		pas, err := filterAssignments(composeParameterAssignments(t.KeyValues, instance.Spec.TunableParameters), instance)
+		if err != nil {
+			logger.Error(err, "The response contains an infeasible sampling", "response", response)
+			return nil, err
+		}
+		// Trials are named by the experiment controller
+		assignment = append(assignment, morphlingv1alpha1.TrialAssignment{ParameterAssignments: pas})
+	}
+	if response.Exhausted {
+		logger.Info("The search space is exhausted", "endpoint", endpoint, "requestNum", requestNum, "samplings", len(assignment))
+		return assignment, ErrSearchSpaceExhausted
 	}
 	return assignment, nil
 }
 
-func newSamplingRequest(requestNum int32, instance *morphlingv1alpha1.ProfilingExperiment, currentCount int32, trials []morphlingv1alpha1.Trial) (*grpcapi.SamplingRequest, error) {
+// resolveEndpoint returns the endpoint of the algorithm service registered by a SamplingAlgorithm for the algorithm of
+// the experiment, or the endpoint of the client if the algorithm is not registered
+func (g *General) resolveEndpoint(instance *morphlingv1alpha1.ProfilingExperiment) (string, error) {
+	algorithms := &morphlingv1alpha1.SamplingAlgorithmList{}
+	if err := g.List(context.TODO(), algorithms); err != nil {
+		if meta.IsNoMatchError(err) {
+			// SamplingAlgorithm is not installed
+			return g.endpoint, nil
+		}
+		return "", err
+	}
+	for i := range algorithms.Items {
+		algorithm := &algorithms.Items[i]
+		if algorithm.GetAlgorithmName() != instance.Spec.Algorithm.AlgorithmName {
+			continue
+		}
+		if err := validateAlgorithmSettings(algorithm, instance.Spec.Algorithm.AlgorithmSettings); err != nil {
+			return "", err
+		}
+		return algorithm.Spec.Endpoint, nil
+	}
+	return g.endpoint, nil
+}
+
+// validateAlgorithmSettings checks the settings are supported by the algorithm, if it declares the supported ones
+func validateAlgorithmSettings(algorithm *morphlingv1alpha1.SamplingAlgorithm, settings []morphlingv1alpha1.AlgorithmSetting) error {
+	if len(algorithm.Spec.SupportedSettings) == 0 {
+		return nil
+	}
+	supported := make(map[string]bool, len(algorithm.Spec.SupportedSettings))
+	for _, setting := range algorithm.Spec.SupportedSettings {
+		supported[setting.Name] = true
+	}
+	for _, setting := range settings {
+		if !supported[setting.Name] {
+			return fmt.Errorf("setting %s is not supported by algorithm %s of SamplingAlgorithm %s", setting.Name, algorithm.GetAlgorithmName(), algorithm.Name)
+		}
+	}
+	return nil
+}
+
+// NewSamplingRequest returns the request to the algorithm server for samplings of the experiment
+func NewSamplingRequest(requestNum int32, instance *morphlingv1alpha1.ProfilingExperiment, currentCount int32, trials []morphlingv1alpha1.Trial) (*grpcapi.SamplingRequest, error) {
 	request := &grpcapi.SamplingRequest{
 		AlgorithmName:    string(instance.Spec.Algorithm.AlgorithmName),
 		RequiredSampling: requestNum,
@@ -123,12 +194,14 @@ func newSamplingRequest(requestNum int32, instance *morphlingv1alpha1.ProfilingE
 		return nil, err
 	}
 	request.Parameters = pars
+	request.Constraints = instance.Spec.Constraints
 
 	existingTrials, err := convertTrials(trials)
 	if err != nil {
 		return nil, err
 	}
 	request.ExistingResults = existingTrials
+	request.PendingAssignments = convertPendingTrials(trials)
 
 	request.IsFirstRequest = currentCount < 1
 	request.AlgorithmExtraSettings = convertSettings(instance)
@@ -151,10 +224,25 @@ func convertPars(instance *morphlingv1alpha1.ProfilingExperiment) ([]*grpcapi.Pa
 				return nil, err
 			}
 
+			feasibleRange, err := convertFeasibleRange(p.FeasibleSpace, p.ParameterType)
+			if err != nil {
+				return nil, err
+			}
+
+			conditions := make([]*grpcapi.ParameterCondition, 0)
+			for _, cond := range p.Conditions {
+				conditions = append(conditions, &grpcapi.ParameterCondition{
+					Parameter: cond.Parameter,
+					Values:    cond.Values,
+				})
+			}
+
 			pars = append(pars, &grpcapi.ParameterSpec{
 				Name:          p.Name,
 				ParameterType: parType,
 				FeasibleSpace: feasibleSpace,
+				Conditions:    conditions,
+				FeasibleRange: feasibleRange,
 			})
 		}
 	}
@@ -181,6 +269,9 @@ func ConvertFeasibleSpace(fs morphlingv1alpha1.FeasibleSpace, parType morphlingv
 			if min > max {
 				return nil, fmt.Errorf("int parameter, min should be smaller than max")
 			}
+			if err := validateDistribution(fs.Distribution, float64(min)); err != nil {
+				return nil, err
+			}
 
 			if min < 0 || max < 0 || step < 0 {
 				return nil, fmt.Errorf("int parameter, should be larger than zero")
@@ -202,21 +293,45 @@ func ConvertFeasibleSpace(fs morphlingv1alpha1.FeasibleSpace, parType morphlingv
 			if err != nil {
 				return nil, err
 			}
+			if min > max {
+				return nil, fmt.Errorf("double parameter, min should be smaller than max")
+			}
+			if err := validateDistribution(fs.Distribution, min); err != nil {
+				return nil, err
+			}
+			// A continuous parameter is sampled from its bounds
+			if fs.Step == "" {
+				return res, nil
+			}
 			step, err := strconv.ParseFloat(fs.Step, 64)
 			if err != nil {
 				return nil, err
 			}
-			if min > max {
-				return nil, fmt.Errorf("double parameter, min should be smaller than max")
-			}
-			if min < 0 || max < 0 || step < 0 {
-				return nil, fmt.Errorf("int parameter, should be larger than zero")
+			if step <= 0 {
+				return nil, fmt.Errorf("double parameter, step should be larger than zero")
 			}
 
-			current := min
-			for current <= max {
-				res = append(res, fmt.Sprintf("%.2g", current))
-				current += step
+			// Values are computed from min rather than accumulated, so that they do not drift.
+			for i := 0; ; i++ {
+				current := min + float64(i)*step
+				if current > max+step*1e-9 {
+					break
+				}
+				res = append(res, formatDouble(current))
+			}
+		}
+	case morphlingv1alpha1.ParameterTypeQuantity:
+		{
+			min, max, step, err := parseQuantityRange(fs)
+			if err != nil {
+				return nil, err
+			}
+			if err := validateDistribution(fs.Distribution, float64(min.MilliValue())/1000); err != nil {
+				return nil, err
+			}
+			// Quantities are enumerated in milli units, formatted the same way as min
+			for current := min.MilliValue(); current <= max.MilliValue(); current += step.MilliValue() {
+				res = append(res, resource.NewMilliQuantity(current, min.Format).String())
 			}
 		}
 	case morphlingv1alpha1.ParameterTypeCategorical:
@@ -239,6 +354,107 @@ func ConvertFeasibleSpace(fs morphlingv1alpha1.FeasibleSpace, parType morphlingv
 	return res, nil
 }
 
+// convertFeasibleRange returns the bounds of an int, double or quantity parameter, or nil for other types.
+func convertFeasibleRange(fs morphlingv1alpha1.FeasibleSpace, parType morphlingv1alpha1.ParameterType) (*grpcapi.FeasibleRange, error) {
+	var res *grpcapi.FeasibleRange
+	switch parType {
+	case morphlingv1alpha1.ParameterTypeInt, morphlingv1alpha1.ParameterTypeDouble:
+		var err error
+		if res, err = parseFeasibleRange(fs); err != nil {
+			return nil, err
+		}
+	case morphlingv1alpha1.ParameterTypeQuantity:
+		min, max, step, err := parseQuantityRange(fs)
+		if err != nil {
+			return nil, err
+		}
+		res = &grpcapi.FeasibleRange{
+			Min:  float64(min.MilliValue()) / 1000,
+			Max:  float64(max.MilliValue()) / 1000,
+			Step: float64(step.MilliValue()) / 1000,
+		}
+	default:
+		return nil, nil
+	}
+	if fs.Distribution == morphlingv1alpha1.DistributionLogUniform {
+		res.Distribution = grpcapi.Distribution_LOG_UNIFORM
+	}
+	return res, nil
+}
+
+func parseFeasibleRange(fs morphlingv1alpha1.FeasibleSpace) (*grpcapi.FeasibleRange, error) {
+	min, err := strconv.ParseFloat(fs.Min, 64)
+	if err != nil {
+		return nil, err
+	}
+	max, err := strconv.ParseFloat(fs.Max, 64)
+	if err != nil {
+		return nil, err
+	}
+	res := &grpcapi.FeasibleRange{Min: min, Max: max}
+	if fs.Step != "" {
+		if res.Step, err = strconv.ParseFloat(fs.Step, 64); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func parseQuantityRange(fs morphlingv1alpha1.FeasibleSpace) (min, max, step resource.Quantity, err error) {
+	if min, err = resource.ParseQuantity(fs.Min); err != nil {
+		return
+	}
+	if max, err = resource.ParseQuantity(fs.Max); err != nil {
+		return
+	}
+	if step, err = resource.ParseQuantity(fs.Step); err != nil {
+		return
+	}
+	if min.Cmp(max) > 0 {
+		err = fmt.Errorf("quantity parameter, min should be smaller than max")
+	} else if step.Sign() <= 0 {
+		err = fmt.Errorf("quantity parameter, step should be larger than zero")
+	}
+	return
+}
+
+// normalizeValue formats a sampled quantity the same way as the feasible space, e.g., 1500m for 1.5,
+// so that equal quantities are the same values to optimizers and trials.
+func normalizeValue(par morphlingv1alpha1.ParameterSpec, value string) string {
+	if par.ParameterType != morphlingv1alpha1.ParameterTypeQuantity {
+		return value
+	}
+	q, err := resource.ParseQuantity(value)
+	if err != nil {
+		return value
+	}
+	format := q.Format
+	if min, err := resource.ParseQuantity(par.FeasibleSpace.Min); err == nil {
+		format = min.Format
+	}
+	return resource.NewMilliQuantity(q.MilliValue(), format).String()
+}
+
+func validateDistribution(distribution morphlingv1alpha1.Distribution, min float64) error {
+	switch distribution {
+	case "", morphlingv1alpha1.DistributionUniform:
+		return nil
+	case morphlingv1alpha1.DistributionLogUniform:
+		if min <= 0 {
+			return fmt.Errorf("log-uniform parameter, min should be larger than zero")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown distribution %s", distribution)
+	}
+}
+
+// formatDouble formats a double value without the floating point noise of its computation, e.g., 0.3 for 0.1+0.2.
+func formatDouble(v float64) string {
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(v, 'g', 12, 64), 64)
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}
+
 func convertParameterType(parType morphlingv1alpha1.ParameterType) (grpcapi.ParameterType, error) {
 	switch parType {
 	case morphlingv1alpha1.ParameterTypeInt:
@@ -249,6 +465,8 @@ func convertParameterType(parType morphlingv1alpha1.ParameterType) (grpcapi.Para
 		return grpcapi.ParameterType_CATEGORICAL, nil
 	case morphlingv1alpha1.ParameterTypeDouble:
 		return grpcapi.ParameterType_DOUBLE, nil
+	case morphlingv1alpha1.ParameterTypeQuantity:
+		return grpcapi.ParameterType_QUANTITY, nil
 	default:
 		return grpcapi.ParameterType_UNKNOWN_TYPE, fmt.Errorf("unknown ParameterType")
 	}
@@ -266,6 +484,7 @@ func convertTrials(trials []morphlingv1alpha1.Trial) ([]*grpcapi.TrialResult, er
 			trialGrpc := &grpcapi.TrialResult{
 				ParameterAssignments: []*grpcapi.KeyValue{},
 				ObjectValue:          float32(objectValue),
+				TrialName:            trial.Name,
 			}
 			for _, assignment := range trial.Spec.SamplingResult {
 				trialGrpc.ParameterAssignments = append(trialGrpc.ParameterAssignments, &grpcapi.KeyValue{
@@ -283,6 +502,30 @@ func convertTrials(trials []morphlingv1alpha1.Trial) ([]*grpcapi.TrialResult, er
 	return existingTrials, nil
 }
 
+// convertPendingTrials returns the assignments of the trials without results yet, including failed ones so that they
+// are not sampled again, except killed ones
+func convertPendingTrials(trials []morphlingv1alpha1.Trial) []*grpcapi.ParameterAssignments {
+	pending := make([]*grpcapi.ParameterAssignments, 0)
+	for _, trial := range trials {
+		if (trial.Status.TrialResult != nil) && (trial.Status.TrialResult.ObjectiveMetricsObserved != nil) {
+			continue
+		}
+		// The assignments of killed trials may be sampled again
+		if util.IsKilledTrial(&trial) {
+			continue
+		}
+		assignments := &grpcapi.ParameterAssignments{KeyValues: []*grpcapi.KeyValue{}}
+		for _, assignment := range trial.Spec.SamplingResult {
+			assignments.KeyValues = append(assignments.KeyValues, &grpcapi.KeyValue{
+				Key:   assignment.Name,
+				Value: assignment.Value,
+			})
+		}
+		pending = append(pending, assignments)
+	}
+	return pending
+}
+
 func convertSettings(instance *morphlingv1alpha1.ProfilingExperiment) []*grpcapi.KeyValue {
 
 	if instance.Spec.Algorithm.AlgorithmSettings != nil {
@@ -302,29 +545,22 @@ func composeParameterAssignments(pas []*grpcapi.KeyValue, categories []morphling
 	res := make([]morphlingv1alpha1.ParameterAssignment, 0)
 	for _, pa := range pas {
 		categoryThis := morphlingv1alpha1.CategoryResource
+		value := pa.Value
 		for _, cat := range categories {
 			for _, par := range cat.Parameters {
 				if par.Name == pa.Key {
 					categoryThis = cat.Category
+					value = normalizeValue(par, pa.Value)
 				}
 			}
 		}
 
 		res = append(res, morphlingv1alpha1.ParameterAssignment{
 			Name:     pa.Key,
-			Value:    pa.Value,
+			Value:    value,
 			Category: categoryThis,
 			//todo: Category
 		})
 	}
 	return res
 }
-
-func getAlgorithmServerEndpoint() string {
-
-	serviceName := consts.DefaultSamplingService
-	return fmt.Sprintf("%s:%d",
-		serviceName,
-		//s.Namespace,
-		consts.DefaultSamplingPort)
-}
@@ -0,0 +1,203 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sampling_client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	grpcapi "github.com/alibaba/morphling/api/v1alpha1/grpc_proto/grpc_algorithm/go"
+	"github.com/alibaba/morphling/pkg/controllers/util"
+)
+
+func TestConvertDoubleFeasibleSpace(t *testing.T) {
+	double := morphlingv1alpha1.ParameterTypeDouble
+	values, err := ConvertFeasibleSpace(morphlingv1alpha1.FeasibleSpace{Min: "0", Max: "0.5", Step: "0.125"}, double)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0", "0.125", "0.25", "0.375", "0.5"}, values)
+
+	values, err = ConvertFeasibleSpace(morphlingv1alpha1.FeasibleSpace{Min: "0.1", Max: "0.3", Step: "0.1"}, double)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0.1", "0.2", "0.3"}, values)
+
+	values, err = ConvertFeasibleSpace(morphlingv1alpha1.FeasibleSpace{Min: "-1", Max: "1", Step: "0.5"}, double)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"-1", "-0.5", "0", "0.5", "1"}, values)
+
+	// Continuous parameters are sampled from the bounds
+	values, err = ConvertFeasibleSpace(morphlingv1alpha1.FeasibleSpace{Min: "0.0001", Max: "0.1", Distribution: morphlingv1alpha1.DistributionLogUniform}, double)
+	assert.NoError(t, err)
+	assert.Empty(t, values)
+
+	for _, invalid := range []morphlingv1alpha1.FeasibleSpace{
+		{Min: "1", Max: "0"},
+		{Min: "0", Max: "1", Step: "0"},
+		{Min: "0", Max: "1", Distribution: morphlingv1alpha1.DistributionLogUniform},
+		{Min: "0", Max: "1", Distribution: "normal"},
+	} {
+		_, err = ConvertFeasibleSpace(invalid, double)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestConvertFeasibleRange(t *testing.T) {
+	r, err := convertFeasibleRange(morphlingv1alpha1.FeasibleSpace{Min: "0.0001", Max: "0.1", Distribution: morphlingv1alpha1.DistributionLogUniform}, morphlingv1alpha1.ParameterTypeDouble)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0001, r.Min)
+	assert.Equal(t, 0.1, r.Max)
+	assert.Equal(t, float64(0), r.Step)
+	assert.Equal(t, grpcapi.Distribution_LOG_UNIFORM, r.Distribution)
+
+	r, err = convertFeasibleRange(morphlingv1alpha1.FeasibleSpace{Min: "1", Max: "8", Step: "1"}, morphlingv1alpha1.ParameterTypeInt)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(1), r.Step)
+	assert.Equal(t, grpcapi.Distribution_UNIFORM, r.Distribution)
+
+	r, err = convertFeasibleRange(morphlingv1alpha1.FeasibleSpace{List: []string{"a"}}, morphlingv1alpha1.ParameterTypeCategorical)
+	assert.NoError(t, err)
+	assert.Nil(t, r)
+
+	// Continuous search spaces are unbounded
+	instance := newConditionalExperiment()
+	instance.Spec.TunableParameters[0].Parameters = append(instance.Spec.TunableParameters[0].Parameters, morphlingv1alpha1.ParameterSpec{
+		Name:          "gpu_memory_fraction",
+		ParameterType: morphlingv1alpha1.ParameterTypeDouble,
+		FeasibleSpace: morphlingv1alpha1.FeasibleSpace{Min: "0.5", Max: "0.9"},
+	})
+	size, err := SearchSpaceSize(instance)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, size)
+}
+
+func TestConvertQuantityFeasibleSpace(t *testing.T) {
+	quantity := morphlingv1alpha1.ParameterTypeQuantity
+	values, err := ConvertFeasibleSpace(morphlingv1alpha1.FeasibleSpace{Min: "500m", Max: "2", Step: "500m"}, quantity)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"500m", "1", "1500m", "2"}, values)
+
+	values, err = ConvertFeasibleSpace(morphlingv1alpha1.FeasibleSpace{Min: "1Gi", Max: "2Gi", Step: "512Mi"}, quantity)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1Gi", "1536Mi", "2Gi"}, values)
+
+	for _, invalid := range []morphlingv1alpha1.FeasibleSpace{
+		{Min: "2", Max: "1", Step: "1"},
+		{Min: "1", Max: "2"},
+		{Min: "1", Max: "2", Step: "0"},
+		{Min: "1", Max: "2", Step: "large"},
+		{Min: "0", Max: "2", Step: "1", Distribution: morphlingv1alpha1.DistributionLogUniform},
+	} {
+		_, err = ConvertFeasibleSpace(invalid, quantity)
+		assert.Error(t, err, invalid)
+	}
+
+	// Bounds are sent in base units
+	r, err := convertFeasibleRange(morphlingv1alpha1.FeasibleSpace{Min: "500m", Max: "2", Step: "250m"}, quantity)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.5, r.Min)
+	assert.Equal(t, float64(2), r.Max)
+	assert.Equal(t, 0.25, r.Step)
+}
+
+func TestNormalizeQuantityValue(t *testing.T) {
+	par := morphlingv1alpha1.ParameterSpec{
+		Name:          "cpu",
+		ParameterType: morphlingv1alpha1.ParameterTypeQuantity,
+		FeasibleSpace: morphlingv1alpha1.FeasibleSpace{Min: "500m", Max: "2", Step: "500m"},
+	}
+	assert.Equal(t, "1500m", normalizeValue(par, "1.5"))
+	assert.Equal(t, "1500m", normalizeValue(par, "1500m"))
+	assert.Equal(t, "2", normalizeValue(par, "2000m"))
+	assert.Equal(t, "large", normalizeValue(par, "large"))
+
+	par.ParameterType = morphlingv1alpha1.ParameterTypeDouble
+	assert.Equal(t, "1.5", normalizeValue(par, "1.5"))
+}
+
+func TestResolveEndpoint(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, morphlingv1alpha1.AddToScheme(scheme))
+	c := fake.NewFakeClientWithScheme(scheme,
+		&morphlingv1alpha1.SamplingAlgorithm{
+			ObjectMeta: metav1.ObjectMeta{Name: "grid"},
+			Spec:       morphlingv1alpha1.SamplingAlgorithmSpec{Endpoint: "morphling-algorithm-server.morphling-system:9996"},
+		},
+		&morphlingv1alpha1.SamplingAlgorithm{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-bo"},
+			Spec: morphlingv1alpha1.SamplingAlgorithmSpec{
+				AlgorithmName:     "BayesianOpt",
+				Endpoint:          "bo.team:9996",
+				SupportedSettings: []morphlingv1alpha1.SupportedAlgorithmSetting{{Name: "random_state", DefaultValue: "0"}},
+			},
+		},
+	)
+	g := NewForEndpoint(scheme, c, "localhost:9996").(*General)
+
+	pe := &morphlingv1alpha1.ProfilingExperiment{}
+	for algorithm, expected := range map[morphlingv1alpha1.AlgorithmName]string{
+		morphlingv1alpha1.GridSearch:   "morphling-algorithm-server.morphling-system:9996",
+		morphlingv1alpha1.BayesianOpt:  "bo.team:9996",
+		morphlingv1alpha1.RandomSearch: "localhost:9996",
+	} {
+		pe.Spec.Algorithm.AlgorithmName = algorithm
+		endpoint, err := g.resolveEndpoint(pe)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, endpoint, algorithm)
+	}
+
+	// Settings not supported by the algorithm are rejected
+	pe.Spec.Algorithm.AlgorithmName = morphlingv1alpha1.BayesianOpt
+	pe.Spec.Algorithm.AlgorithmSettings = []morphlingv1alpha1.AlgorithmSetting{{Name: "random_state", Value: "1"}}
+	_, err := g.resolveEndpoint(pe)
+	assert.NoError(t, err)
+	pe.Spec.Algorithm.AlgorithmSettings = append(pe.Spec.Algorithm.AlgorithmSettings, morphlingv1alpha1.AlgorithmSetting{Name: "kappa", Value: "2"})
+	_, err = g.resolveEndpoint(pe)
+	assert.Error(t, err)
+}
+
+func TestNewSamplingRequestPendingAssignments(t *testing.T) {
+	instance := newConditionalExperiment()
+	trials := []morphlingv1alpha1.Trial{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "pe-1-0"},
+			Spec:       morphlingv1alpha1.TrialSpec{SamplingResult: []morphlingv1alpha1.ParameterAssignment{{Name: "cpu", Value: "4"}}},
+			Status: morphlingv1alpha1.TrialStatus{TrialResult: &morphlingv1alpha1.TrialResult{
+				ObjectiveMetricsObserved: []morphlingv1alpha1.Metric{{Name: "qps", Value: "10"}},
+			}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "pe-1-1"},
+			Spec:       morphlingv1alpha1.TrialSpec{SamplingResult: []morphlingv1alpha1.ParameterAssignment{{Name: "cpu", Value: "8"}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "pe-1-2"},
+			Spec:       morphlingv1alpha1.TrialSpec{SamplingResult: []morphlingv1alpha1.ParameterAssignment{{Name: "cpu", Value: "2"}}},
+		},
+	}
+	util.MarkTrialStatusKilled(&trials[2], "killed")
+	request, err := NewSamplingRequest(1, instance, 3, trials)
+	assert.NoError(t, err)
+	assert.Len(t, request.ExistingResults, 1)
+	assert.Equal(t, "pe-1-0", request.ExistingResults[0].TrialName)
+	// Trials without results yet are sent as pending, unless killed
+	assert.Len(t, request.PendingAssignments, 1)
+	assert.Equal(t, "8", request.PendingAssignments[0].KeyValues[0].Value)
+}
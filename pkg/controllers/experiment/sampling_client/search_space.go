@@ -0,0 +1,257 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sampling_client
+
+import (
+	"fmt"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+)
+
+// maxEnumeratedSearchSpace bounds the number of combinations enumerated to size a conditional or
+// constrained search space; larger spaces are sized by the product of the feasible spaces.
+const maxEnumeratedSearchSpace = 1 << 20
+
+// searchSpace is the flattened search space of an experiment, with the parameters ordered such that
+// every parameter comes after the parameters its conditions depend on.
+type searchSpace struct {
+	parameters  []morphlingv1alpha1.ParameterSpec
+	values      [][]string
+	constraints []*Constraint
+}
+
+func newSearchSpace(instance *morphlingv1alpha1.ProfilingExperiment) (*searchSpace, error) {
+	specs := make(map[string]morphlingv1alpha1.ParameterSpec)
+	names := make([]string, 0)
+	for _, cat := range instance.Spec.TunableParameters {
+		for _, p := range cat.Parameters {
+			if _, ok := specs[p.Name]; ok {
+				return nil, fmt.Errorf("parameter %s is defined more than once", p.Name)
+			}
+			specs[p.Name] = p
+			names = append(names, p.Name)
+		}
+	}
+
+	s := &searchSpace{}
+	// Order the parameters by their conditions, keeping the declared order otherwise.
+	state := make(map[string]int) // 1: visiting, 2: visited
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case 1:
+			return fmt.Errorf("conditions of parameter %s are circular", name)
+		case 2:
+			return nil
+		}
+		state[name] = 1
+		p := specs[name]
+		for _, cond := range p.Conditions {
+			if _, ok := specs[cond.Parameter]; !ok {
+				return fmt.Errorf("condition of parameter %s refers to unknown parameter %s", name, cond.Parameter)
+			}
+			if err := visit(cond.Parameter); err != nil {
+				return err
+			}
+		}
+		state[name] = 2
+		feasibleSpace, err := ConvertFeasibleSpace(p.FeasibleSpace, p.ParameterType)
+		if err != nil {
+			return err
+		}
+		s.parameters = append(s.parameters, p)
+		s.values = append(s.values, feasibleSpace)
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, expr := range instance.Spec.Constraints {
+		c, err := ParseConstraint(expr)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range c.Parameters() {
+			if _, ok := specs[name]; !ok {
+				return nil, fmt.Errorf("constraint %q refers to unknown parameter %s", expr, name)
+			}
+		}
+		s.constraints = append(s.constraints, c)
+	}
+	return s, nil
+}
+
+// conditional returns true if the space has conditional parameters or constraints.
+func (s *searchSpace) conditional() bool {
+	if len(s.constraints) > 0 {
+		return true
+	}
+	for _, p := range s.parameters {
+		if len(p.Conditions) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// feasible returns true if the values of the active parameters satisfy all constraints.
+func (s *searchSpace) feasible(values map[string]string) (bool, error) {
+	for _, c := range s.constraints {
+		ok, err := c.Satisfied(values)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// size counts the feasible combinations of the active parameters, giving up after limit combinations.
+func (s *searchSpace) size(limit int) (int, bool, error) {
+	count, visited := 0, 0
+	values := make(map[string]string)
+	var walk func(i int) error
+	walk = func(i int) error {
+		if visited >= limit {
+			return nil
+		}
+		if i == len(s.parameters) {
+			visited++
+			ok, err := s.feasible(values)
+			if ok {
+				count++
+			}
+			return err
+		}
+		p := s.parameters[i]
+		if !IsParameterActive(p, values) {
+			return walk(i + 1)
+		}
+		for _, v := range s.values[i] {
+			values[p.Name] = v
+			if err := walk(i + 1); err != nil {
+				return err
+			}
+		}
+		delete(values, p.Name)
+		return nil
+	}
+	if err := walk(0); err != nil {
+		return 0, false, err
+	}
+	return count, visited < limit, nil
+}
+
+// isContinuous returns true for a double parameter without a step.
+func isContinuous(p morphlingv1alpha1.ParameterSpec) bool {
+	return p.ParameterType == morphlingv1alpha1.ParameterTypeDouble && p.FeasibleSpace.Step == ""
+}
+
+// IsParameterActive returns true if all conditions of the parameter hold for the assigned values.
+func IsParameterActive(p morphlingv1alpha1.ParameterSpec, values map[string]string) bool {
+	for _, cond := range p.Conditions {
+		v, ok := values[cond.Parameter]
+		if !ok {
+			return false
+		}
+		matched := false
+		for _, expected := range cond.Values {
+			if v == expected {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// SearchSpaceSize returns the number of distinct configurations in the search space of the experiment,
+// leaving out inactive conditional parameters and configurations violating the constraints.
+// It returns 0 for a search space with continuous parameters.
+func SearchSpaceSize(instance *morphlingv1alpha1.ProfilingExperiment) (int, error) {
+	s, err := newSearchSpace(instance)
+	if err != nil {
+		return 0, err
+	}
+	product := 1
+	for i, values := range s.values {
+		if isContinuous(s.parameters[i]) {
+			return 0, nil
+		}
+		if len(values) == 0 {
+			return 0, fmt.Errorf("feasible space of parameter %s is empty", s.parameters[i].Name)
+		}
+		product *= len(values)
+	}
+	if !s.conditional() {
+		return product, nil
+	}
+	count, complete, err := s.size(maxEnumeratedSearchSpace)
+	if err != nil {
+		return 0, err
+	}
+	if !complete {
+		return product, nil
+	}
+	return count, nil
+}
+
+// filterAssignments drops the inactive conditional parameters from the sampled assignments, and
+// returns an error if they violate the constraints of the experiment.
+func filterAssignments(pas []morphlingv1alpha1.ParameterAssignment, instance *morphlingv1alpha1.ProfilingExperiment) ([]morphlingv1alpha1.ParameterAssignment, error) {
+	s, err := newSearchSpace(instance)
+	if err != nil {
+		return nil, err
+	}
+	sampled := make(map[string]string)
+	for _, pa := range pas {
+		sampled[pa.Name] = pa.Value
+	}
+	active := make(map[string]string)
+	inactive := make(map[string]bool)
+	for _, p := range s.parameters {
+		if v, ok := sampled[p.Name]; ok && IsParameterActive(p, active) {
+			active[p.Name] = v
+		} else {
+			inactive[p.Name] = true
+		}
+	}
+	res := make([]morphlingv1alpha1.ParameterAssignment, 0)
+	for _, pa := range pas {
+		if !inactive[pa.Name] {
+			res = append(res, pa)
+		}
+	}
+	for _, c := range s.constraints {
+		ok, err := c.Satisfied(active)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("sampling %v violates the constraint %q", active, c)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sampling_client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+)
+
+func newConditionalExperiment() *morphlingv1alpha1.ProfilingExperiment {
+	return &morphlingv1alpha1.ProfilingExperiment{
+		Spec: morphlingv1alpha1.ProfilingExperimentSpec{
+			TunableParameters: []morphlingv1alpha1.ParameterCategory{
+				{
+					Category: morphlingv1alpha1.CategoryEnv,
+					Parameters: []morphlingv1alpha1.ParameterSpec{
+						{
+							Name:          "max_batch_wait",
+							ParameterType: morphlingv1alpha1.ParameterTypeDiscrete,
+							FeasibleSpace: morphlingv1alpha1.FeasibleSpace{List: []string{"5", "10", "20"}},
+							Conditions:    []morphlingv1alpha1.ParameterCondition{{Parameter: "enable_batching", Values: []string{"true"}}},
+						},
+						{
+							Name:          "enable_batching",
+							ParameterType: morphlingv1alpha1.ParameterTypeCategorical,
+							FeasibleSpace: morphlingv1alpha1.FeasibleSpace{List: []string{"true", "false"}},
+						},
+					},
+				},
+				{
+					Category: morphlingv1alpha1.CategoryResource,
+					Parameters: []morphlingv1alpha1.ParameterSpec{
+						{
+							Name:          "cpu",
+							ParameterType: morphlingv1alpha1.ParameterTypeDiscrete,
+							FeasibleSpace: morphlingv1alpha1.FeasibleSpace{List: []string{"4", "8", "16"}},
+						},
+					},
+				},
+				{
+					Category: morphlingv1alpha1.CategoryReplicas,
+					Parameters: []morphlingv1alpha1.ParameterSpec{
+						{
+							Name:          "replicas",
+							ParameterType: morphlingv1alpha1.ParameterTypeInt,
+							FeasibleSpace: morphlingv1alpha1.FeasibleSpace{Min: "1", Max: "4", Step: "1"},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestConstraint(t *testing.T) {
+	c, err := ParseConstraint("cpu * replicas <= 32")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cpu", "replicas"}, c.Parameters())
+
+	ok, err := c.Satisfied(map[string]string{"cpu": "8", "replicas": "4"})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = c.Satisfied(map[string]string{"cpu": "16", "replicas": "3"})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// Quantities and quoted names
+	c, err = ParseConstraint("'requests.cpu' * 2 - (1 + 1) > -1.5")
+	assert.NoError(t, err)
+	ok, err = c.Satisfied(map[string]string{"requests.cpu": "500m"})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// Constraints on missing parameters are ignored
+	ok, err = c.Satisfied(map[string]string{})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = c.Satisfied(map[string]string{"requests.cpu": "large"})
+	assert.Error(t, err)
+
+	for _, invalid := range []string{"cpu * replicas", "cpu = 4", "cpu <= (4", "cpu <= 4 4", "'cpu <= 4"} {
+		_, err = ParseConstraint(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestSearchSpaceSize(t *testing.T) {
+	instance := newConditionalExperiment()
+	instance.Spec.TunableParameters[0].Parameters[0].Conditions = nil
+	size, err := SearchSpaceSize(instance)
+	assert.NoError(t, err)
+	assert.Equal(t, 3*2*3*4, size)
+
+	// max_batch_wait is sampled only when batching is enabled
+	instance = newConditionalExperiment()
+	size, err = SearchSpaceSize(instance)
+	assert.NoError(t, err)
+	assert.Equal(t, (3+1)*3*4, size)
+
+	// 4 x 1..4, 8 x 1..4, 16 x 1..2
+	instance.Spec.Constraints = []string{"cpu * replicas <= 32"}
+	size, err = SearchSpaceSize(instance)
+	assert.NoError(t, err)
+	assert.Equal(t, (3+1)*(4+4+2), size)
+
+	instance.Spec.Constraints = []string{"cpu * replicas <= 32", "gpu <= 1"}
+	_, err = SearchSpaceSize(instance)
+	assert.Error(t, err)
+
+	instance = newConditionalExperiment()
+	instance.Spec.TunableParameters[0].Parameters[1].Conditions = []morphlingv1alpha1.ParameterCondition{{Parameter: "max_batch_wait", Values: []string{"5"}}}
+	_, err = SearchSpaceSize(instance)
+	assert.Error(t, err)
+}
+
+func TestFilterAssignments(t *testing.T) {
+	instance := newConditionalExperiment()
+	instance.Spec.Constraints = []string{"cpu * replicas <= 32"}
+	pas := []morphlingv1alpha1.ParameterAssignment{
+		{Name: "max_batch_wait", Value: "10", Category: morphlingv1alpha1.CategoryEnv},
+		{Name: "enable_batching", Value: "false", Category: morphlingv1alpha1.CategoryEnv},
+		{Name: "cpu", Value: "8", Category: morphlingv1alpha1.CategoryResource},
+		{Name: "replicas", Value: "4", Category: morphlingv1alpha1.CategoryReplicas},
+	}
+
+	res, err := filterAssignments(pas, instance)
+	assert.NoError(t, err)
+	assert.Equal(t, pas[1:], res)
+
+	pas[1].Value = "true"
+	res, err = filterAssignments(pas, instance)
+	assert.NoError(t, err)
+	assert.Equal(t, pas, res)
+
+	pas[2].Value = "16"
+	_, err = filterAssignments(pas, instance)
+	assert.Error(t, err)
+}
+
+func TestConvertParsWithConditions(t *testing.T) {
+	instance := newConditionalExperiment()
+	instance.Spec.Constraints = []string{"cpu * replicas <= 32"}
+	request, err := NewSamplingRequest(1, instance, 0, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cpu * replicas <= 32"}, request.Constraints)
+	assert.Len(t, request.Parameters, 4)
+	assert.Equal(t, "enable_batching", request.Parameters[0].Conditions[0].Parameter)
+	assert.Equal(t, []string{"true"}, request.Parameters[0].Conditions[0].Values)
+	assert.Empty(t, request.Parameters[1].Conditions)
+}
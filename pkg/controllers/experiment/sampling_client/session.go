@@ -0,0 +1,148 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sampling_client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	grpcapi "github.com/alibaba/morphling/api/v1alpha1/grpc_proto/grpc_algorithm/go"
+)
+
+// sessionKey identifies the session of an experiment on an algorithm server
+type sessionKey struct {
+	endpoint string
+	id       string
+}
+
+// session is a session created on an algorithm server, with the trials whose results have been reported to it
+type session struct {
+	generation int64
+	reported   map[string]bool
+}
+
+// sessions keeps the experiment history on the algorithm servers, so that a request carries only the results not
+// reported yet instead of all of them. Servers without sessions are sent stateless requests.
+type sessions struct {
+	mu        sync.Mutex
+	sessions  map[sessionKey]*session
+	stateless map[string]bool
+}
+
+func newSessions() *sessions {
+	return &sessions{sessions: map[sessionKey]*session{}, stateless: map[string]bool{}}
+}
+
+// sessionID returns the ID of the session of the experiment, unique across experiments recreated with the same name
+func sessionID(instance *morphlingv1alpha1.ProfilingExperiment) string {
+	return fmt.Sprintf("%s/%s/%s", instance.Namespace, instance.Name, instance.UID)
+}
+
+// getSuggestions returns the samplings of the stateless request through the session of the experiment. The session is
+// created with the spec and the results of the request if the client has not created it, e.g., after a restart, or if
+// the spec has changed since, and created again if the server has lost it.
+func (s *sessions) getSuggestions(ctx context.Context, client grpcapi.SuggestionClient, endpoint string, instance *morphlingv1alpha1.ProfilingExperiment, request *grpcapi.SamplingRequest) (*grpcapi.SamplingResponse, error) {
+	if s.isStateless(endpoint) {
+		return client.GetSuggestions(ctx, request)
+	}
+	key := sessionKey{endpoint: endpoint, id: sessionID(instance)}
+	response, err := s.sample(ctx, client, key, instance.Generation, request)
+	if status.Code(err) == codes.NotFound {
+		log.Info("Session not found, creating it again", "endpoint", endpoint, "session", key.id)
+		s.forget(key)
+		response, err = s.sample(ctx, client, key, instance.Generation, request)
+	}
+	if status.Code(err) == codes.Unimplemented {
+		log.Info("The algorithm server does not support sessions, sending stateless requests", "endpoint", endpoint)
+		s.setStateless(endpoint)
+		return client.GetSuggestions(ctx, request)
+	}
+	return response, err
+}
+
+func (s *sessions) sample(ctx context.Context, client grpcapi.SuggestionClient, key sessionKey, generation int64, request *grpcapi.SamplingRequest) (*grpcapi.SamplingResponse, error) {
+	current := s.get(key)
+	if current == nil || current.generation != generation {
+		if _, err := client.CreateSession(ctx, &grpcapi.CreateSessionRequest{SessionId: key.id, Spec: request}); err != nil {
+			return nil, err
+		}
+		current = &session{generation: generation, reported: map[string]bool{}}
+		for _, result := range request.ExistingResults {
+			current.reported[result.TrialName] = true
+		}
+		s.put(key, current)
+	} else if results := current.unreported(request.ExistingResults); len(results) > 0 {
+		if _, err := client.ReportResult(ctx, &grpcapi.ReportResultRequest{SessionId: key.id, Results: results}); err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		for _, result := range results {
+			current.reported[result.TrialName] = true
+		}
+		s.mu.Unlock()
+	}
+	return client.GetSuggestions(ctx, &grpcapi.SamplingRequest{
+		SessionId:          key.id,
+		RequiredSampling:   request.RequiredSampling,
+		PendingAssignments: request.PendingAssignments,
+	})
+}
+
+func (s *session) unreported(results []*grpcapi.TrialResult) []*grpcapi.TrialResult {
+	var res []*grpcapi.TrialResult
+	for _, result := range results {
+		if !s.reported[result.TrialName] {
+			res = append(res, result)
+		}
+	}
+	return res
+}
+
+func (s *sessions) get(key sessionKey) *session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sessions[key]
+}
+
+func (s *sessions) put(key sessionKey, session *session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[key] = session
+}
+
+func (s *sessions) forget(key sessionKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, key)
+}
+
+func (s *sessions) isStateless(endpoint string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stateless[endpoint]
+}
+
+func (s *sessions) setStateless(endpoint string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stateless[endpoint] = true
+}
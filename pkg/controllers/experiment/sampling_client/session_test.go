@@ -0,0 +1,128 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sampling_client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	grpcapi "github.com/alibaba/morphling/api/v1alpha1/grpc_proto/grpc_algorithm/go"
+)
+
+// fakeSessionServer keeps the sessions in memory, or supports stateless requests only if stateless is set
+type fakeSessionServer struct {
+	stateless bool
+	sessions  map[string][]*grpcapi.TrialResult
+	// requests are the names of the RPCs called
+	requests []string
+	reported []*grpcapi.TrialResult
+}
+
+func (f *fakeSessionServer) GetSuggestions(ctx context.Context, in *grpcapi.SamplingRequest, opts ...grpc.CallOption) (*grpcapi.SamplingResponse, error) {
+	f.requests = append(f.requests, "GetSuggestions")
+	if in.SessionId != "" {
+		if _, ok := f.sessions[in.SessionId]; !ok {
+			return nil, status.Error(codes.NotFound, "session not found")
+		}
+	}
+	return &grpcapi.SamplingResponse{}, nil
+}
+
+func (f *fakeSessionServer) ValidateAlgorithmSettings(ctx context.Context, in *grpcapi.SamplingValidationRequest, opts ...grpc.CallOption) (*grpcapi.SamplingValidationResponse, error) {
+	return &grpcapi.SamplingValidationResponse{}, nil
+}
+
+func (f *fakeSessionServer) CreateSession(ctx context.Context, in *grpcapi.CreateSessionRequest, opts ...grpc.CallOption) (*grpcapi.CreateSessionResponse, error) {
+	f.requests = append(f.requests, "CreateSession")
+	if f.stateless {
+		return nil, status.Error(codes.Unimplemented, "unknown method CreateSession")
+	}
+	f.sessions[in.SessionId] = in.Spec.ExistingResults
+	return &grpcapi.CreateSessionResponse{}, nil
+}
+
+func (f *fakeSessionServer) ReportResult(ctx context.Context, in *grpcapi.ReportResultRequest, opts ...grpc.CallOption) (*grpcapi.ReportResultResponse, error) {
+	f.requests = append(f.requests, "ReportResult")
+	if _, ok := f.sessions[in.SessionId]; !ok {
+		return nil, status.Error(codes.NotFound, "session not found")
+	}
+	f.sessions[in.SessionId] = append(f.sessions[in.SessionId], in.Results...)
+	f.reported = in.Results
+	return &grpcapi.ReportResultResponse{}, nil
+}
+
+func newResultsRequest(trials ...string) *grpcapi.SamplingRequest {
+	request := &grpcapi.SamplingRequest{AlgorithmName: "grid", RequiredSampling: 1}
+	for _, trial := range trials {
+		request.ExistingResults = append(request.ExistingResults, &grpcapi.TrialResult{TrialName: trial})
+	}
+	return request
+}
+
+func TestSessions(t *testing.T) {
+	server := &fakeSessionServer{sessions: map[string][]*grpcapi.TrialResult{}}
+	s := newSessions()
+	pe := &morphlingv1alpha1.ProfilingExperiment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pe", UID: "uid", Generation: 1}}
+	sample := func(request *grpcapi.SamplingRequest) {
+		server.requests, server.reported = nil, nil
+		_, err := s.getSuggestions(context.TODO(), server, "algorithm:9996", pe, request)
+		assert.NoError(t, err)
+	}
+
+	// The session is created with the results so far
+	sample(newResultsRequest("pe-1-0"))
+	assert.Equal(t, []string{"CreateSession", "GetSuggestions"}, server.requests)
+	assert.Len(t, server.sessions["default/pe/uid"], 1)
+
+	// Only new results are reported
+	sample(newResultsRequest("pe-1-0", "pe-1-1", "pe-1-2"))
+	assert.Equal(t, []string{"ReportResult", "GetSuggestions"}, server.requests)
+	assert.Len(t, server.reported, 2)
+	sample(newResultsRequest("pe-1-0", "pe-1-1", "pe-1-2"))
+	assert.Equal(t, []string{"GetSuggestions"}, server.requests)
+
+	// A session lost by the server is created again with all results
+	delete(server.sessions, "default/pe/uid")
+	sample(newResultsRequest("pe-1-0", "pe-1-1", "pe-1-2"))
+	assert.Equal(t, []string{"GetSuggestions", "CreateSession", "GetSuggestions"}, server.requests)
+	assert.Len(t, server.sessions["default/pe/uid"], 3)
+
+	// The session is replaced once the spec changes
+	pe.Generation = 2
+	sample(newResultsRequest("pe-1-0", "pe-1-1", "pe-1-2"))
+	assert.Equal(t, []string{"CreateSession", "GetSuggestions"}, server.requests)
+
+	// Servers without sessions are sent stateless requests
+	stateless := &fakeSessionServer{stateless: true}
+	for i := 0; i < 2; i++ {
+		stateless.requests = nil
+		_, err := s.getSuggestions(context.TODO(), stateless, "stateless:9996", pe, newResultsRequest("pe-1-0"))
+		assert.NoError(t, err)
+		if i == 0 {
+			assert.Equal(t, []string{"CreateSession", "GetSuggestions"}, stateless.requests)
+		} else {
+			assert.Equal(t, []string{"GetSuggestions"}, stateless.requests)
+		}
+	}
+}
@@ -17,9 +17,12 @@ limitations under the License.
 package experiment
 
 import (
-	"github.com/alibaba/morphling/pkg/controllers/consts"
+	"fmt"
+	"math"
 	"strconv"
 
+	"github.com/alibaba/morphling/pkg/controllers/consts"
+
 	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
 	samplingClient "github.com/alibaba/morphling/pkg/controllers/experiment/sampling_client"
 	"github.com/alibaba/morphling/pkg/controllers/util"
@@ -33,6 +36,7 @@ func updateTrialsSummary(instance *morphlingv1alpha1.ProfilingExperiment, trials
 	sts.TrialsTotal = 0
 	sts.RunningTrialList, sts.PendingTrialList, sts.FailedTrialList, sts.SucceededTrialList, sts.KilledTrialList = nil, nil, nil, nil, nil
 	bestTrialIndex := -1
+	bestTrialRung := int32(0)
 	objectiveType := instance.Spec.Objective.Type
 	objectiveMetricName := instance.Spec.Objective.ObjectiveMetricName
 
@@ -57,6 +61,15 @@ func updateTrialsSummary(instance *morphlingv1alpha1.ProfilingExperiment, trials
 			continue
 		}
 
+		// Trials of multi-fidelity experiments are only compared with the trials of the highest rung with results
+		if instance.Spec.MultiFidelity != nil {
+			if rung := trialRung(&trials.Items[index]); rung < bestTrialRung {
+				continue
+			} else if rung > bestTrialRung {
+				bestTrialRung, bestTrialIndex = rung, -1
+			}
+		}
+
 		// Initialize vars to objective metric value of the first trial
 		if bestTrialIndex == -1 {
 			bestTrialValue = *objectiveMetricValue
@@ -112,23 +125,56 @@ func getObjectiveMetricValue(trial morphlingv1alpha1.Trial, objectiveMetricName
 }
 
 // updateExperimentStatusCondition updates the experiment status.
-func updateExperimentStatusCondition(instance *morphlingv1alpha1.ProfilingExperiment) {
-	completedTrialsCount := instance.Status.TrialsSucceeded + instance.Status.TrialsFailed + instance.Status.TrialsKilled
+func updateExperimentStatusCondition(instance *morphlingv1alpha1.ProfilingExperiment, trials []morphlingv1alpha1.Trial) {
+	completedTrialsCount := instance.Status.TrialsSucceeded + instance.Status.TrialsFailed + instance.Status.TrialsKilled - suspendKilledCount(trials)
 	now := metav1.Now()
 
-	// Check if MaxTrialCount is reached.
-	if (instance.Spec.MaxNumTrials != nil) && (completedTrialsCount >= *instance.Spec.MaxNumTrials) {
-		msg := "Experiment has succeeded because max trial count has reached"
-		util.MarkExperimentStatusSucceeded(instance, msg)
-		instance.Status.CompletionTime = &now
+	// Check if the experiment is suspended, so that killing its trials does not complete it.
+	if isSuspended(instance) {
+		msg := "Experiment is suspended"
+		if instance.Spec.SuspendPolicy == morphlingv1alpha1.SuspendPolicyKill {
+			msg += ", and its pending and running trials are killed"
+		}
+		util.MarkExperimentStatusSuspended(instance, msg)
 		return
 	}
 
-	// Check if Sampling space is exhausted.
-	if (CalculateMaximumSearchSpace(instance) > 0) && (int(completedTrialsCount) >= CalculateMaximumSearchSpace(instance)) {
-		msg := "Experiment has succeeded because maximum search space has reached"
-		util.MarkExperimentStatusSucceeded(instance, msg)
-		instance.Status.CompletionTime = &now
+	// Multi-fidelity experiments complete with their rungs rather than after max trial count
+	if instance.Spec.MultiFidelity != nil {
+		if rungsCompleted(instance) {
+			msg := fmt.Sprintf("Experiment has succeeded because all %d rungs have completed", len(instance.Status.Rungs))
+			if last := instance.Status.Rungs[len(instance.Status.Rungs)-1]; last.Trials == 0 {
+				msg = "Experiment has succeeded because no trial was promoted to the next rung"
+			}
+			util.MarkExperimentStatusSucceeded(instance, msg)
+			instance.Status.CompletionTime = &now
+			return
+		}
+	} else {
+		// Check if MaxTrialCount is reached.
+		if (instance.Spec.MaxNumTrials != nil) && (completedTrialsCount >= *instance.Spec.MaxNumTrials) {
+			msg := "Experiment has succeeded because max trial count has reached"
+			util.MarkExperimentStatusSucceeded(instance, msg)
+			instance.Status.CompletionTime = &now
+			return
+		}
+
+		// Check if the search space is exhausted, once all trials of its assignments are completed
+		if searchSpaceExhausted(instance) && instance.Status.TrialsPending+instance.Status.TrialsRunning == 0 && len(instance.Status.PendingSamplings) == 0 {
+			space := instance.Status.SearchSpace
+			msg := fmt.Sprintf("Experiment has succeeded because the search space is exhausted, %d of %d distinct assignments have succeeded", space.Succeeded, space.Tried)
+			if space.Failed > 0 {
+				msg += fmt.Sprintf(" and %d have failed", space.Failed)
+			}
+			util.MarkExperimentStatusSucceeded(instance, msg)
+			instance.Status.CompletionTime = &now
+			return
+		}
+	}
+
+	// Restarting experiments run again once their active trials fit in the parallelism
+	activeTrialsCount := instance.Status.TrialsPending + instance.Status.TrialsRunning
+	if util.IsRestartingExperiment(instance) && instance.Spec.Parallelism != nil && activeTrialsCount > *instance.Spec.Parallelism {
 		return
 	}
 
@@ -136,23 +182,53 @@ func updateExperimentStatusCondition(instance *morphlingv1alpha1.ProfilingExperi
 	util.MarkExperimentStatusRunning(instance, msg)
 }
 
-func CalculateMaximumSearchSpace(instance *morphlingv1alpha1.ProfilingExperiment) int {
-	space := int(1)
-	for _, cat := range instance.Spec.TunableParameters {
-		for _, p := range cat.Parameters {
-			feasibleSpace, err := samplingClient.ConvertFeasibleSpace(p.FeasibleSpace, p.ParameterType)
-			if err != nil {
-				log.Error(err, "failed to calculate maximum search space")
-				return 0
-			}
-			if int(len(feasibleSpace)) <= 0 {
-				log.Error(err, "failed to calculate maximum search space")
-				return 0
-			}
-			space *= int(len(feasibleSpace))
+// updateSearchSpace computes the size of the search space once per generation of the experiment, and counts the
+// distinct assignments tried by the trials. Killed trials do not count, their assignments may be sampled again.
+func updateSearchSpace(instance *morphlingv1alpha1.ProfilingExperiment, trials []morphlingv1alpha1.Trial) error {
+	space := instance.Status.SearchSpace
+	if space == nil || space.ObservedGeneration != instance.Generation {
+		size, err := samplingClient.SearchSpaceSize(instance)
+		if err != nil {
+			return fmt.Errorf("failed to calculate the search space: %v", err)
+		}
+		if size > math.MaxInt32 {
+			size = math.MaxInt32
+		}
+		space = &morphlingv1alpha1.SearchSpaceStatus{ObservedGeneration: instance.Generation, Size: int32(size)}
+		instance.Status.SearchSpace = space
+	}
+
+	tried, succeeded, failed := map[string]bool{}, map[string]bool{}, map[string]bool{}
+	for i := range trials {
+		trial := &trials[i]
+		if util.IsKilledTrial(trial) {
+			continue
 		}
+		key := assignmentsKey(trial.Spec.SamplingResult)
+		tried[key] = true
+		if util.IsSucceededTrial(trial) {
+			succeeded[key] = true
+		} else if util.IsFailedTrial(trial) {
+			failed[key] = true
+		}
+	}
+	space.Tried, space.Succeeded, space.Failed = int32(len(tried)), int32(len(succeeded)), 0
+	for key := range failed {
+		if !succeeded[key] {
+			space.Failed++
+		}
+	}
+	return nil
+}
+
+// searchSpaceExhausted returns whether all assignments of the search space are tried, or the sampler has reported it
+// has no more assignments to suggest
+func searchSpaceExhausted(instance *morphlingv1alpha1.ProfilingExperiment) bool {
+	space := instance.Status.SearchSpace
+	if space == nil {
+		return false
 	}
-	return space
+	return space.Exhausted || (space.Size > 0 && space.Tried >= space.Size)
 }
 
 // TrialLabels returns the expected trial labels.
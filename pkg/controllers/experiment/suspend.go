@@ -0,0 +1,102 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	"github.com/alibaba/morphling/pkg/controllers/util"
+)
+
+const suspendKilledMessage = "Trial is killed as the experiment is suspended"
+
+func isSuspended(instance *morphlingv1alpha1.ProfilingExperiment) bool {
+	return instance.Spec.Suspend != nil && *instance.Spec.Suspend
+}
+
+// suspendKilledCount returns the number of trials killed on suspension of the experiment. They do not count toward
+// maxNumTrials, since their assignments are sampled again on resumption.
+func suspendKilledCount(trials []morphlingv1alpha1.Trial) int32 {
+	count := int32(0)
+	for i := range trials {
+		if util.GetTrialKilledReason(&trials[i]) == morphlingv1alpha1.TrialKilledOnSuspension {
+			count++
+		}
+	}
+	return count
+}
+
+// observeSpecUpdate marks the experiment restarting if its spec has changed since last observed, e.g., on resumption
+// or changes of maxNumTrials or parallelism, and drops the pending samplings beyond the new max trial count. It returns
+// whether the experiment is restarted.
+func observeSpecUpdate(instance *morphlingv1alpha1.ProfilingExperiment, trials []morphlingv1alpha1.Trial) bool {
+	sts := &instance.Status
+	observed := sts.ObservedGeneration
+	if observed == instance.Generation {
+		return false
+	}
+	sts.ObservedGeneration = instance.Generation
+	if observed == 0 || isSuspended(instance) {
+		return false
+	}
+
+	if instance.Spec.MaxNumTrials != nil {
+		budget := *instance.Spec.MaxNumTrials - sts.TrialsTotal + suspendKilledCount(trials)
+		if budget < 0 {
+			budget = 0
+		}
+		if int(budget) < len(sts.PendingSamplings) {
+			log.Info("Drop pending samplings beyond max trial count", "experiment", instance.Name, "dropped", len(sts.PendingSamplings)-int(budget))
+			sts.PendingSamplings = sts.PendingSamplings[:budget]
+			if budget == 0 {
+				sts.PendingSamplings = nil
+			}
+		}
+	}
+	msg := fmt.Sprintf("Experiment is restarting with the spec of generation %d", instance.Generation)
+	if instance.Spec.MaxNumTrials != nil && instance.Spec.Parallelism != nil {
+		msg += fmt.Sprintf(", maxNumTrials %d and parallelism %d", *instance.Spec.MaxNumTrials, *instance.Spec.Parallelism)
+	}
+	util.MarkExperimentStatusRestarting(instance, msg)
+	return true
+}
+
+// killActiveTrials kills the pending and running trials of a suspended experiment with the Kill suspend policy
+func (r *ProfilingExperimentReconciler) killActiveTrials(instance *morphlingv1alpha1.ProfilingExperiment, trials []morphlingv1alpha1.Trial) error {
+	logger := log.WithValues("Experiment", types.NamespacedName{Name: instance.GetName(), Namespace: instance.GetNamespace()})
+
+	for i := range trials {
+		trial := trials[i].DeepCopy()
+		if util.IsCompletedTrial(trial) {
+			continue
+		}
+		if err := util.KillTrial(r.Client, trial, morphlingv1alpha1.TrialKilledOnSuspension, suspendKilledMessage); err != nil {
+			if errors.IsConflict(err) {
+				// Killed on the next reconcile
+				continue
+			}
+			logger.Error(err, "Kill trial error", "trial", trial.Name)
+			return err
+		}
+		logger.Info("Trial killed", "trial", trial.Name)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	"github.com/alibaba/morphling/pkg/controllers/util"
+)
+
+func TestSuspendAndResume(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, morphlingv1alpha1.AddToScheme(scheme))
+	maxNumTrials, parallelism, suspend := int32(4), int32(2), true
+	pe := &morphlingv1alpha1.ProfilingExperiment{
+		ObjectMeta: metav1.ObjectMeta{Name: "exp", Namespace: "default", Generation: 1},
+		Spec: morphlingv1alpha1.ProfilingExperimentSpec{
+			TunableParameters: []morphlingv1alpha1.ParameterCategory{{
+				Category: morphlingv1alpha1.CategoryResource,
+				Parameters: []morphlingv1alpha1.ParameterSpec{{
+					Name:          "cpu",
+					ParameterType: morphlingv1alpha1.ParameterTypeDiscrete,
+					FeasibleSpace: morphlingv1alpha1.FeasibleSpace{List: []string{"1", "2", "4", "8"}},
+				}},
+			}},
+			MaxNumTrials: &maxNumTrials,
+			Parallelism:  &parallelism,
+		},
+	}
+	util.MarkExperimentStatusCreated(pe, "created")
+	c := fake.NewFakeClientWithScheme(scheme)
+	sampling := &stubSampling{assignments: []morphlingv1alpha1.TrialAssignment{
+		{ParameterAssignments: cpuAssignment("1")},
+		{ParameterAssignments: cpuAssignment("2")},
+	}}
+	r := &ProfilingExperimentReconciler{Client: c, Scheme: scheme, Sampling: sampling}
+	trial := &morphlingv1alpha1.Trial{}
+
+	assert.NoError(t, r.ReconcileExperiment(pe))
+	assert.True(t, util.IsRunningExperiment(pe))
+	assert.Equal(t, int64(1), pe.Status.ObservedGeneration)
+
+	// Suspension kills the active trials and creates no more
+	pe.Spec.Suspend, pe.Spec.SuspendPolicy, pe.Generation = &suspend, morphlingv1alpha1.SuspendPolicyKill, 2
+	assert.NoError(t, r.ReconcileExperiment(pe))
+	assert.True(t, util.IsSuspendedExperiment(pe))
+	assert.False(t, util.IsRunningExperiment(pe))
+	assert.NoError(t, c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "exp-1-0"}, trial))
+	assert.True(t, util.IsKilledTrial(trial))
+	assert.Equal(t, morphlingv1alpha1.TrialKilledOnSuspension, util.GetTrialKilledReason(trial))
+	assert.NoError(t, r.ReconcileExperiment(pe))
+	assert.Equal(t, int32(2), pe.Status.TrialsKilled)
+
+	// Trials are told killed on suspension by the reason rather than the message
+	byUser := newCPUTrial("exp-1-9", "8", func(trial *morphlingv1alpha1.Trial) { util.MarkTrialStatusKilled(trial, suspendKilledMessage) })
+	assert.Equal(t, int32(0), suspendKilledCount([]morphlingv1alpha1.Trial{byUser}))
+	assert.Equal(t, 1, sampling.calls)
+
+	// Resumption restarts the experiment with the new parallelism, then samples the killed configurations again
+	suspend = false
+	parallelism = 1
+	pe.Generation = 3
+	assert.NoError(t, r.ReconcileExperiment(pe))
+	assert.True(t, util.IsRestartingExperiment(pe))
+	assert.Equal(t, 1, sampling.calls)
+	assert.NoError(t, r.ReconcileExperiment(pe))
+	assert.True(t, util.IsRunningExperiment(pe))
+	assert.False(t, util.IsRestartingExperiment(pe))
+	assert.False(t, util.IsSuspendedExperiment(pe))
+	assert.Equal(t, 2, sampling.calls)
+	assert.NoError(t, c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "exp-3-2"}, trial))
+	assert.Equal(t, "1", trial.Spec.SamplingResult[0].Value)
+	assert.Len(t, pe.Status.PendingSamplings, 1)
+
+	// Pending samplings beyond a lowered max trial count are dropped, the killed trials do not count
+	maxNumTrials = 1
+	pe.Generation = 4
+	assert.NoError(t, r.ReconcileExperiment(pe))
+	assert.True(t, util.IsRestartingExperiment(pe))
+	assert.Empty(t, pe.Status.PendingSamplings)
+}
+
+func TestSuspendKillWithinMaxNumTrials(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, morphlingv1alpha1.AddToScheme(scheme))
+	maxNumTrials, parallelism, suspend := int32(2), int32(2), true
+	pe := &morphlingv1alpha1.ProfilingExperiment{
+		ObjectMeta: metav1.ObjectMeta{Name: "exp", Namespace: "default", Generation: 1},
+		Spec: morphlingv1alpha1.ProfilingExperimentSpec{
+			TunableParameters: []morphlingv1alpha1.ParameterCategory{{
+				Category: morphlingv1alpha1.CategoryResource,
+				Parameters: []morphlingv1alpha1.ParameterSpec{{
+					Name:          "cpu",
+					ParameterType: morphlingv1alpha1.ParameterTypeDiscrete,
+					FeasibleSpace: morphlingv1alpha1.FeasibleSpace{List: []string{"1", "2", "4", "8"}},
+				}},
+			}},
+			MaxNumTrials: &maxNumTrials,
+			Parallelism:  &parallelism,
+		},
+	}
+	util.MarkExperimentStatusCreated(pe, "created")
+	c := fake.NewFakeClientWithScheme(scheme)
+	sampling := &stubSampling{assignments: []morphlingv1alpha1.TrialAssignment{
+		{ParameterAssignments: cpuAssignment("1")},
+		{ParameterAssignments: cpuAssignment("2")},
+	}}
+	r := &ProfilingExperimentReconciler{Client: c, Scheme: scheme, Sampling: sampling}
+	assert.NoError(t, r.ReconcileExperiment(pe))
+
+	// Killing all trials of the budget on suspension does not complete the experiment
+	pe.Spec.Suspend, pe.Spec.SuspendPolicy, pe.Generation = &suspend, morphlingv1alpha1.SuspendPolicyKill, 2
+	assert.NoError(t, r.ReconcileExperiment(pe))
+	assert.NoError(t, r.ReconcileExperiment(pe))
+	assert.Equal(t, int32(2), pe.Status.TrialsKilled)
+	assert.True(t, util.IsSuspendedExperiment(pe))
+	assert.False(t, util.IsCompletedExperiment(pe))
+
+	// The killed trials are created again on resumption, within max trial count
+	suspend = false
+	pe.Generation = 3
+	assert.NoError(t, r.ReconcileExperiment(pe))
+	assert.NoError(t, r.ReconcileExperiment(pe))
+	assert.True(t, util.IsRunningExperiment(pe))
+	assert.Equal(t, 2, sampling.calls)
+	trials := &morphlingv1alpha1.TrialList{}
+	assert.NoError(t, c.List(context.TODO(), trials))
+	assert.Len(t, trials.Items, 4)
+}
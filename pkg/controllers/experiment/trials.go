@@ -2,8 +2,16 @@ package experiment
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
 	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
 	"github.com/alibaba/morphling/pkg/controllers/consts"
+	"github.com/alibaba/morphling/pkg/controllers/experiment/sampling_client"
+	"github.com/alibaba/morphling/pkg/controllers/util"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -14,9 +22,14 @@ import (
 func (r *ProfilingExperimentReconciler) ReconcileTrials(instance *morphlingv1alpha1.ProfilingExperiment, trials []morphlingv1alpha1.Trial) error {
 	logger := log.WithValues("Experiment", types.NamespacedName{Name: instance.GetName(), Namespace: instance.GetNamespace()})
 
+	// Trials of multi-fidelity experiments are created rung by rung
+	if instance.Spec.MultiFidelity != nil {
+		return r.reconcileRungs(instance, trials)
+	}
+
 	parallelCount := *instance.Spec.Parallelism
 	activeCount := instance.Status.TrialsPending + instance.Status.TrialsRunning
-	completedCount := instance.Status.TrialsSucceeded + instance.Status.TrialsFailed + instance.Status.TrialsKilled
+	completedCount := instance.Status.TrialsSucceeded + instance.Status.TrialsFailed + instance.Status.TrialsKilled - suspendKilledCount(trials)
 
 	// If new trials are requested
 	if activeCount < parallelCount {
@@ -47,30 +60,121 @@ func (r *ProfilingExperimentReconciler) ReconcileTrials(instance *morphlingv1alp
 	return nil
 }
 
-// createTrials gets sampling_client results and creates new trials
+// createTrials creates new trials of the pending samplings, and gets new samplings once all pending ones are created.
+// Samplings are kept pending in the experiment status until their trials are created, so that failed creations are
+// retried rather than sampled again.
 func (r *ProfilingExperimentReconciler) createTrials(instance *morphlingv1alpha1.ProfilingExperiment, trialList []morphlingv1alpha1.Trial, addCount int32) error {
 	logger := log.WithValues("Experiment", types.NamespacedName{Name: instance.GetName(), Namespace: instance.GetNamespace()})
 
 	// Fetch sampling_client results
-	currentCount := int32(len(trialList))
-	assignments, err := r.GetSamplings(addCount, instance, currentCount, trialList)
-	if err != nil {
-		logger.Error(err, "Get samplings error")
-		return err
+	if len(instance.Status.PendingSamplings) == 0 {
+		if searchSpaceExhausted(instance) {
+			logger.V(1).Info("No samplings are left in the search space", "addCount", addCount)
+			return nil
+		}
+		currentCount := int32(len(trialList)) - suspendKilledCount(trialList)
+		assignments, err := r.GetSamplings(addCount, instance, currentCount, trialList)
+		if err == sampling_client.ErrSamplingInProgress {
+			// Reconciled again once the samplings are computed
+			logger.V(1).Info("Samplings are being computed", "addCount", addCount)
+			return nil
+		}
+		if err == sampling_client.ErrSearchSpaceExhausted {
+			// The last samplings are created, and no more are requested
+			logger.Info("The search space is exhausted", "samplings", len(assignments))
+			if instance.Status.SearchSpace != nil {
+				instance.Status.SearchSpace.Exhausted = true
+			}
+			err = nil
+		}
+		if err != nil {
+			logger.Error(err, "Get samplings error")
+			return err
+		}
+		instance.Status.PendingSamplings = nameSamplings(instance, trialList, assignments)
 	}
 
 	// Create new trials w.r.t. sampling_client results
-	for _, assignment := range assignments {
-		if err = r.createTrialInstance(instance, &assignment); err != nil {
-			logger.Error(err, "Create trial instance error", "trial", assignment)
+	pending := make([]morphlingv1alpha1.TrialAssignment, 0)
+	for i := range instance.Status.PendingSamplings {
+		assignment := &instance.Status.PendingSamplings[i]
+		if int32(i) >= addCount {
+			pending = append(pending, *assignment)
 			continue
 		}
+		if err := r.createTrialInstance(instance, assignment, 0); err != nil {
+			logger.Error(err, "Create trial instance error", "trial", assignment)
+			pending = append(pending, *assignment)
+		}
+	}
+	instance.Status.PendingSamplings = nil
+	if len(pending) > 0 {
+		instance.Status.PendingSamplings = pending
 	}
 	return nil
 }
 
-// createTrialInstance creates a new trial instance
-func (r *ProfilingExperimentReconciler) createTrialInstance(expInstance *morphlingv1alpha1.ProfilingExperiment, trialAssignment *morphlingv1alpha1.TrialAssignment) error {
+// nameSamplings names the samplings after the generation of the experiment and their index among all samplings of the
+// experiment, so that retries never create the same sampling twice, and drops the samplings identical to existing
+// trials, except killed ones, or to each other
+func nameSamplings(instance *morphlingv1alpha1.ProfilingExperiment, trialList []morphlingv1alpha1.Trial, assignments []morphlingv1alpha1.TrialAssignment) []morphlingv1alpha1.TrialAssignment {
+	instance.Status.SamplingCount = nextSamplingIndex(instance, trialList)
+	seen := make(map[string]bool, len(trialList))
+	for i := range trialList {
+		// The assignments of killed trials may be tried again
+		if !util.IsKilledTrial(&trialList[i]) {
+			seen[assignmentsKey(trialList[i].Spec.SamplingResult)] = true
+		}
+	}
+	samplings := make([]morphlingv1alpha1.TrialAssignment, 0, len(assignments))
+	for _, assignment := range assignments {
+		name := fmt.Sprintf("%s-%d-%d", instance.Name, instance.Generation, instance.Status.SamplingCount)
+		instance.Status.SamplingCount++
+		key := assignmentsKey(assignment.ParameterAssignments)
+		if seen[key] {
+			log.Info("Skip sampling identical to an existing trial", "experiment", instance.Name, "sampling", key)
+			continue
+		}
+		seen[key] = true
+		assignment.Name = name
+		samplings = append(samplings, assignment)
+	}
+	return samplings
+}
+
+// nextSamplingIndex returns the index of the next sampling, after the indexes of the existing trials, as the count of
+// samplings in the status is stale if its update conflicted
+func nextSamplingIndex(instance *morphlingv1alpha1.ProfilingExperiment, trialList []morphlingv1alpha1.Trial) int32 {
+	next := instance.Status.SamplingCount
+	prefix := instance.Name + "-"
+	for i := range trialList {
+		if !strings.HasPrefix(trialList[i].Name, prefix) {
+			continue
+		}
+		// Trials are named <experiment>-<generation>-<index>, followed by -r<rung> if promoted
+		parts := strings.Split(strings.TrimPrefix(trialList[i].Name, prefix), "-")
+		if len(parts) < 2 {
+			continue
+		}
+		if index, err := strconv.ParseInt(parts[1], 10, 32); err == nil && int32(index) >= next {
+			next = int32(index) + 1
+		}
+	}
+	return next
+}
+
+// assignmentsKey returns the key of the parameter assignments, regardless of their order
+func assignmentsKey(assignments []morphlingv1alpha1.ParameterAssignment) string {
+	pairs := make([]string, 0, len(assignments))
+	for _, pa := range assignments {
+		pairs = append(pairs, fmt.Sprintf("%s/%s=%s", pa.Category, pa.Name, pa.Value))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// createTrialInstance creates a new trial instance, profiled at the fidelity of the rung for multi-fidelity experiments
+func (r *ProfilingExperimentReconciler) createTrialInstance(expInstance *morphlingv1alpha1.ProfilingExperiment, trialAssignment *morphlingv1alpha1.TrialAssignment, rung int32) error {
 	logger := log.WithValues("Experiment", types.NamespacedName{Name: expInstance.GetName(), Namespace: expInstance.GetNamespace()})
 
 	// Init a new trial instance
@@ -85,28 +189,96 @@ func (r *ProfilingExperimentReconciler) createTrialInstance(expInstance *morphli
 
 	// Set parameters for the new trial
 	trial.Spec.ServiceProgressDeadline = expInstance.Spec.ServiceProgressDeadline
+	trial.Spec.ServiceWorkload = expInstance.Spec.ServiceWorkload.DeepCopy()
+	trial.Spec.Service = expInstance.Spec.Service.DeepCopy()
+	trial.Spec.CostModel = expInstance.Spec.CostModel.DeepCopy()
 	trial.Spec.Objective = expInstance.Spec.Objective
 	trial.Spec.RequestTemplate = expInstance.Spec.RequestTemplate
 	expInstance.Spec.ServicePodTemplate.DeepCopyInto(&trial.Spec.ServicePodTemplate)
 	expInstance.Spec.ClientTemplate.DeepCopyInto(&trial.Spec.ClientTemplate)
 	trial.Spec.SamplingResult = make([]morphlingv1alpha1.ParameterAssignment, 0)
 	for _, pa := range trialAssignment.ParameterAssignments {
-		trial.Spec.SamplingResult = append(trial.Spec.SamplingResult, morphlingv1alpha1.ParameterAssignment{
+		assignment := morphlingv1alpha1.ParameterAssignment{
 			Name:     pa.Name,
 			Value:    pa.Value,
 			Category: pa.Category,
-			//todo: Category
-		})
+		}
+		// Record the containers receiving the value, and how it is rendered into args
+		assignment.Containers, assignment.InitContainers = parameterContainers(expInstance, pa.Category, pa.Name)
+		if _, spec := findParameter(expInstance, pa.Category, pa.Name); spec != nil {
+			assignment.ArgTemplate = spec.ArgTemplate
+		}
+		trial.Spec.SamplingResult = append(trial.Spec.SamplingResult, assignment)
+	}
+	if spec := expInstance.Spec.MultiFidelity; spec != nil {
+		trial.Spec.Fidelity = &morphlingv1alpha1.TrialFidelity{Name: spec.Name, Value: rungFidelities(spec)[rung], Rung: rung}
 	}
 
 	// Create the new trial
 	if err := r.Create(context.TODO(), trial); err != nil {
+		if errors.IsAlreadyExists(err) {
+			// Created by a former reconcile whose status update is lost, unless the name is taken by another assignment
+			existing := &morphlingv1alpha1.Trial{}
+			if err := r.Get(context.TODO(), types.NamespacedName{Namespace: trial.Namespace, Name: trial.Name}, existing); err != nil {
+				return err
+			}
+			if assignmentsKey(existing.Spec.SamplingResult) != assignmentsKey(trial.Spec.SamplingResult) {
+				return fmt.Errorf("trial %s already exists with assignments %s rather than %s", trial.Name,
+					assignmentsKey(existing.Spec.SamplingResult), assignmentsKey(trial.Spec.SamplingResult))
+			}
+			logger.Info("Trial already exists", "Trial name", trial.GetName())
+			return nil
+		}
 		logger.Error(err, "Trial create error", "Trial name", trial.GetName())
 		return err
 	}
 	return nil
 }
 
+// findParameter returns the spec of the tunable parameter and its category, nil if not found
+func findParameter(expInstance *morphlingv1alpha1.ProfilingExperiment, category morphlingv1alpha1.Category, name string) (*morphlingv1alpha1.ParameterCategory, *morphlingv1alpha1.ParameterSpec) {
+	for i := range expInstance.Spec.TunableParameters {
+		c := &expInstance.Spec.TunableParameters[i]
+		if c.Category != category {
+			continue
+		}
+		for j := range c.Parameters {
+			if c.Parameters[j].Name == name {
+				return c, &c.Parameters[j]
+			}
+		}
+	}
+	return nil, nil
+}
+
+// parameterContainers returns the containers and init containers of service pods receiving the parameter,
+// by the selector of the parameter or its category, or all containers of the service pod template if not selected
+func parameterContainers(expInstance *morphlingv1alpha1.ProfilingExperiment, category morphlingv1alpha1.Category, name string) ([]string, []string) {
+	if category == morphlingv1alpha1.CategoryReplicas {
+		return nil, nil
+	}
+	var selector *morphlingv1alpha1.ContainerSelector
+	if c, p := findParameter(expInstance, category, name); p != nil {
+		selector = c.ContainerSelector
+		if p.ContainerSelector != nil {
+			selector = p.ContainerSelector
+		}
+	}
+	if selector != nil {
+		selector = selector.DeepCopy()
+		return selector.Containers, selector.InitContainers
+	}
+	// Pods of unstructured workloads may be defined by their own templates, so containers are left unnamed for all of them
+	if w := expInstance.Spec.ServiceWorkload; w != nil && w.Kind == morphlingv1alpha1.ServiceWorkloadUnstructured {
+		return nil, nil
+	}
+	var containers []string
+	for _, c := range expInstance.Spec.ServicePodTemplate.Template.Spec.Containers {
+		containers = append(containers, c.Name)
+	}
+	return containers, nil
+}
+
 // fetchTrials get trial list of this experiment
 func (r *ProfilingExperimentReconciler) fetchTrials(instance *morphlingv1alpha1.ProfilingExperiment) (*morphlingv1alpha1.TrialList, error) {
 	trials := &morphlingv1alpha1.TrialList{}
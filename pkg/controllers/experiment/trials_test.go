@@ -0,0 +1,186 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	"github.com/alibaba/morphling/pkg/controllers/experiment/sampling_client"
+	"github.com/alibaba/morphling/pkg/controllers/util"
+)
+
+// stubSampling returns the same samplings and error on every call
+type stubSampling struct {
+	assignments []morphlingv1alpha1.TrialAssignment
+	err         error
+	calls       int
+}
+
+func (s *stubSampling) GetSamplings(requestNum int32, instance *morphlingv1alpha1.ProfilingExperiment, currentCount int32, trials []morphlingv1alpha1.Trial) ([]morphlingv1alpha1.TrialAssignment, error) {
+	s.calls++
+	return s.assignments, s.err
+}
+
+func cpuAssignment(value string) []morphlingv1alpha1.ParameterAssignment {
+	return []morphlingv1alpha1.ParameterAssignment{{Name: "cpu", Value: value, Category: morphlingv1alpha1.CategoryResource}}
+}
+
+func TestCreateTrials(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, morphlingv1alpha1.AddToScheme(scheme))
+	pe := &morphlingv1alpha1.ProfilingExperiment{
+		ObjectMeta: metav1.ObjectMeta{Name: "exp", Namespace: "default", Generation: 1},
+	}
+	existing := morphlingv1alpha1.Trial{
+		ObjectMeta: metav1.ObjectMeta{Name: "exp-1-3", Namespace: "default"},
+		Spec:       morphlingv1alpha1.TrialSpec{SamplingResult: cpuAssignment("4")},
+	}
+	c := fake.NewFakeClientWithScheme(scheme, existing.DeepCopy())
+	sampling := &stubSampling{assignments: []morphlingv1alpha1.TrialAssignment{
+		{ParameterAssignments: cpuAssignment("4")},
+		{ParameterAssignments: cpuAssignment("2")},
+		{ParameterAssignments: cpuAssignment("2")},
+		{ParameterAssignments: cpuAssignment("3")},
+	}}
+	r := &ProfilingExperimentReconciler{Client: c, Scheme: scheme, Sampling: sampling}
+
+	// Samplings are named after the existing trials, as the sampling count of the status may be stale. Samplings identical
+	// to existing trials or to each other are dropped, and the ones beyond addCount are pending
+	assert.NoError(t, r.createTrials(pe, []morphlingv1alpha1.Trial{existing}, 1))
+	assert.Equal(t, 1, sampling.calls)
+	assert.Equal(t, int32(8), pe.Status.SamplingCount)
+	assert.Equal(t, []morphlingv1alpha1.TrialAssignment{{Name: "exp-1-7", ParameterAssignments: cpuAssignment("3")}}, pe.Status.PendingSamplings)
+	trial := &morphlingv1alpha1.Trial{}
+	assert.NoError(t, c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "exp-1-5"}, trial))
+	assert.Equal(t, "2", trial.Spec.SamplingResult[0].Value)
+
+	// Pending samplings are created before sampling again, and trials created before are not created twice
+	assert.NoError(t, r.createTrials(pe, []morphlingv1alpha1.Trial{existing, *trial}, 1))
+	assert.Equal(t, 1, sampling.calls)
+	assert.Empty(t, pe.Status.PendingSamplings)
+	assert.NoError(t, c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "exp-1-7"}, trial))
+	assert.Equal(t, "3", trial.Spec.SamplingResult[0].Value)
+	assert.NoError(t, r.createTrialInstance(pe, &morphlingv1alpha1.TrialAssignment{Name: "exp-1-7", ParameterAssignments: cpuAssignment("3")}, 0))
+
+	// A trial of another assignment taking the name is not mistaken for the sampling
+	err := r.createTrialInstance(pe, &morphlingv1alpha1.TrialAssignment{Name: "exp-1-7", ParameterAssignments: cpuAssignment("6")}, 0)
+	assert.Error(t, err)
+
+	// New samplings are named after the generation and the samplings received so far
+	pe.Generation = 2
+	sampling.assignments = []morphlingv1alpha1.TrialAssignment{{ParameterAssignments: cpuAssignment("5")}}
+	assert.NoError(t, r.createTrials(pe, []morphlingv1alpha1.Trial{existing}, 1))
+	assert.Equal(t, 2, sampling.calls)
+	assert.NoError(t, c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "exp-2-8"}, trial))
+	promoted := morphlingv1alpha1.Trial{ObjectMeta: metav1.ObjectMeta{Name: "exp-1-9-r1", Namespace: "default"}}
+	assert.Equal(t, int32(10), nextSamplingIndex(pe, []morphlingv1alpha1.Trial{promoted}))
+}
+
+func newCPUTrial(name, cpu string, mark func(*morphlingv1alpha1.Trial)) morphlingv1alpha1.Trial {
+	trial := morphlingv1alpha1.Trial{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       morphlingv1alpha1.TrialSpec{SamplingResult: cpuAssignment(cpu)},
+	}
+	if mark != nil {
+		mark(&trial)
+	}
+	return trial
+}
+
+func TestUpdateSearchSpace(t *testing.T) {
+	pe := &morphlingv1alpha1.ProfilingExperiment{
+		ObjectMeta: metav1.ObjectMeta{Name: "exp", Namespace: "default", Generation: 1},
+		Spec: morphlingv1alpha1.ProfilingExperimentSpec{
+			TunableParameters: []morphlingv1alpha1.ParameterCategory{{
+				Category: morphlingv1alpha1.CategoryResource,
+				Parameters: []morphlingv1alpha1.ParameterSpec{{
+					Name:          "cpu",
+					ParameterType: morphlingv1alpha1.ParameterTypeDiscrete,
+					FeasibleSpace: morphlingv1alpha1.FeasibleSpace{List: []string{"1", "2", "4"}},
+				}},
+			}},
+		},
+	}
+	succeeded := func(trial *morphlingv1alpha1.Trial) {
+		util.MarkTrialStatusSucceeded(trial, corev1.ConditionTrue, "succeeded")
+	}
+	failed := func(trial *morphlingv1alpha1.Trial) { util.MarkTrialStatusFailed(trial, "failed") }
+	killed := func(trial *morphlingv1alpha1.Trial) { util.MarkTrialStatusKilled(trial, "killed") }
+
+	// Assignments are counted once, and killed trials do not count
+	trials := []morphlingv1alpha1.Trial{
+		newCPUTrial("exp-1-0", "1", succeeded),
+		newCPUTrial("exp-1-0-r1", "1", succeeded),
+		newCPUTrial("exp-1-1", "2", failed),
+		newCPUTrial("exp-1-2", "4", killed),
+	}
+	assert.NoError(t, updateSearchSpace(pe, trials))
+	assert.Equal(t, &morphlingv1alpha1.SearchSpaceStatus{ObservedGeneration: 1, Size: 3, Tried: 2, Succeeded: 1, Failed: 1}, pe.Status.SearchSpace)
+	assert.False(t, searchSpaceExhausted(pe))
+
+	// The experiment is not completed until the trials of all assignments are
+	trials = append(trials, newCPUTrial("exp-1-3", "4", nil))
+	assert.NoError(t, updateSearchSpace(pe, trials))
+	assert.True(t, searchSpaceExhausted(pe))
+	pe.Status.TrialsPending = 1
+	updateExperimentStatusCondition(pe, trials)
+	assert.False(t, util.IsCompletedExperiment(pe))
+	succeeded(&trials[4])
+	pe.Status.TrialsPending = 0
+	assert.NoError(t, updateSearchSpace(pe, trials))
+	updateExperimentStatusCondition(pe, trials)
+	assert.True(t, util.IsSucceededExperiment(pe))
+	assert.Contains(t, pe.Status.Conditions[len(pe.Status.Conditions)-1].Message, "2 of 3 distinct assignments have succeeded and 1 have failed")
+
+	// The size is computed again for a new generation, and invalid search spaces are errors
+	pe.Generation = 2
+	pe.Spec.TunableParameters[0].Parameters[0].FeasibleSpace.List = nil
+	assert.Error(t, updateSearchSpace(pe, trials))
+}
+
+func TestCreateTrialsSearchSpaceExhausted(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, morphlingv1alpha1.AddToScheme(scheme))
+	pe := &morphlingv1alpha1.ProfilingExperiment{
+		ObjectMeta: metav1.ObjectMeta{Name: "exp", Namespace: "default", Generation: 1},
+		Status:     morphlingv1alpha1.ProfilingExperimentStatus{SamplingCount: 1, SearchSpace: &morphlingv1alpha1.SearchSpaceStatus{ObservedGeneration: 1}},
+	}
+	killed := newCPUTrial("exp-1-0", "2", func(trial *morphlingv1alpha1.Trial) { util.MarkTrialStatusKilled(trial, "killed") })
+	c := fake.NewFakeClientWithScheme(scheme)
+	sampling := &stubSampling{
+		assignments: []morphlingv1alpha1.TrialAssignment{{ParameterAssignments: cpuAssignment("2")}},
+		err:         sampling_client.ErrSearchSpaceExhausted,
+	}
+	r := &ProfilingExperimentReconciler{Client: c, Scheme: scheme, Sampling: sampling}
+
+	// The last samplings are created, the assignments of killed trials included, and no more are requested
+	assert.NoError(t, r.createTrials(pe, []morphlingv1alpha1.Trial{killed}, 2))
+	assert.True(t, pe.Status.SearchSpace.Exhausted)
+	trial := &morphlingv1alpha1.Trial{}
+	assert.NoError(t, c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "exp-1-1"}, trial))
+	assert.NoError(t, r.createTrials(pe, []morphlingv1alpha1.Trial{killed, *trial}, 1))
+	assert.Equal(t, 1, sampling.calls)
+}
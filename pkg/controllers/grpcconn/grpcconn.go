@@ -0,0 +1,259 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package grpcconn manages the long-lived gRPC connections of the controllers to the algorithm server and the
+// db-manager, shared by all reconciles, with keepalive, optional mTLS and a circuit breaker per endpoint.
+package grpcconn
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/alibaba/morphling/pkg/controllers/consts"
+	"github.com/alibaba/morphling/pkg/controllers/util"
+)
+
+var log = logf.Log.WithName("grpcconn")
+
+// ErrCircuitOpen is returned without calling an endpoint failed too many times in a row, until it is retried
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// Config is the configuration of the connections
+type Config struct {
+	// AlgorithmEndpoint is the endpoint of the algorithm server
+	AlgorithmEndpoint string
+	// DBManagerEndpoint is the endpoint of the db-manager
+	DBManagerEndpoint string
+	// TLSSecret is the name of the secret of the client certificate (tls.crt and tls.key) and the CA certificate
+	// of the servers (ca.crt), in the namespace of the controllers. Connections are insecure if it is empty.
+	TLSSecret string
+	// Timeout is the timeout of a call
+	Timeout time.Duration
+	// KeepaliveTime is the interval of the pings on an idle connection, and KeepaliveTimeout is the time waiting
+	// for the ack before the connection is closed
+	KeepaliveTime    time.Duration
+	KeepaliveTimeout time.Duration
+	// FailureThreshold is the number of failures in a row opening the circuit of an endpoint for OpenDuration
+	FailureThreshold int
+	OpenDuration     time.Duration
+	// RetryInterval is the interval to requeue a reconcile failed as its endpoint is unavailable
+	RetryInterval time.Duration
+}
+
+// DefaultConfig returns the default configuration, with the endpoints overridden by ALGORITHM_SERVER_ENDPOINT and
+// DB_MANAGER_ENDPOINT if set
+func DefaultConfig() Config {
+	return Config{
+		AlgorithmEndpoint: consts.GetEnvOrDefault("ALGORITHM_SERVER_ENDPOINT",
+			fmt.Sprintf("%s:%d", consts.DefaultSamplingService, consts.DefaultSamplingPort)),
+		DBManagerEndpoint: consts.GetEnvOrDefault("DB_MANAGER_ENDPOINT", util.GetDBStorageEndpoint()),
+		TLSSecret:         consts.GetEnvOrDefault("GRPC_TLS_SECRET", ""),
+		Timeout:           30 * time.Second,
+		KeepaliveTime:     time.Minute,
+		KeepaliveTimeout:  20 * time.Second,
+		FailureThreshold:  5,
+		OpenDuration:      30 * time.Second,
+		RetryInterval:     10 * time.Second,
+	}
+}
+
+// AddFlags binds the flags of the configuration, defaulted to its current values
+func (c *Config) AddFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.AlgorithmEndpoint, "algorithm-server-endpoint", c.AlgorithmEndpoint, "The endpoint of the algorithm server.")
+	fs.StringVar(&c.DBManagerEndpoint, "db-manager-endpoint", c.DBManagerEndpoint, "The endpoint of the db-manager.")
+	fs.StringVar(&c.TLSSecret, "grpc-tls-secret", c.TLSSecret, "The secret of the client and CA certificates for mTLS to the algorithm server and the db-manager, insecure if empty.")
+	fs.DurationVar(&c.Timeout, "grpc-timeout", c.Timeout, "The timeout of a call to the algorithm server or the db-manager.")
+	fs.DurationVar(&c.KeepaliveTime, "grpc-keepalive-time", c.KeepaliveTime, "The interval of the keepalive pings on idle connections.")
+	fs.DurationVar(&c.KeepaliveTimeout, "grpc-keepalive-timeout", c.KeepaliveTimeout, "The time waiting for the ack of a keepalive ping before the connection is closed.")
+	fs.IntVar(&c.FailureThreshold, "grpc-failure-threshold", c.FailureThreshold, "The number of failed calls in a row to an endpoint opening its circuit breaker.")
+	fs.DurationVar(&c.OpenDuration, "grpc-open-duration", c.OpenDuration, "The time an endpoint is not called once its circuit breaker is open.")
+	fs.DurationVar(&c.RetryInterval, "grpc-retry-interval", c.RetryInterval, "The interval to requeue a reconcile failed as its endpoint is unavailable.")
+}
+
+// UnavailableError is returned by calls failed as their endpoint is unavailable, which are retried after RetryAfter
+// instead of blocking the reconcile
+type UnavailableError struct {
+	Endpoint   string
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("%s is unavailable: %v", e.Endpoint, e.Err)
+}
+
+func (e *UnavailableError) Unwrap() error {
+	return e.Err
+}
+
+// RetryAfter returns the time to retry after if err is caused by an unavailable endpoint
+func RetryAfter(err error) (time.Duration, bool) {
+	var unavailable *UnavailableError
+	if errors.As(err, &unavailable) {
+		return unavailable.RetryAfter, true
+	}
+	return 0, false
+}
+
+// Manager holds a connection and a circuit breaker per endpoint
+type Manager struct {
+	config Config
+	creds  credentials.TransportCredentials
+	now    func() time.Time
+
+	mu       sync.Mutex
+	conns    map[string]*grpc.ClientConn
+	breakers map[string]*breaker
+}
+
+// Default is the manager of the clients of the controllers, replaced on startup with the configured one
+var Default = NewManager(DefaultConfig(), nil)
+
+// NewManager returns a manager of connections secured by creds, or insecure ones if creds is nil
+func NewManager(config Config, creds credentials.TransportCredentials) *Manager {
+	return &Manager{
+		config:   config,
+		creds:    creds,
+		now:      time.Now,
+		conns:    map[string]*grpc.ClientConn{},
+		breakers: map[string]*breaker{},
+	}
+}
+
+// Config returns the configuration of the manager
+func (m *Manager) Config() Config {
+	return m.config
+}
+
+// Invoke calls fn with the connection to the endpoint and a context of the call timeout. Calls failed with
+// Unavailable or DeadlineExceeded count towards opening the circuit of the endpoint, and are returned as
+// UnavailableError, as are calls skipped since the circuit is open.
+func (m *Manager) Invoke(endpoint string, fn func(ctx context.Context, conn *grpc.ClientConn) error) error {
+	conn, wait, err := m.acquire(endpoint)
+	if err != nil {
+		return err
+	}
+	if conn == nil {
+		return &UnavailableError{Endpoint: endpoint, RetryAfter: wait, Err: ErrCircuitOpen}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.config.Timeout)
+	defer cancel()
+	err = fn(ctx, conn)
+	if code := status.Code(err); code == codes.Unavailable || code == codes.DeadlineExceeded {
+		m.report(endpoint, false)
+		return &UnavailableError{Endpoint: endpoint, RetryAfter: m.config.RetryInterval, Err: err}
+	}
+	m.report(endpoint, true)
+	return err
+}
+
+// acquire returns the connection to the endpoint, dialed on the first call, or the time to wait if the circuit of
+// the endpoint is open
+func (m *Manager) acquire(endpoint string) (*grpc.ClientConn, time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if b := m.breakers[endpoint]; b != nil {
+		if wait := b.wait(m.now(), m.config.FailureThreshold); wait > 0 {
+			return nil, wait, nil
+		}
+	}
+	if conn, ok := m.conns[endpoint]; ok {
+		return conn, 0, nil
+	}
+	conn, err := grpc.Dial(endpoint, m.dialOptions()...)
+	if err != nil {
+		return nil, 0, err
+	}
+	log.Info("Connected", "endpoint", endpoint, "tls", m.creds != nil)
+	m.conns[endpoint] = conn
+	return conn, 0, nil
+}
+
+func (m *Manager) dialOptions() []grpc.DialOption {
+	opts := []grpc.DialOption{
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                m.config.KeepaliveTime,
+			Timeout:             m.config.KeepaliveTimeout,
+			PermitWithoutStream: true,
+		}),
+	}
+	if m.creds != nil {
+		return append(opts, grpc.WithTransportCredentials(m.creds))
+	}
+	return append(opts, grpc.WithInsecure())
+}
+
+func (m *Manager) report(endpoint string, succeeded bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b := m.breakers[endpoint]
+	if b == nil {
+		b = &breaker{}
+		m.breakers[endpoint] = b
+	}
+	if succeeded {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= m.config.FailureThreshold {
+		b.openUntil = m.now().Add(m.config.OpenDuration)
+		log.Info("Circuit breaker is open", "endpoint", endpoint, "failures", b.failures, "until", b.openUntil)
+	}
+}
+
+// Close closes all connections
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var firstErr error
+	for endpoint, conn := range m.conns {
+		if err := conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(m.conns, endpoint)
+	}
+	return firstErr
+}
+
+// breaker counts the failures in a row of an endpoint. Once the circuit is open, the endpoint is not called until
+// openUntil, after which calls are let through again and the first failure opens the circuit again.
+type breaker struct {
+	failures  int
+	openUntil time.Time
+}
+
+func (b *breaker) wait(now time.Time, threshold int) time.Duration {
+	if b.failures < threshold || !now.Before(b.openUntil) {
+		return 0
+	}
+	return b.openUntil.Sub(now)
+}
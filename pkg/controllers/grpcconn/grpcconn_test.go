@@ -0,0 +1,221 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpcconn
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestManager(now *time.Time) *Manager {
+	config := DefaultConfig()
+	config.FailureThreshold = 2
+	config.OpenDuration = time.Minute
+	config.RetryInterval = 5 * time.Second
+	m := NewManager(config, nil)
+	m.now = func() time.Time { return *now }
+	return m
+}
+
+func TestInvokeSharesConnection(t *testing.T) {
+	now := time.Now()
+	m := newTestManager(&now)
+	defer m.Close()
+
+	var conns []*grpc.ClientConn
+	for i := 0; i < 2; i++ {
+		err := m.Invoke("127.0.0.1:1", func(ctx context.Context, conn *grpc.ClientConn) error {
+			_, ok := ctx.Deadline()
+			assert.True(t, ok)
+			conns = append(conns, conn)
+			return nil
+		})
+		assert.NoError(t, err)
+	}
+	assert.Same(t, conns[0], conns[1])
+
+	// Errors of the server are returned as they are
+	err := m.Invoke("127.0.0.1:1", func(ctx context.Context, conn *grpc.ClientConn) error {
+		return status.Error(codes.InvalidArgument, "invalid")
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, ok := RetryAfter(err)
+	assert.False(t, ok)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	m := newTestManager(&now)
+	defer m.Close()
+
+	calls := 0
+	unavailable := func(ctx context.Context, conn *grpc.ClientConn) error {
+		calls++
+		return status.Error(codes.Unavailable, "connection refused")
+	}
+	for i := 0; i < 2; i++ {
+		err := m.Invoke("a:1", unavailable)
+		retryAfter, ok := RetryAfter(fmt.Errorf("wrapped: %w", err))
+		assert.True(t, ok)
+		assert.Equal(t, 5*time.Second, retryAfter)
+		assert.Equal(t, codes.Unavailable, status.Code(errors.Unwrap(err)))
+	}
+	assert.Equal(t, 2, calls)
+
+	// The circuit is open, and the endpoint is not called
+	now = now.Add(20 * time.Second)
+	err := m.Invoke("a:1", unavailable)
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	retryAfter, ok := RetryAfter(err)
+	assert.True(t, ok)
+	assert.Equal(t, 40*time.Second, retryAfter)
+	assert.Equal(t, 2, calls)
+
+	// Other endpoints are called
+	assert.NoError(t, m.Invoke("b:1", func(ctx context.Context, conn *grpc.ClientConn) error { return nil }))
+
+	// Once open for long enough, the endpoint is retried, and a failure opens the circuit again
+	now = now.Add(time.Minute)
+	_ = m.Invoke("a:1", unavailable)
+	assert.Equal(t, 3, calls)
+	assert.True(t, errors.Is(m.Invoke("a:1", unavailable), ErrCircuitOpen))
+
+	// A success closes it
+	now = now.Add(time.Minute)
+	assert.NoError(t, m.Invoke("a:1", func(ctx context.Context, conn *grpc.ClientConn) error { return nil }))
+	_ = m.Invoke("a:1", unavailable)
+	assert.Equal(t, 4, calls)
+	_, ok = RetryAfter(m.Invoke("a:1", unavailable))
+	assert.True(t, ok)
+	assert.Equal(t, 5, calls)
+}
+
+// writeCert writes the PEM certificate and key signed by the parent, or self-signed if parent is nil
+func writeCert(t *testing.T, dir, name string, template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name+".crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name+".key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return cert, key
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "grpcconn")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	notAfter := time.Now().Add(time.Hour)
+	ca, caKey := writeCert(t, dir, "ca", &x509.Certificate{
+		SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "morphling-ca"}, NotAfter: notAfter,
+		IsCA: true, BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign,
+	}, nil, nil)
+	writeCert(t, dir, "tls", &x509.Certificate{
+		SerialNumber: big.NewInt(2), Subject: pkix.Name{CommonName: "server"}, NotAfter: notAfter,
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")}, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca, caKey)
+	writeCert(t, dir, "client", &x509.Certificate{
+		SerialNumber: big.NewInt(3), Subject: pkix.Name{CommonName: "controller"}, NotAfter: notAfter,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+	read := func(name string) []byte {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		assert.NoError(t, err)
+		return data
+	}
+
+	serverCreds, err := LoadServerCredentials(dir)
+	if !assert.NoError(t, err) {
+		return
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := grpc.NewServer(grpc.Creds(serverCreds))
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go server.Serve(listener)
+	defer server.Stop()
+	endpoint := listener.Addr().String()
+
+	check := func(creds credentials.TransportCredentials) error {
+		m := NewManager(DefaultConfig(), creds)
+		defer m.Close()
+		return m.Invoke(endpoint, func(ctx context.Context, conn *grpc.ClientConn) error {
+			_, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+			return err
+		})
+	}
+
+	key := types.NamespacedName{Namespace: "morphling-system", Name: "morphling-grpc-tls"}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name},
+		Data:       map[string][]byte{CACertKey: read("ca.crt"), CertKey: read("client.crt"), KeyKey: read("client.key")},
+	}
+	clientCreds, err := LoadClientCredentials(fake.NewFakeClientWithScheme(scheme.Scheme, secret), key)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, check(clientCreds))
+
+	// Clients without a certificate of the CA are rejected
+	writeCert(t, dir, "other", &x509.Certificate{
+		SerialNumber: big.NewInt(4), Subject: pkix.Name{CommonName: "other"}, NotAfter: notAfter,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, nil, nil)
+	secret.Data[CertKey], secret.Data[KeyKey] = read("other.crt"), read("other.key")
+	selfSigned, err := LoadClientCredentials(fake.NewFakeClientWithScheme(scheme.Scheme, secret), key)
+	assert.NoError(t, err)
+	assert.Error(t, check(selfSigned))
+	assert.Error(t, check(nil))
+
+	_, err = LoadClientCredentials(fake.NewFakeClientWithScheme(scheme.Scheme), key)
+	assert.Error(t, err)
+}
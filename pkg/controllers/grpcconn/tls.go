@@ -0,0 +1,88 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpcconn
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/grpc/credentials"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Keys of the certificates in a secret, the same as of the secrets of cert-manager
+const (
+	CACertKey = "ca.crt"
+	CertKey   = corev1.TLSCertKey
+	KeyKey    = corev1.TLSPrivateKeyKey
+)
+
+// LoadClientCredentials returns the client credentials of the secret, with the client certificate presented to the
+// servers and the CA certificate verifying them, or the system roots if the secret has none
+func LoadClientCredentials(reader client.Reader, key types.NamespacedName) (credentials.TransportCredentials, error) {
+	secret := &corev1.Secret{}
+	if err := reader.Get(context.TODO(), key, secret); err != nil {
+		return nil, fmt.Errorf("failed to get TLS secret %s: %v", key, err)
+	}
+	cert, err := tls.X509KeyPair(secret.Data[CertKey], secret.Data[KeyKey])
+	if err != nil {
+		return nil, fmt.Errorf("invalid client certificate of secret %s: %v", key, err)
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{cert}}
+	if ca, ok := secret.Data[CACertKey]; ok {
+		if config.RootCAs, err = certPool(ca); err != nil {
+			return nil, fmt.Errorf("invalid CA certificate of secret %s: %v", key, err)
+		}
+	}
+	return credentials.NewTLS(config), nil
+}
+
+// LoadServerCredentials returns the server credentials of the certificates in the directory, e.g., a mounted
+// secret. Client certificates are required and verified if the directory has a CA certificate.
+func LoadServerCredentials(dir string) (credentials.TransportCredentials, error) {
+	cert, err := tls.LoadX509KeyPair(filepath.Join(dir, CertKey), filepath.Join(dir, KeyKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid server certificate in %s: %v", dir, err)
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{cert}}
+	ca, err := ioutil.ReadFile(filepath.Join(dir, CACertKey))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if config.ClientCAs, err = certPool(ca); err != nil {
+			return nil, fmt.Errorf("invalid CA certificate in %s: %v", dir, err)
+		}
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return credentials.NewTLS(config), nil
+}
+
+func certPool(pem []byte) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificate found")
+	}
+	return pool, nil
+}
@@ -2,6 +2,7 @@ package trial
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
 	"github.com/alibaba/morphling/pkg/controllers/consts"
@@ -101,13 +102,46 @@ func (r *ReconcileTrial) getDesiredJobSpec(instance *morphlingv1alpha1.Trial) (*
 func appendJobEnv(t *morphlingv1alpha1.Trial, env []corev1.EnvVar) []corev1.EnvVar {
 	env = append(env, corev1.EnvVar{Name: "RequestTemplate", Value: fmt.Sprintf(t.Spec.RequestTemplate)})
 	env = append(env, corev1.EnvVar{Name: "ServiceName", Value: util.GetServiceEndpoint(t)})
+	env = append(env, servicePortsEnv(t)...)
 	env = append(env, corev1.EnvVar{Name: "TrialName", Value: fmt.Sprintf(t.Name)})
 	env = append(env, corev1.EnvVar{Name: "Namespace", Value: fmt.Sprintf(t.Namespace)})
 	env = append(env, corev1.EnvVar{Name: "DBNamespace", Value: fmt.Sprintf(consts.DefaultControllerNamespace)})
 	env = append(env, corev1.EnvVar{Name: "DBPort", Value: fmt.Sprintf(consts.DefaultMorphlingDBManagerServicePort)})
 	for _, cat := range t.Spec.SamplingResult {
 		name := strings.ReplaceAll(strings.ToUpper(cat.Name), ".", "_")
-		env = append(env, corev1.EnvVar{Name: name, Value: fmt.Sprintf(cat.Value)})
+		env = append(env, corev1.EnvVar{Name: name, Value: cat.Value})
+	}
+	env = append(env, fidelityEnv(t)...)
+	return env
+}
+
+// fidelityEnv exposes the fidelity of the trial of a multi-fidelity experiment to the client, as FIDELITY, RUNG and the
+// fidelity parameter name
+func fidelityEnv(t *morphlingv1alpha1.Trial) []corev1.EnvVar {
+	f := t.Spec.Fidelity
+	if f == nil {
+		return nil
+	}
+	env := []corev1.EnvVar{
+		{Name: "FIDELITY", Value: fmt.Sprint(f.Value)},
+		{Name: "RUNG", Value: fmt.Sprint(f.Rung)},
+	}
+	if f.Name != "" {
+		env = append(env, corev1.EnvVar{Name: strings.ReplaceAll(strings.ToUpper(f.Name), ".", "_"), Value: fmt.Sprint(f.Value)})
+	}
+	return env
+}
+
+// servicePortsEnv exposes all the ports of the trial service to the client, as ServicePorts in JSON and SERVICE_PORT_<NAME>
+func servicePortsEnv(t *morphlingv1alpha1.Trial) []corev1.EnvVar {
+	ports := util.GetServicePorts(t)
+	env := []corev1.EnvVar{{Name: "ServiceHost", Value: util.GetServiceName(t)}}
+	if value, err := json.Marshal(ports); err == nil {
+		env = append(env, corev1.EnvVar{Name: "ServicePorts", Value: string(value)})
+	}
+	for _, p := range ports {
+		name := strings.ReplaceAll(strings.ToUpper(p.Name), "-", "_")
+		env = append(env, corev1.EnvVar{Name: "SERVICE_PORT_" + name, Value: fmt.Sprint(p.Port)})
 	}
 	return env
 }
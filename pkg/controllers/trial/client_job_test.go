@@ -0,0 +1,57 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trial
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+)
+
+func TestAppendJobEnvFidelity(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	instance := newFakeInstance()
+	jobEnv := func() map[string]string {
+		env := map[string]string{}
+		for _, e := range appendJobEnv(instance, nil) {
+			env[e.Name] = e.Value
+		}
+		return env
+	}
+	g.Expect(jobEnv()).NotTo(gomega.HaveKey("FIDELITY"))
+
+	instance.Spec.Fidelity = &morphlingv1alpha1.TrialFidelity{Name: "test.duration", Value: 90, Rung: 2}
+	env := jobEnv()
+	g.Expect(env["FIDELITY"]).To(gomega.Equal("90"))
+	g.Expect(env["RUNG"]).To(gomega.Equal("2"))
+	g.Expect(env["TEST_DURATION"]).To(gomega.Equal("90"))
+}
+
+func TestAppendJobEnvSamplingResult(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	instance := newFakeInstance()
+	instance.Spec.SamplingResult = []morphlingv1alpha1.ParameterAssignment{
+		{Name: "test.format", Value: "%d-%s"},
+	}
+	env := map[string]string{}
+	for _, e := range appendJobEnv(instance, nil) {
+		env[e.Name] = e.Value
+	}
+	g.Expect(env["TEST_FORMAT"]).To(gomega.Equal("%d-%s"))
+}
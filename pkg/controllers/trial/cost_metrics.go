@@ -0,0 +1,147 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trial
+
+import (
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	"github.com/alibaba/morphling/pkg/controllers/consts"
+)
+
+// gibibyte is the unit of byte-valued resources in cost models
+const gibibyte = float64(1 << 30)
+
+// appendCostMetrics adds the replica count and the costs of the service pods to the trial result, unless they are
+// reported by the client. The cost prices the resource usage of the pods observed while the client job runs, and the
+// allocated cost prices the resources requested by the pods, whether used or not.
+func appendCostMetrics(t *morphlingv1alpha1.Trial, result *morphlingv1alpha1.TrialResult) error {
+	replicas, err := serviceReplicas(t)
+	if err != nil {
+		return err
+	}
+	if replicas != nil {
+		addMetric(result, consts.MetricReplicas, float64(*replicas))
+	}
+	if t.Spec.CostModel == nil {
+		return nil
+	}
+
+	if usage := t.Status.ResourceUsage; usage != nil && usage.Samples > 0 {
+		cost, err := usageCost(t.Spec.CostModel, usage)
+		if err != nil {
+			return err
+		}
+		addCostMetrics(t.Spec.CostModel, result, consts.MetricCost, consts.MetricCostPerPrefix, cost)
+	}
+
+	count := float64(1)
+	if replicas != nil {
+		count = float64(*replicas)
+	}
+	podTemplate, err := desiredPodTemplate(t)
+	if err != nil {
+		return err
+	}
+	podCost, err := podCost(t.Spec.CostModel, podTemplate.Spec)
+	if err != nil {
+		return err
+	}
+	addCostMetrics(t.Spec.CostModel, result, consts.MetricAllocatedCost, consts.MetricAllocatedCostPerPrefix, podCost*count)
+	return nil
+}
+
+// addCostMetrics adds a cost metric and the cost per throughput, if the throughput metric is reported
+func addCostMetrics(model *morphlingv1alpha1.CostModel, result *morphlingv1alpha1.TrialResult, name, perPrefix string, cost float64) {
+	addMetric(result, name, cost)
+	throughputName := model.ThroughputMetricName
+	if throughputName == "" {
+		throughputName = consts.DefaultThroughputMetricName
+	}
+	for _, m := range result.ObjectiveMetricsObserved {
+		if m.Name != throughputName {
+			continue
+		}
+		throughput, err := strconv.ParseFloat(m.Value, 64)
+		if err == nil && throughput > 0 {
+			addMetric(result, perPrefix+throughputName, cost/throughput)
+		}
+		break
+	}
+}
+
+// usageCost prices the average resource usage of the service pods over the samples
+func usageCost(model *morphlingv1alpha1.CostModel, usage *morphlingv1alpha1.ResourceUsage) (float64, error) {
+	cost := float64(0)
+	for name, price := range model.ResourcePrices {
+		unitPrice, err := strconv.ParseFloat(price, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid price %s of resource %s", price, name)
+		}
+		if quantity, ok := usage.Total[name]; ok {
+			cost += unitPrice * resourceUnits(name, quantity) / float64(usage.Samples)
+		}
+	}
+	return cost, nil
+}
+
+// podCost prices the resources requested by the containers of a pod, falling back to the limits if not requested
+func podCost(model *morphlingv1alpha1.CostModel, podSpec corev1.PodSpec) (float64, error) {
+	cost := float64(0)
+	for name, price := range model.ResourcePrices {
+		unitPrice, err := strconv.ParseFloat(price, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid price %s of resource %s", price, name)
+		}
+		for _, c := range podSpec.Containers {
+			quantity, ok := c.Resources.Requests[name]
+			if !ok {
+				quantity, ok = c.Resources.Limits[name]
+			}
+			if ok {
+				cost += unitPrice * resourceUnits(name, quantity)
+			}
+		}
+	}
+	return cost, nil
+}
+
+// resourceUnits converts a quantity into the units priced by cost models
+func resourceUnits(name corev1.ResourceName, quantity resource.Quantity) float64 {
+	switch name {
+	case corev1.ResourceMemory, corev1.ResourceStorage, corev1.ResourceEphemeralStorage:
+		return float64(quantity.Value()) / gibibyte
+	default:
+		return float64(quantity.MilliValue()) / 1000
+	}
+}
+
+func addMetric(result *morphlingv1alpha1.TrialResult, name string, value float64) {
+	for _, m := range result.ObjectiveMetricsObserved {
+		if m.Name == name {
+			return
+		}
+	}
+	result.ObjectiveMetricsObserved = append(result.ObjectiveMetricsObserved, morphlingv1alpha1.Metric{
+		Name:  name,
+		Value: strconv.FormatFloat(value, 'f', -1, 64),
+	})
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trial
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	"github.com/alibaba/morphling/pkg/controllers/util"
+)
+
+func TestAppendCostMetrics(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	instance := newFakeInstance()
+	instance.Spec.SamplingResult = append(instance.Spec.SamplingResult,
+		morphlingv1alpha1.ParameterAssignment{Name: "memory", Value: "512Mi", Category: morphlingv1alpha1.CategoryResource},
+		morphlingv1alpha1.ParameterAssignment{Name: "replicas", Value: "4", Category: morphlingv1alpha1.CategoryReplicas},
+	)
+	instance.Spec.CostModel = &morphlingv1alpha1.CostModel{
+		ResourcePrices: map[corev1.ResourceName]string{corev1.ResourceCPU: "0.5", corev1.ResourceMemory: "0.2"},
+	}
+	result := &morphlingv1alpha1.TrialResult{
+		ObjectiveMetricsObserved: []morphlingv1alpha1.Metric{{Name: "qps", Value: "30"}},
+	}
+	g.Expect(appendCostMetrics(instance, result)).To(gomega.Succeed())
+	// 4 replicas of 1 core and 0.5 GiB each
+	g.Expect(result.ObjectiveMetricsObserved).To(gomega.Equal([]morphlingv1alpha1.Metric{
+		{Name: "qps", Value: "30"},
+		{Name: "replicas", Value: "4"},
+		{Name: "allocated_cost", Value: "2.4"},
+		{Name: "allocated_cost_per_qps", Value: "0.08"},
+	}))
+
+	// The replicas are set to the service deployment, which is ready only when all of them are available
+	provider, err := getServiceWorkloadProvider(instance)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	obj, err := provider.New(instance)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	deploy := obj.(*appsv1.Deployment)
+	g.Expect(*deploy.Spec.Replicas).To(gomega.Equal(int32(4)))
+	deploy.Status = appsv1.DeploymentStatus{
+		UpdatedReplicas:   4,
+		AvailableReplicas: 3,
+		Conditions:        []appsv1.DeploymentCondition{{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue}},
+	}
+	g.Expect(provider.Assess(instance, deploy).Ready).To(gomega.BeFalse())
+	deploy.Status.AvailableReplicas = 4
+	g.Expect(provider.Assess(instance, deploy).Ready).To(gomega.BeTrue())
+
+	instance.Spec.SamplingResult[2].Value = "0"
+	_, err = provider.New(instance)
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+// newPodMetrics returns the metrics of a pod as served by metrics-server
+func newPodMetrics(name string, labels map[string]string, timestamp string, usages ...map[string]interface{}) *unstructured.Unstructured {
+	containers := make([]interface{}, 0, len(usages))
+	for _, usage := range usages {
+		containers = append(containers, map[string]interface{}{"name": "c", "usage": usage})
+	}
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"timestamp":  timestamp,
+		"window":     "30s",
+		"containers": containers,
+	}}
+	obj.SetGroupVersionKind(podMetricsListGVK.GroupVersion().WithKind("PodMetrics"))
+	obj.SetName(name)
+	obj.SetNamespace(namespace)
+	obj.SetLabels(labels)
+	return obj
+}
+
+func TestSampleResourceUsage(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	instance := newFakeInstance()
+	instance.Spec.CostModel = &morphlingv1alpha1.CostModel{
+		ResourcePrices: map[corev1.ResourceName]string{corev1.ResourceCPU: "0.5", corev1.ResourceMemory: "0.25"},
+	}
+	labels := util.ServicePodLabels(instance)
+	s := runtime.NewScheme()
+	g.Expect(morphlingv1alpha1.AddToScheme(s)).To(gomega.Succeed())
+	// The fake client lists unstructured objects of registered kinds only
+	s.AddKnownTypeWithName(podMetricsListGVK.GroupVersion().WithKind("PodMetrics"), &unstructured.Unstructured{})
+	s.AddKnownTypeWithName(podMetricsListGVK, &unstructured.UnstructuredList{})
+	c := fake.NewFakeClientWithScheme(s,
+		newPodMetrics("pod-a", labels, "2021-06-01T00:00:15Z",
+			map[string]interface{}{"cpu": "500m", "memory": "256Mi"},
+			map[string]interface{}{"cpu": "100m", "memory": "256Mi"}),
+		newPodMetrics("pod-b", labels, "2021-06-01T00:00:10Z", map[string]interface{}{"cpu": "400m", "memory": "512Mi"}),
+		newPodMetrics("other", map[string]string{"app": "other"}, "2021-06-01T00:00:15Z", map[string]interface{}{"cpu": "8"}),
+	)
+	r := &ReconcileTrial{Client: c, Scheme: s, Log: log}
+
+	// The usage of all containers of the service pods is summed, and the same metrics are not sampled twice
+	g.Expect(r.sampleResourceUsage(instance)).To(gomega.Succeed())
+	g.Expect(r.sampleResourceUsage(instance)).To(gomega.Succeed())
+	usage := instance.Status.ResourceUsage
+	g.Expect(usage.Samples).To(gomega.Equal(int32(1)))
+	g.Expect(usage.Total.Cpu().MilliValue()).To(gomega.Equal(int64(1000)))
+	g.Expect(usage.Total.Memory().Value()).To(gomega.Equal(int64(1 << 30)))
+	g.Expect(usage.LastSampleTime.UTC().Format("15:04:05")).To(gomega.Equal("00:00:15"))
+
+	podB := newPodMetrics("pod-b", labels, "2021-06-01T00:00:30Z", map[string]interface{}{"cpu": "2400m", "memory": "1536Mi"})
+	g.Expect(c.Delete(context.TODO(), podB)).To(gomega.Succeed())
+	g.Expect(c.Create(context.TODO(), podB)).To(gomega.Succeed())
+	g.Expect(r.sampleResourceUsage(instance)).To(gomega.Succeed())
+	g.Expect(usage.Samples).To(gomega.Equal(int32(2)))
+	g.Expect(usage.Total.Cpu().MilliValue()).To(gomega.Equal(int64(4000)))
+
+	// The cost prices the average usage of 2 cores and 1.5 GiB, apart from the allocated cost of the 1 core requested
+	result := &morphlingv1alpha1.TrialResult{
+		ObjectiveMetricsObserved: []morphlingv1alpha1.Metric{{Name: "qps", Value: "20"}},
+	}
+	g.Expect(appendCostMetrics(instance, result)).To(gomega.Succeed())
+	g.Expect(result.ObjectiveMetricsObserved).To(gomega.Equal([]morphlingv1alpha1.Metric{
+		{Name: "qps", Value: "20"},
+		{Name: "cost", Value: "1.375"},
+		{Name: "cost_per_qps", Value: "0.06875"},
+		{Name: "allocated_cost", Value: "0.5"},
+		{Name: "allocated_cost_per_qps", Value: "0.025"},
+	}))
+}
@@ -3,9 +3,8 @@ package dbclient
 import (
 	"context"
 	api_pb "github.com/alibaba/morphling/api/v1alpha1/grpc_proto/grpc_storage/go"
-	"github.com/alibaba/morphling/pkg/controllers/util"
+	"github.com/alibaba/morphling/pkg/controllers/grpcconn"
 	"google.golang.org/grpc"
-	"time"
 
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
@@ -14,7 +13,6 @@ import (
 
 var (
 	log                = logf.Log.WithName("trial-db-client")
-	timeout            = 60 * time.Second
 	defaultMetricValue = string("0.0")
 )
 
@@ -23,35 +21,29 @@ type DBClient interface {
 }
 
 type TrialDBClient struct {
+	endpoint string
+	conns    *grpcconn.Manager
 }
 
 func NewTrialDBClient() DBClient {
-	return &TrialDBClient{}
+	return &TrialDBClient{endpoint: grpcconn.Default.Config().DBManagerEndpoint, conns: grpcconn.Default}
+}
+
+// NewTrialDBClientForEndpoint returns a client of the db-manager served at the endpoint, e.g., a port-forwarded one
+func NewTrialDBClientForEndpoint(endpoint string) DBClient {
+	return &TrialDBClient{endpoint: endpoint, conns: grpcconn.Default}
 }
 
 func (t TrialDBClient) GetTrialResult(trial *morphlingv1alpha1.Trial) (*morphlingv1alpha1.TrialResult, error) {
 	// Prepare db request
 	request := prepareDBRequest(trial)
 
-	// Dial DB storage
-	endpoint := util.GetDBStorageEndpoint()
-	conn, err := grpc.Dial(endpoint, grpc.WithInsecure())
-	if err != nil {
-		return nil, err
-	}
-	defer func(conn *grpc.ClientConn) {
-		err := conn.Close()
-		if err != nil {
-
-		}
-	}(conn)
-
-	clientGRPC := api_pb.NewDBClient(conn)
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-
-	// Send request, receive reply
-	response, err := clientGRPC.GetResult(ctx, request, grpc.WaitForReady(true))
+	// Send request over the shared connection to DB storage, receive reply
+	var response *api_pb.GetResultReply
+	err := t.conns.Invoke(t.endpoint, func(ctx context.Context, conn *grpc.ClientConn) (err error) {
+		response, err = api_pb.NewDBClient(conn).GetResult(ctx, request)
+		return err
+	})
 	if err != nil {
 		log.Error(err, "Failed to get trial result from db storage")
 		return nil, err
@@ -71,11 +63,7 @@ func validateDBResult(trial *morphlingv1alpha1.Trial, response *api_pb.GetResult
 
 	reply.TunableParameters = make([]morphlingv1alpha1.ParameterAssignment, 0)
 	for _, assignment := range trial.Spec.SamplingResult {
-		reply.TunableParameters = append(reply.TunableParameters, morphlingv1alpha1.ParameterAssignment{
-			Name:     assignment.Name,
-			Value:    assignment.Value,
-			Category: assignment.Category,
-		})
+		reply.TunableParameters = append(reply.TunableParameters, *assignment.DeepCopy())
 	}
 
 	reply.ObjectiveMetricsObserved = make([]morphlingv1alpha1.Metric, 0)
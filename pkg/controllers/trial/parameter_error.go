@@ -0,0 +1,39 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trial
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ParameterError is an invalid parameter assignment of a trial, which fails the trial instead of being retried
+type ParameterError struct {
+	Name   string
+	Value  string
+	Reason string
+}
+
+func (e *ParameterError) Error() string {
+	return fmt.Sprintf("invalid parameter %s=%s: %s", e.Name, e.Value, e.Reason)
+}
+
+// isParameterError tells whether the error is caused by an invalid parameter assignment
+func isParameterError(err error) bool {
+	var paramErr *ParameterError
+	return errors.As(err, &paramErr)
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trial
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	"github.com/alibaba/morphling/pkg/controllers/util"
+)
+
+// resourceUsageSampleInterval is how often the usage of service pods is sampled while the client job runs, about the
+// resolution of metrics-server
+const resourceUsageSampleInterval = 15 * time.Second
+
+// podMetricsListGVK is the kind of the pod metrics served by metrics-server, listed as unstructured objects to read them
+// from the API server rather than an informer, as the metrics API could not be watched
+var podMetricsListGVK = schema.GroupVersionKind{Group: "metrics.k8s.io", Version: "v1beta1", Kind: "PodMetricsList"}
+
+// sampleResourceUsage adds the usage of all containers of the service pods, as reported by the metrics API, to the
+// resource usage of the trial. Metrics no newer than the last sample are not sampled twice.
+func (r *ReconcileTrial) sampleResourceUsage(t *morphlingv1alpha1.Trial) error {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(podMetricsListGVK)
+	if err := r.List(context.TODO(), list, client.InNamespace(t.Namespace), client.MatchingLabels(util.ServicePodLabels(t))); err != nil {
+		return err
+	}
+	if len(list.Items) == 0 {
+		return nil
+	}
+
+	total := corev1.ResourceList{}
+	var sampleTime time.Time
+	for _, item := range list.Items {
+		timestamp, _, _ := unstructured.NestedString(item.Object, "timestamp")
+		if ts, err := time.Parse(time.RFC3339, timestamp); err == nil && ts.After(sampleTime) {
+			sampleTime = ts
+		}
+		containers, _, err := unstructured.NestedSlice(item.Object, "containers")
+		if err != nil {
+			return fmt.Errorf("invalid metrics of pod %s: %v", item.GetName(), err)
+		}
+		for _, c := range containers {
+			usage, _, err := unstructured.NestedStringMap(c.(map[string]interface{}), "usage")
+			if err != nil {
+				return fmt.Errorf("invalid metrics of pod %s: %v", item.GetName(), err)
+			}
+			for name, value := range usage {
+				quantity, err := resource.ParseQuantity(value)
+				if err != nil {
+					return fmt.Errorf("invalid usage %s of resource %s of pod %s", value, name, item.GetName())
+				}
+				sum := total[corev1.ResourceName(name)]
+				sum.Add(quantity)
+				total[corev1.ResourceName(name)] = sum
+			}
+		}
+	}
+
+	usage := t.Status.ResourceUsage
+	if usage == nil {
+		usage = &morphlingv1alpha1.ResourceUsage{}
+	} else if usage.LastSampleTime != nil && !sampleTime.After(usage.LastSampleTime.Time) {
+		return nil
+	}
+	if usage.Total == nil {
+		usage.Total = corev1.ResourceList{}
+	}
+	for name, quantity := range total {
+		sum := usage.Total[name]
+		sum.Add(quantity)
+		usage.Total[name] = sum
+	}
+	usage.Samples++
+	lastSampleTime := metav1.NewTime(sampleTime)
+	usage.LastSampleTime = &lastSampleTime
+	t.Status.ResourceUsage = usage
+	return nil
+}
@@ -4,16 +4,19 @@ import (
 	"context"
 	"fmt"
 	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
-	"github.com/alibaba/morphling/pkg/controllers/consts"
 	"github.com/alibaba/morphling/pkg/controllers/util"
-	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"strconv"
 	"strings"
 )
 
@@ -26,15 +29,27 @@ func (r *ReconcileTrial) getDesiredService(t *morphlingv1alpha1.Trial) (*corev1.
 		},
 		Spec: corev1.ServiceSpec{
 			Selector: util.ServicePodLabels(t),
-			Ports: []corev1.ServicePort{
-				{
-					Name: consts.DefaultServicePortName,
-					Port: consts.DefaultServicePort,
-				},
-			},
-			Type: corev1.ServiceTypeClusterIP,
+			Type:     corev1.ServiceTypeClusterIP,
 		},
 	}
+	for _, p := range util.GetServicePorts(t) {
+		port := corev1.ServicePort{
+			Name:       p.Name,
+			Port:       p.Port,
+			Protocol:   p.Protocol,
+			TargetPort: p.TargetPort,
+		}
+		if port.Protocol == "" {
+			port.Protocol = corev1.ProtocolTCP
+		}
+		if port.TargetPort.Type == intstr.Int && port.TargetPort.IntVal == 0 {
+			port.TargetPort = intstr.FromInt(int(p.Port))
+		}
+		service.Spec.Ports = append(service.Spec.Ports, port)
+	}
+	if t.Spec.Service != nil && t.Spec.Service.Headless {
+		service.Spec.ClusterIP = corev1.ClusterIPNone
+	}
 	// ToDo: SetControllerReference here is useless, as the controller delete svc upon trial completion
 	// Add owner reference to the service so that it could be GC
 	if err := controllerutil.SetControllerReference(t, service, r.Scheme); err != nil {
@@ -73,127 +88,400 @@ func (r *ReconcileTrial) reconcileService(instance *morphlingv1alpha1.Trial, ser
 	return nil
 }
 
-// getDesiredPodSpec returns a new deployment containing the ML service under test
-func (r *ReconcileTrial) getDesiredDeploymentSpec(instance *morphlingv1alpha1.Trial) (*appsv1.Deployment, error) {
-	// Prepare podTemplate and embed tunable parameters
-	podTemplate := &corev1.PodTemplateSpec{}
-	if &instance.Spec.ServicePodTemplate != nil {
-		instance.Spec.ServicePodTemplate.Template.Spec.DeepCopyInto(&podTemplate.Spec)
-	}
-	podTemplate.Labels = util.ServicePodLabels(instance)
-	for i := range podTemplate.Spec.Containers {
-		c := &podTemplate.Spec.Containers[i]
-		c.Env, c.Args, c.Resources = appendServiceEnv(instance, c.Env, c.Args, c.Resources)
-	}
-	// Prepare k8s deployment
-	deploy := &appsv1.Deployment{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:        util.GetServiceDeploymentName(instance),
-			Namespace:   instance.GetNamespace(),
-			Labels:      util.ServiceDeploymentLabels(instance),
-			Annotations: instance.Annotations,
-		},
-		Spec: appsv1.DeploymentSpec{
-			Selector: &metav1.LabelSelector{MatchLabels: util.ServicePodLabels(instance)},
-			Template: *podTemplate,
-		},
+// getDesiredServiceWorkload returns a new workload containing the ML service under test
+func (r *ReconcileTrial) getDesiredServiceWorkload(instance *morphlingv1alpha1.Trial, provider ServiceWorkloadProvider) (runtime.Object, error) {
+	// Prepare the workload with tunable parameters embedded
+	workload, err := provider.New(instance)
+	if err != nil {
+		return nil, err
 	}
-	if instance.Spec.ServiceProgressDeadline != nil {
-		deploy.Spec.ProgressDeadlineSeconds = instance.Spec.ServiceProgressDeadline
+	accessor, err := meta.Accessor(workload)
+	if err != nil {
+		return nil, err
 	}
 	// ToDo: SetControllerReference here is useless, as the controller delete svc upon trial completion
 	// Add owner reference to the service so that it could be GC
-	if err := controllerutil.SetControllerReference(instance, deploy, r.Scheme); err != nil {
+	if err := controllerutil.SetControllerReference(instance, accessor, r.Scheme); err != nil {
 		return nil, err
 	}
-	return deploy, nil
+	return workload, nil
 }
 
-// reconcileServiceDeployment reconciles the ML deployment containing the ML service under test
-func (r *ReconcileTrial) reconcileServiceDeployment(instance *morphlingv1alpha1.Trial, deploy *appsv1.Deployment) (*appsv1.Deployment, error) {
+// reconcileServiceWorkload reconciles the ML workload containing the ML service under test
+func (r *ReconcileTrial) reconcileServiceWorkload(instance *morphlingv1alpha1.Trial, provider ServiceWorkloadProvider, desired runtime.Object) (runtime.Object, error) {
 	logger := log.WithValues("Trial", types.NamespacedName{Name: instance.GetName(), Namespace: instance.GetNamespace()})
 
-	err := r.Get(context.TODO(), types.NamespacedName{Name: deploy.GetName(), Namespace: deploy.GetNamespace()}, deploy)
+	accessor, err := meta.Accessor(desired)
+	if err != nil {
+		return nil, err
+	}
+	name := accessor.GetName()
+	deployed, err := provider.Empty(instance)
+	if err != nil {
+		return nil, err
+	}
+	err = r.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: accessor.GetNamespace()}, deployed)
 	if err != nil && !util.IsCompletedTrial(instance) {
-		// If not created, create the service deployment
+		// If not created, create the service workload
 		if errors.IsNotFound(err) {
-			if util.IsCompletedTrial(instance) {
-				return nil, nil
-			}
-
-			logger.Info("Creating ML service deployment", "name", deploy.GetName())
-			err = r.Create(context.TODO(), deploy)
+			logger.Info("Creating ML service workload", "name", name)
+			err = r.Create(context.TODO(), desired)
 			if err != nil {
-				logger.Error(err, "Create service deployment error", "name", deploy.GetName())
+				logger.Error(err, "Create service workload error", "name", name)
 				return nil, err
 			}
-		} else {
-			logger.Error(err, "Get service deployment error", "name", deploy.GetName())
+			return desired, nil
+		}
+		logger.Error(err, "Get service workload error", "name", name)
+		return nil, err
+	}
+	if util.IsCompletedTrial(instance) {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			logger.Error(err, "Get service workload error", "name", name)
 			return nil, err
 		}
-	} else {
-		if util.IsCompletedTrial(instance) {
-			if deploy.ObjectMeta.DeletionTimestamp != nil || errors.IsNotFound(err) {
-				logger.Info("Deleting ML deployment", "name", deploy.GetName())
-				return nil, nil
-			}
-			// // Delete ML deployments upon trial completions
-			if err = r.Delete(context.TODO(), deploy, client.PropagationPolicy(metav1.DeletePropagationForeground)); err != nil {
-				if errors.IsNotFound(err) {
-					logger.Info("Delete ML deployment operation is redundant", "name", deploy.GetName())
-					return nil, nil
-				}
-				logger.Error(err, "Delete ML deployment error", "name", deploy.GetName())
-				return nil, err
-			} else {
-				logger.Info("Delete ML deployment succeeded", "name", deploy.GetName())
+		deployedAccessor, err := meta.Accessor(deployed)
+		if err != nil {
+			return nil, err
+		}
+		if deployedAccessor.GetDeletionTimestamp() != nil {
+			logger.Info("Deleting ML workload", "name", name)
+			return nil, nil
+		}
+		// Delete ML workloads upon trial completions
+		if err = provider.Cleanup(r.Client, deployed); err != nil {
+			if errors.IsNotFound(err) {
+				logger.Info("Delete ML workload operation is redundant", "name", name)
 				return nil, nil
 			}
+			logger.Error(err, "Delete ML workload error", "name", name)
+			return nil, err
 		}
+		logger.Info("Delete ML workload succeeded", "name", name)
+		return nil, nil
 	}
-	return deploy, nil
+	return deployed, nil
 }
 
-// AppendAssignmentEnv appends an environment variable for service pods
-func appendServiceEnv(t *morphlingv1alpha1.Trial, env []corev1.EnvVar, args []string, resources corev1.ResourceRequirements) ([]corev1.EnvVar, []string, corev1.ResourceRequirements) {
+// Prefixes of resource parameter names, targeting requests or limits only, e.g., requests.cpu,
+// or setting limits by the ratio to requests, e.g., limitRatio.cpu=2
+const (
+	resourceRequestsPrefix   = "requests."
+	resourceLimitsPrefix     = "limits."
+	resourceLimitRatioPrefix = "limitRatio."
+)
+
+// appendServiceEnv embeds the trial parameters received by a service container into its env, args and resources
+func appendServiceEnv(t *morphlingv1alpha1.Trial, c *corev1.Container, initContainer bool) error {
+	var ratios []morphlingv1alpha1.ParameterAssignment
 	for _, a := range t.Spec.SamplingResult {
+		if !receivesParameter(a, c.Name, initContainer) {
+			continue
+		}
 		switch a.Category {
 		case morphlingv1alpha1.CategoryEnv:
 			{
 				name := strings.ReplaceAll(strings.ToUpper(a.Name), ".", "_")
-				env = append(env, corev1.EnvVar{Name: name, Value: fmt.Sprintf(a.Value)})
+				c.Env = append(c.Env, corev1.EnvVar{Name: name, Value: a.Value})
 			}
 		case morphlingv1alpha1.CategoryArgs:
 			{
-				args = append(args, fmt.Sprintf(a.Value))
+				if err := setArgs(c, a); err != nil {
+					return err
+				}
 			}
 		case morphlingv1alpha1.CategoryResource:
 			{
-				var resourceClass = corev1.ResourceCPU
-				switch a.Name {
-				case "cpu":
-					resourceClass = corev1.ResourceCPU
-				case "memory":
-					resourceClass = corev1.ResourceMemory
-				case "storage":
-					resourceClass = corev1.ResourceStorage
-				case "nvidia.com/gpu":
-					resourceClass = "nvidia.com/gpu"
-				case "nvidia.com/gpumem":
-					resourceClass = "nvidia.com/gpumem"
-				default:
-					resourceClass = corev1.ResourceEphemeralStorage
+				// Limit ratios are applied once all the requests are set
+				if strings.HasPrefix(a.Name, resourceLimitRatioPrefix) {
+					ratios = append(ratios, a)
+					continue
 				}
-				if resources.Limits == nil {
-					resources.Limits = make(map[corev1.ResourceName]resource.Quantity)
+				if err := setResource(&c.Resources, a); err != nil {
+					return err
 				}
-				if resources.Requests == nil {
-					resources.Requests = make(map[corev1.ResourceName]resource.Quantity)
-				}
-				resources.Limits[resourceClass] = resource.MustParse(a.Value)
-				resources.Requests[resourceClass] = resource.MustParse(a.Value)
 			}
 		}
 	}
-	return env, args, resources
+	for _, a := range ratios {
+		if err := setResourceLimitRatio(&c.Resources, a); err != nil {
+			return err
+		}
+	}
+	for name, request := range c.Resources.Requests {
+		if limit, ok := c.Resources.Limits[name]; ok && request.Cmp(limit) > 0 {
+			return &ParameterError{Name: string(name), Value: request.String(),
+				Reason: fmt.Sprintf("request exceeds limit %s", limit.String())}
+		}
+	}
+	return nil
+}
+
+// argValuePlaceholder is replaced with the value of the parameter in arg templates
+const argValuePlaceholder = "{{value}}"
+
+// setArgs renders the args parameter into the container. Without an arg template, the value is appended to the args.
+// With an arg template, e.g., --batch-size={{value}}, its flag is replaced if present in the args or
+// command, no matter it is formatted as --batch-size=4 or --batch-size 4, or appended to the args otherwise.
+func setArgs(c *corev1.Container, a morphlingv1alpha1.ParameterAssignment) error {
+	if a.ArgTemplate == "" {
+		c.Args = append(c.Args, a.Value)
+		return nil
+	}
+	if !strings.Contains(a.ArgTemplate, argValuePlaceholder) {
+		return &ParameterError{Name: a.Name, Value: a.Value, Reason: fmt.Sprintf("arg template %q has no %s", a.ArgTemplate, argValuePlaceholder)}
+	}
+	rendered := strings.Fields(strings.ReplaceAll(a.ArgTemplate, argValuePlaceholder, a.Value))
+	if len(rendered) == 0 {
+		return &ParameterError{Name: a.Name, Value: a.Value, Reason: "arg template renders no args"}
+	}
+	flag := rendered[0]
+	if !strings.HasPrefix(flag, "-") {
+		// Positional args are not replaceable
+		c.Args = append(c.Args, rendered...)
+		return nil
+	}
+	flag = strings.SplitN(flag, "=", 2)[0]
+	if replaced, ok := replaceFlag(c.Args, flag, rendered); ok {
+		c.Args = replaced
+	} else if replaced, ok := replaceFlag(c.Command, flag, rendered); ok {
+		c.Command = replaced
+	} else {
+		c.Args = append(c.Args, rendered...)
+	}
+	return nil
+}
+
+// replaceFlag replaces the flag and its value in the args with the rendered args, false if the flag is absent
+func replaceFlag(args []string, flag string, rendered []string) ([]string, bool) {
+	for i, arg := range args {
+		span := 0
+		if strings.HasPrefix(arg, flag+"=") {
+			span = 1
+		} else if arg == flag {
+			// The value follows as the next arg, unless it is another flag
+			span = 1
+			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+				span = 2
+			}
+		}
+		if span == 0 {
+			continue
+		}
+		replaced := make([]string, 0, len(args)-span+len(rendered))
+		replaced = append(replaced, args[:i]...)
+		replaced = append(replaced, rendered...)
+		replaced = append(replaced, args[i+span:]...)
+		return replaced, true
+	}
+	return args, false
+}
+
+// receivesParameter tells whether the container receives the value of the parameter
+func receivesParameter(a morphlingv1alpha1.ParameterAssignment, containerName string, initContainer bool) bool {
+	if initContainer {
+		return containsString(a.InitContainers, containerName)
+	}
+	if len(a.Containers) == 0 && len(a.InitContainers) == 0 {
+		return true
+	}
+	return containsString(a.Containers, containerName)
+}
+
+// setResource sets the requests and/or limits of a resource, by the resource parameter
+func setResource(resources *corev1.ResourceRequirements, a morphlingv1alpha1.ParameterAssignment) error {
+	setRequests, setLimits := true, true
+	name := a.Name
+	if strings.HasPrefix(name, resourceRequestsPrefix) {
+		name, setLimits = strings.TrimPrefix(name, resourceRequestsPrefix), false
+	} else if strings.HasPrefix(name, resourceLimitsPrefix) {
+		name, setRequests = strings.TrimPrefix(name, resourceLimitsPrefix), false
+	}
+	resourceName := corev1.ResourceName(name)
+	if err := validateResourceName(resourceName); err != nil {
+		return &ParameterError{Name: a.Name, Value: a.Value, Reason: err.Error()}
+	}
+	quantity, err := resource.ParseQuantity(a.Value)
+	if err != nil {
+		return &ParameterError{Name: a.Name, Value: a.Value, Reason: err.Error()}
+	}
+	if quantity.Sign() < 0 {
+		return &ParameterError{Name: a.Name, Value: a.Value, Reason: "quantity must not be negative"}
+	}
+	// Extended resources and hugepages cannot be overcommitted, requests and limits of them must be equal
+	if !isOvercommitAllowed(resourceName) {
+		setRequests, setLimits = true, true
+	}
+	if setRequests {
+		if resources.Requests == nil {
+			resources.Requests = make(corev1.ResourceList)
+		}
+		resources.Requests[resourceName] = quantity
+	}
+	if setLimits {
+		if resources.Limits == nil {
+			resources.Limits = make(corev1.ResourceList)
+		}
+		resources.Limits[resourceName] = quantity
+	}
+	return nil
+}
+
+// setResourceLimitRatio sets the limit of a resource to its request multiplied by the ratio
+func setResourceLimitRatio(resources *corev1.ResourceRequirements, a morphlingv1alpha1.ParameterAssignment) error {
+	resourceName := corev1.ResourceName(strings.TrimPrefix(a.Name, resourceLimitRatioPrefix))
+	if err := validateResourceName(resourceName); err != nil {
+		return &ParameterError{Name: a.Name, Value: a.Value, Reason: err.Error()}
+	}
+	if !isOvercommitAllowed(resourceName) {
+		return &ParameterError{Name: a.Name, Value: a.Value, Reason: "limits of the resource must equal requests"}
+	}
+	ratio, err := strconv.ParseFloat(a.Value, 64)
+	if err != nil || ratio < 1 {
+		return &ParameterError{Name: a.Name, Value: a.Value, Reason: "ratio must be a number no less than 1"}
+	}
+	request, ok := resources.Requests[resourceName]
+	if !ok {
+		return &ParameterError{Name: a.Name, Value: a.Value, Reason: fmt.Sprintf("no request of %s to scale", resourceName)}
+	}
+	var limit *resource.Quantity
+	if resourceName == corev1.ResourceCPU {
+		limit = resource.NewMilliQuantity(int64(float64(request.MilliValue())*ratio), request.Format)
+	} else {
+		limit = resource.NewQuantity(int64(float64(request.Value())*ratio), request.Format)
+	}
+	if resources.Limits == nil {
+		resources.Limits = make(corev1.ResourceList)
+	}
+	resources.Limits[resourceName] = *limit
+	return nil
+}
+
+// validateResourceName accepts standard resources, hugepages and domain-qualified extended resources, e.g., nvidia.com/gpu
+func validateResourceName(name corev1.ResourceName) error {
+	switch name {
+	case corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourceEphemeralStorage, corev1.ResourceStorage:
+		return nil
+	}
+	if strings.HasPrefix(string(name), corev1.ResourceHugePagesPrefix) {
+		if _, err := resource.ParseQuantity(strings.TrimPrefix(string(name), corev1.ResourceHugePagesPrefix)); err != nil {
+			return fmt.Errorf("invalid hugepages size of resource %s", name)
+		}
+		return nil
+	}
+	if !strings.Contains(string(name), "/") || len(validation.IsQualifiedName(string(name))) > 0 {
+		return fmt.Errorf("unknown resource %s, extended resources should be domain-qualified, e.g., nvidia.com/gpu", name)
+	}
+	return nil
+}
+
+// isOvercommitAllowed tells whether requests and limits of the resource could differ
+func isOvercommitAllowed(name corev1.ResourceName) bool {
+	switch name {
+	case corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourceEphemeralStorage, corev1.ResourceStorage:
+		return true
+	}
+	return false
+}
+
+// ApplyParameterAssignments embeds the parameter assignments into the containers of a pod spec the same way as the
+// service deployment of trials, e.g., to roll out the optimal assignment. Env vars already set are overridden, and
+// args already present are replaced or not appended again, so that it could be applied repeatedly. If the container name is
+// given, all the assignments are applied to it, regardless of the containers recorded in them. Otherwise, they are
+// applied to the containers recorded in them, and an assignment received by no container of the pod spec is an error.
+func ApplyParameterAssignments(assignments []morphlingv1alpha1.ParameterAssignment, podSpec *corev1.PodSpec, containerName string) error {
+	t := &morphlingv1alpha1.Trial{Spec: morphlingv1alpha1.TrialSpec{SamplingResult: assignments}}
+	if containerName != "" {
+		t.Spec.SamplingResult = make([]morphlingv1alpha1.ParameterAssignment, 0, len(assignments))
+		for _, a := range assignments {
+			t.Spec.SamplingResult = append(t.Spec.SamplingResult, morphlingv1alpha1.ParameterAssignment{
+				Name:        a.Name,
+				Value:       a.Value,
+				Category:    a.Category,
+				ArgTemplate: a.ArgTemplate,
+			})
+		}
+	}
+	applied := false
+	received := make([]bool, len(t.Spec.SamplingResult))
+	apply := func(c *corev1.Container, initContainer bool) error {
+		// Args without templates already present are skipped rather than appended again
+		containerTrial := &morphlingv1alpha1.Trial{}
+		for i, a := range t.Spec.SamplingResult {
+			if !receivesParameter(a, c.Name, initContainer) {
+				continue
+			}
+			received[i] = true
+			if a.Category == morphlingv1alpha1.CategoryArgs && a.ArgTemplate == "" && containsString(c.Args, a.Value) {
+				continue
+			}
+			containerTrial.Spec.SamplingResult = append(containerTrial.Spec.SamplingResult, a)
+		}
+		// Env vars are merged rather than appended, to override the values already set
+		env := c.Env
+		c.Env = nil
+		if err := appendServiceEnv(containerTrial, c, initContainer); err != nil {
+			return err
+		}
+		c.Env = mergeEnv(env, c.Env)
+		return nil
+	}
+	for i := range podSpec.Containers {
+		c := &podSpec.Containers[i]
+		if containerName != "" && c.Name != containerName {
+			continue
+		}
+		if err := apply(c, false); err != nil {
+			return err
+		}
+		applied = true
+	}
+	if containerName != "" {
+		if !applied {
+			return fmt.Errorf("container %q not found", containerName)
+		}
+		return nil
+	}
+	for i := range podSpec.InitContainers {
+		if err := apply(&podSpec.InitContainers[i], true); err != nil {
+			return err
+		}
+	}
+	// Containers may be named differently than in the profiled pod template, in which case the container to apply to
+	// must be given
+	for i, a := range t.Spec.SamplingResult {
+		if !received[i] && a.Category != morphlingv1alpha1.CategoryReplicas {
+			return fmt.Errorf("parameter %s is received by none of the containers %v and init containers %v", a.Name, a.Containers, a.InitContainers)
+		}
+	}
+	return nil
+}
+
+// mergeEnv overrides the values of env vars already set, and appends the others
+func mergeEnv(env, overrides []corev1.EnvVar) []corev1.EnvVar {
+	for _, o := range overrides {
+		found := false
+		for i := range env {
+			if env[i].Name == o.Name {
+				env[i] = o
+				found = true
+			}
+		}
+		if !found {
+			env = append(env, o)
+		}
+	}
+	return env
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,187 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trial
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	"github.com/alibaba/morphling/pkg/controllers/util"
+)
+
+func TestGetDesiredServicePorts(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	s := runtime.NewScheme()
+	g.Expect(morphlingv1alpha1.AddToScheme(s)).To(gomega.Succeed())
+	r := &ReconcileTrial{Scheme: s}
+
+	instance := newFakeInstance()
+	service, err := r.getDesiredService(instance)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(service.Spec.Ports).To(gomega.Equal([]corev1.ServicePort{
+		{Name: "profile-service", Port: 8500, Protocol: corev1.ProtocolTCP, TargetPort: intstr.FromInt(8500)},
+	}))
+	g.Expect(service.Spec.ClusterIP).To(gomega.BeEmpty())
+
+	instance.Spec.Service = &morphlingv1alpha1.ServiceSpec{
+		Ports: []morphlingv1alpha1.ServicePort{
+			{Name: "http", Port: 80, AppProtocol: "http", TargetPort: intstr.FromString("http")},
+			{Name: "grpc-metrics", Port: 9090, Protocol: corev1.ProtocolTCP},
+		},
+		Headless: true,
+	}
+	service, err = r.getDesiredService(instance)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(service.Spec.Ports).To(gomega.Equal([]corev1.ServicePort{
+		{Name: "http", Port: 80, Protocol: corev1.ProtocolTCP, TargetPort: intstr.FromString("http")},
+		{Name: "grpc-metrics", Port: 9090, Protocol: corev1.ProtocolTCP, TargetPort: intstr.FromInt(9090)},
+	}))
+	g.Expect(service.Spec.ClusterIP).To(gomega.Equal(corev1.ClusterIPNone))
+
+	env := map[string]string{}
+	for _, e := range appendJobEnv(instance, nil) {
+		env[e.Name] = e.Value
+	}
+	g.Expect(env["ServiceName"]).To(gomega.Equal("test-trial-service:80"))
+	g.Expect(env["ServiceHost"]).To(gomega.Equal("test-trial-service"))
+	g.Expect(env["SERVICE_PORT_HTTP"]).To(gomega.Equal("80"))
+	g.Expect(env["SERVICE_PORT_GRPC_METRICS"]).To(gomega.Equal("9090"))
+	g.Expect(env["ServicePorts"]).To(gomega.ContainSubstring(`"appProtocol":"http"`))
+}
+
+func TestAppendServiceEnvResources(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	instance := newFakeInstance()
+	instance.Spec.SamplingResult = []morphlingv1alpha1.ParameterAssignment{
+		{Name: "limitRatio.cpu", Value: "1.5", Category: morphlingv1alpha1.CategoryResource},
+		{Name: "requests.cpu", Value: "500m", Category: morphlingv1alpha1.CategoryResource},
+		{Name: "limits.memory", Value: "2Gi", Category: morphlingv1alpha1.CategoryResource},
+		{Name: "requests.nvidia.com/gpu", Value: "1", Category: morphlingv1alpha1.CategoryResource},
+		{Name: "hugepages-2Mi", Value: "64Mi", Category: morphlingv1alpha1.CategoryResource},
+	}
+	c := &corev1.Container{Resources: corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+	}}
+	g.Expect(appendServiceEnv(instance, c, false)).To(gomega.Succeed())
+	resources := c.Resources
+	g.Expect(resources.Requests.Cpu().String()).To(gomega.Equal("500m"))
+	g.Expect(resources.Limits.Cpu().String()).To(gomega.Equal("750m"))
+	g.Expect(resources.Requests.Memory().String()).To(gomega.Equal("1Gi"))
+	g.Expect(resources.Limits.Memory().String()).To(gomega.Equal("2Gi"))
+	gpu := corev1.ResourceName("nvidia.com/gpu")
+	g.Expect(resources.Requests[gpu]).To(gomega.Equal(resources.Limits[gpu]))
+	hugepages := corev1.ResourceName("hugepages-2Mi")
+	g.Expect(resources.Requests[hugepages]).To(gomega.Equal(resources.Limits[hugepages]))
+
+	for _, a := range []morphlingv1alpha1.ParameterAssignment{
+		{Name: "cpu", Value: "two"},
+		{Name: "gpu", Value: "1"},
+		{Name: "limitRatio.nvidia.com/gpu", Value: "2"},
+		{Name: "limitRatio.memory", Value: "0.5"},
+		{Name: "limits.cpu", Value: "100m"},
+	} {
+		a.Category = morphlingv1alpha1.CategoryResource
+		instance.Spec.SamplingResult = []morphlingv1alpha1.ParameterAssignment{{Name: "requests.cpu", Value: "1", Category: morphlingv1alpha1.CategoryResource}, a}
+		err := appendServiceEnv(instance, &corev1.Container{}, false)
+		g.Expect(isParameterError(err)).To(gomega.BeTrue(), a.Name)
+	}
+}
+
+func TestReconcileTrialWithInvalidParameters(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	s := runtime.NewScheme()
+	g.Expect(morphlingv1alpha1.AddToScheme(s)).To(gomega.Succeed())
+	g.Expect(corev1.AddToScheme(s)).To(gomega.Succeed())
+	r := &ReconcileTrial{Client: fake.NewFakeClientWithScheme(s), Scheme: s, Log: log}
+
+	instance := newFakeInstance()
+	instance.Spec.SamplingResult[0].Value = "1.5.cores"
+	_, err := r.reconcileTrial(instance)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(util.IsFailedTrial(instance)).To(gomega.BeTrue())
+	g.Expect(instance.Status.Conditions[len(instance.Status.Conditions)-1].Message).To(gomega.ContainSubstring("Invalid trial parameters"))
+}
+
+func TestApplyTrialParametersToSelectedContainers(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	instance := newFakeInstance()
+	instance.Spec.SamplingResult = []morphlingv1alpha1.ParameterAssignment{
+		{Name: "BATCH_SIZE", Value: "8", Category: morphlingv1alpha1.CategoryEnv, Containers: []string{"model"}},
+		{Name: "cpu", Value: "2", Category: morphlingv1alpha1.CategoryResource, Containers: []string{"model"}},
+		{Name: "WARMUP", Value: "true", Category: morphlingv1alpha1.CategoryEnv, InitContainers: []string{"download"}},
+		{Name: "LOG_LEVEL", Value: "debug", Category: morphlingv1alpha1.CategoryEnv},
+	}
+	podSpec := &corev1.PodSpec{
+		InitContainers: []corev1.Container{{Name: "download"}},
+		Containers:     []corev1.Container{{Name: "model"}, {Name: "envoy"}},
+	}
+	g.Expect(applyTrialParameters(instance, podSpec)).To(gomega.Succeed())
+	envNames := func(c corev1.Container) []string {
+		names := make([]string, 0)
+		for _, e := range c.Env {
+			names = append(names, e.Name)
+		}
+		return names
+	}
+	g.Expect(envNames(podSpec.Containers[0])).To(gomega.Equal([]string{"BATCH_SIZE", "LOG_LEVEL"}))
+	g.Expect(podSpec.Containers[0].Resources.Limits.Cpu().String()).To(gomega.Equal("2"))
+	g.Expect(envNames(podSpec.Containers[1])).To(gomega.Equal([]string{"LOG_LEVEL"}))
+	g.Expect(podSpec.Containers[1].Resources.Limits).To(gomega.BeEmpty())
+	g.Expect(envNames(podSpec.InitContainers[0])).To(gomega.Equal([]string{"WARMUP"}))
+
+	instance.Spec.SamplingResult[0].Containers = []string{"server"}
+	err := applyTrialParameters(instance, &corev1.PodSpec{Containers: []corev1.Container{{Name: "model"}}})
+	g.Expect(isParameterError(err)).To(gomega.BeTrue())
+}
+
+func TestAppendServiceEnvArgTemplates(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	instance := newFakeInstance()
+	instance.Spec.SamplingResult = []morphlingv1alpha1.ParameterAssignment{
+		{Name: "batch-size", Value: "8", Category: morphlingv1alpha1.CategoryArgs, ArgTemplate: "--batch-size={{value}}"},
+		{Name: "max-num-seqs", Value: "64", Category: morphlingv1alpha1.CategoryArgs, ArgTemplate: "--max-num-seqs {{value}}"},
+		{Name: "port", Value: "8080", Category: morphlingv1alpha1.CategoryArgs, ArgTemplate: "--port={{value}}"},
+		{Name: "dtype", Value: "half", Category: morphlingv1alpha1.CategoryArgs, ArgTemplate: "--dtype={{value}}"},
+		{Name: "verbose", Value: "--verbose", Category: morphlingv1alpha1.CategoryArgs},
+	}
+	c := &corev1.Container{
+		Command: []string{"server", "--port", "8500"},
+		Args:    []string{"--batch-size", "4", "--max-num-seqs=16", "--verbose"},
+	}
+	g.Expect(appendServiceEnv(instance, c, false)).To(gomega.Succeed())
+	g.Expect(c.Command).To(gomega.Equal([]string{"server", "--port=8080"}))
+	// Args without templates are always appended to trial containers
+	g.Expect(c.Args).To(gomega.Equal([]string{"--batch-size=8", "--max-num-seqs", "64", "--verbose", "--dtype=half", "--verbose"}))
+
+	// Applying the assignments to a workload replaces flags or skips args already present, so it changes nothing again
+	podSpec := &corev1.PodSpec{Containers: []corev1.Container{*c}}
+	g.Expect(ApplyParameterAssignments(instance.Spec.SamplingResult, podSpec, "")).To(gomega.Succeed())
+	g.Expect(podSpec.Containers[0].Command).To(gomega.Equal([]string{"server", "--port=8080"}))
+	g.Expect(podSpec.Containers[0].Args).To(gomega.Equal(c.Args))
+
+	instance.Spec.SamplingResult = []morphlingv1alpha1.ParameterAssignment{
+		{Name: "batch-size", Value: "8", Category: morphlingv1alpha1.CategoryArgs, ArgTemplate: "--batch-size"},
+	}
+	g.Expect(isParameterError(appendServiceEnv(instance, c, false))).To(gomega.BeTrue())
+}
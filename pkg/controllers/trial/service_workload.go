@@ -0,0 +1,503 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trial
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	"github.com/alibaba/morphling/pkg/controllers/util"
+)
+
+// workloadRecheckInterval is how often workloads not watched by the controller are assessed again
+const workloadRecheckInterval = 10 * time.Second
+
+// WorkloadState is the assessment of a deployed service workload
+type WorkloadState struct {
+	// Ready is true if the service is ready to be tested
+	Ready bool
+	// Failed is true if the service would never be ready
+	Failed bool
+	// Message tells the details of the state
+	Message string
+	// RecheckAfter is set if changes of the workload are not watched, or it fails upon a deadline
+	RecheckAfter time.Duration
+}
+
+// ServiceWorkloadProvider builds, assesses and cleans up the workload running the service under test of trials
+type ServiceWorkloadProvider interface {
+	// New returns the desired workload of the trial, with the trial parameters applied to its pods
+	New(t *morphlingv1alpha1.Trial) (runtime.Object, error)
+	// Empty returns an empty object of the workload kind, to read the deployed workload into
+	Empty(t *morphlingv1alpha1.Trial) (runtime.Object, error)
+	// Assess tells whether the deployed workload is ready to be tested or has failed
+	Assess(t *morphlingv1alpha1.Trial, obj runtime.Object) WorkloadState
+	// Cleanup deletes the deployed workload upon the completion of the trial
+	Cleanup(c client.Client, obj runtime.Object) error
+}
+
+var (
+	workloadProvidersLock sync.RWMutex
+	workloadProviders     = map[morphlingv1alpha1.ServiceWorkloadKind]ServiceWorkloadProvider{
+		morphlingv1alpha1.ServiceWorkloadDeployment:   &deploymentProvider{},
+		morphlingv1alpha1.ServiceWorkloadStatefulSet:  &statefulSetProvider{},
+		morphlingv1alpha1.ServiceWorkloadUnstructured: &unstructuredProvider{},
+	}
+)
+
+// RegisterServiceWorkloadProvider plugs in a provider of a workload kind, replacing the existing one of the kind
+func RegisterServiceWorkloadProvider(kind morphlingv1alpha1.ServiceWorkloadKind, provider ServiceWorkloadProvider) {
+	workloadProvidersLock.Lock()
+	defer workloadProvidersLock.Unlock()
+	workloadProviders[kind] = provider
+}
+
+// getServiceWorkloadProvider returns the provider of the service workload of the trial, Deployment by default
+func getServiceWorkloadProvider(t *morphlingv1alpha1.Trial) (ServiceWorkloadProvider, error) {
+	kind := morphlingv1alpha1.ServiceWorkloadDeployment
+	if t.Spec.ServiceWorkload != nil && t.Spec.ServiceWorkload.Kind != "" {
+		kind = t.Spec.ServiceWorkload.Kind
+	}
+	workloadProvidersLock.RLock()
+	defer workloadProvidersLock.RUnlock()
+	provider, ok := workloadProviders[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown service workload kind %s", kind)
+	}
+	return provider, nil
+}
+
+// desiredPodTemplate returns the service pod template of the trial, with the trial parameters applied
+func desiredPodTemplate(t *morphlingv1alpha1.Trial) (corev1.PodTemplateSpec, error) {
+	podTemplate := corev1.PodTemplateSpec{}
+	t.Spec.ServicePodTemplate.Template.DeepCopyInto(&podTemplate)
+	podTemplate.Labels = util.ServicePodLabels(t)
+	err := applyTrialParameters(t, &podTemplate.Spec)
+	return podTemplate, err
+}
+
+// serviceReplicas returns the replica count assigned to the trial, nil if replicas are not tuned
+func serviceReplicas(t *morphlingv1alpha1.Trial) (*int32, error) {
+	for _, a := range t.Spec.SamplingResult {
+		if a.Category != morphlingv1alpha1.CategoryReplicas {
+			continue
+		}
+		replicas, err := strconv.ParseInt(a.Value, 10, 32)
+		if err != nil || replicas < 1 {
+			return nil, &ParameterError{Name: a.Name, Value: a.Value, Reason: "replicas must be a positive integer"}
+		}
+		r := int32(replicas)
+		return &r, nil
+	}
+	return nil, nil
+}
+
+// applyTrialParameters embeds the trial parameters into the containers of the pod spec
+func applyTrialParameters(t *morphlingv1alpha1.Trial, podSpec *corev1.PodSpec) error {
+	if err := validateParameterContainers(t, podSpec); err != nil {
+		return err
+	}
+	for i := range podSpec.Containers {
+		if err := appendServiceEnv(t, &podSpec.Containers[i], false); err != nil {
+			return err
+		}
+	}
+	for i := range podSpec.InitContainers {
+		if err := appendServiceEnv(t, &podSpec.InitContainers[i], true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateParameterContainers checks that the containers receiving the parameters are in the pod
+func validateParameterContainers(t *morphlingv1alpha1.Trial, podSpec *corev1.PodSpec) error {
+	containers, initContainers := make([]string, 0), make([]string, 0)
+	for _, c := range podSpec.Containers {
+		containers = append(containers, c.Name)
+	}
+	for _, c := range podSpec.InitContainers {
+		initContainers = append(initContainers, c.Name)
+	}
+	for _, a := range t.Spec.SamplingResult {
+		for _, name := range a.Containers {
+			if !containsString(containers, name) {
+				return &ParameterError{Name: a.Name, Value: a.Value, Reason: fmt.Sprintf("container %s not found in service pods", name)}
+			}
+		}
+		for _, name := range a.InitContainers {
+			if !containsString(initContainers, name) {
+				return &ParameterError{Name: a.Name, Value: a.Value, Reason: fmt.Sprintf("init container %s not found in service pods", name)}
+			}
+		}
+	}
+	return nil
+}
+
+// deleteWorkload deletes the workload together with its pods
+func deleteWorkload(c client.Client, obj runtime.Object) error {
+	return c.Delete(context.TODO(), obj, client.PropagationPolicy(metav1.DeletePropagationForeground))
+}
+
+// progressDeadlineExceeded tells whether the workload has not become ready within the progress deadline of the trial
+func progressDeadlineExceeded(t *morphlingv1alpha1.Trial, obj runtime.Object) bool {
+	if t.Spec.ServiceProgressDeadline == nil {
+		return false
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return false
+	}
+	deadline := accessor.GetCreationTimestamp().Add(time.Duration(*t.Spec.ServiceProgressDeadline) * time.Second)
+	return time.Now().After(deadline)
+}
+
+// deploymentProvider runs the service as a Deployment, judged ready by the DeploymentAvailable condition
+type deploymentProvider struct{}
+
+func (p *deploymentProvider) New(t *morphlingv1alpha1.Trial) (runtime.Object, error) {
+	replicas, err := serviceReplicas(t)
+	if err != nil {
+		return nil, err
+	}
+	podTemplate, err := desiredPodTemplate(t)
+	if err != nil {
+		return nil, err
+	}
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        util.GetServiceDeploymentName(t),
+			Namespace:   t.GetNamespace(),
+			Labels:      util.ServiceDeploymentLabels(t),
+			Annotations: t.Annotations,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: util.ServicePodLabels(t)},
+			Template: podTemplate,
+		},
+	}
+	if t.Spec.ServiceProgressDeadline != nil {
+		deploy.Spec.ProgressDeadlineSeconds = t.Spec.ServiceProgressDeadline
+	}
+	return deploy, nil
+}
+
+func (p *deploymentProvider) Empty(t *morphlingv1alpha1.Trial) (runtime.Object, error) {
+	return &appsv1.Deployment{}, nil
+}
+
+func (p *deploymentProvider) Assess(t *morphlingv1alpha1.Trial, obj runtime.Object) WorkloadState {
+	deploy, ok := obj.(*appsv1.Deployment)
+	if !ok {
+		return WorkloadState{Failed: true, Message: fmt.Sprintf("unexpected workload %T", obj)}
+	}
+	if util.IsServiceDeplomentFail(deploy.Status.Conditions) {
+		return WorkloadState{Failed: true, Message: "Trial service pod failed"}
+	}
+	// The service is tested only when all replicas are available, rather than the minimum for the Available condition
+	replicas := int32(1)
+	if deploy.Spec.Replicas != nil {
+		replicas = *deploy.Spec.Replicas
+	}
+	if util.IsServiceDeplomentReady(deploy.Status.Conditions) && deploy.Status.ObservedGeneration >= deploy.Generation &&
+		deploy.Status.UpdatedReplicas >= replicas && deploy.Status.AvailableReplicas >= replicas {
+		return WorkloadState{Ready: true}
+	}
+	if replicas > 1 {
+		return WorkloadState{Message: fmt.Sprintf("Trial service pods pending, %d of %d available", deploy.Status.AvailableReplicas, replicas)}
+	}
+	return WorkloadState{Message: "Trial service pod pending"}
+}
+
+func (p *deploymentProvider) Cleanup(c client.Client, obj runtime.Object) error {
+	return deleteWorkload(c, obj)
+}
+
+// statefulSetProvider runs the service as a StatefulSet, judged ready when all replicas are ready at the latest
+// revision, and failed if not ready within the progress deadline
+type statefulSetProvider struct{}
+
+func (p *statefulSetProvider) New(t *morphlingv1alpha1.Trial) (runtime.Object, error) {
+	replicas, err := serviceReplicas(t)
+	if err != nil {
+		return nil, err
+	}
+	podTemplate, err := desiredPodTemplate(t)
+	if err != nil {
+		return nil, err
+	}
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        util.GetServiceDeploymentName(t),
+			Namespace:   t.GetNamespace(),
+			Labels:      util.ServiceDeploymentLabels(t),
+			Annotations: t.Annotations,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:            replicas,
+			Selector:            &metav1.LabelSelector{MatchLabels: util.ServicePodLabels(t)},
+			Template:            podTemplate,
+			ServiceName:         util.GetServiceName(t),
+			PodManagementPolicy: appsv1.ParallelPodManagement,
+		},
+	}, nil
+}
+
+func (p *statefulSetProvider) Empty(t *morphlingv1alpha1.Trial) (runtime.Object, error) {
+	return &appsv1.StatefulSet{}, nil
+}
+
+func (p *statefulSetProvider) Assess(t *morphlingv1alpha1.Trial, obj runtime.Object) WorkloadState {
+	sts, ok := obj.(*appsv1.StatefulSet)
+	if !ok {
+		return WorkloadState{Failed: true, Message: fmt.Sprintf("unexpected workload %T", obj)}
+	}
+	replicas := int32(1)
+	if sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
+	}
+	if sts.Status.ObservedGeneration >= sts.Generation && sts.Status.ReadyReplicas >= replicas &&
+		sts.Status.UpdatedReplicas >= replicas {
+		return WorkloadState{Ready: true}
+	}
+	if progressDeadlineExceeded(t, obj) {
+		return WorkloadState{Failed: true, Message: "Trial service pods are not ready within the progress deadline"}
+	}
+	return WorkloadState{
+		Message:      fmt.Sprintf("Trial service pods pending, %d of %d ready", sts.Status.ReadyReplicas, replicas),
+		RecheckAfter: workloadRecheckInterval,
+	}
+}
+
+func (p *statefulSetProvider) Cleanup(c client.Client, obj runtime.Object) error {
+	return deleteWorkload(c, obj)
+}
+
+// unstructuredProvider runs the service as an object of any kind built from the template of the service workload,
+// judged by the readiness and failure rules on its status
+type unstructuredProvider struct{}
+
+func (p *unstructuredProvider) New(t *morphlingv1alpha1.Trial) (runtime.Object, error) {
+	obj, err := p.template(t)
+	if err != nil {
+		return nil, err
+	}
+	obj.SetName(util.GetServiceDeploymentName(t))
+	obj.SetNamespace(t.GetNamespace())
+	objLabels := obj.GetLabels()
+	if objLabels == nil {
+		objLabels = map[string]string{}
+	}
+	for k, v := range util.ServiceDeploymentLabels(t) {
+		objLabels[k] = v
+	}
+	obj.SetLabels(objLabels)
+
+	spec := t.Spec.ServiceWorkload
+	replicas, err := serviceReplicas(t)
+	if err != nil {
+		return nil, err
+	}
+	if replicas != nil && spec.ReplicasPath != "" {
+		if err := unstructured.SetNestedField(obj.Object, int64(*replicas), strings.Split(spec.ReplicasPath, ".")...); err != nil {
+			return nil, err
+		}
+	}
+	if spec.PodSpecPath != "" {
+		// Pod spec inlined, e.g., the predictor of an InferenceService, whose pods take the labels of the workload
+		for k, v := range util.ServicePodLabels(t) {
+			objLabels[k] = v
+		}
+		obj.SetLabels(objLabels)
+		fields := strings.Split(spec.PodSpecPath, ".")
+		raw, found, err := unstructured.NestedMap(obj.Object, fields...)
+		if err != nil {
+			return nil, err
+		}
+		podSpec := t.Spec.ServicePodTemplate.Template.Spec.DeepCopy()
+		if found {
+			podSpec = &corev1.PodSpec{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, podSpec); err != nil {
+				return nil, err
+			}
+		}
+		if err := applyTrialParameters(t, podSpec); err != nil {
+			return nil, err
+		}
+		patched, err := runtime.DefaultUnstructuredConverter.ToUnstructured(podSpec)
+		if err != nil {
+			return nil, err
+		}
+		// Keep the fields of the inlined object beyond the pod spec
+		if raw == nil {
+			raw = map[string]interface{}{}
+		}
+		for k, v := range patched {
+			raw[k] = v
+		}
+		return obj, unstructured.SetNestedMap(obj.Object, raw, fields...)
+	}
+
+	path := spec.PodTemplatePath
+	if path == "" {
+		path = "spec.template"
+	}
+	fields := strings.Split(path, ".")
+	raw, found, err := unstructured.NestedMap(obj.Object, fields...)
+	if err != nil {
+		return nil, err
+	}
+	podTemplate, err := desiredPodTemplate(t)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		podTemplate = corev1.PodTemplateSpec{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &podTemplate); err != nil {
+			return nil, err
+		}
+		if podTemplate.Labels == nil {
+			podTemplate.Labels = map[string]string{}
+		}
+		for k, v := range util.ServicePodLabels(t) {
+			podTemplate.Labels[k] = v
+		}
+		if err := applyTrialParameters(t, &podTemplate.Spec); err != nil {
+			return nil, err
+		}
+	}
+	patched, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&podTemplate)
+	if err != nil {
+		return nil, err
+	}
+	return obj, unstructured.SetNestedMap(obj.Object, patched, fields...)
+}
+
+func (p *unstructuredProvider) Empty(t *morphlingv1alpha1.Trial) (runtime.Object, error) {
+	obj, err := p.template(t)
+	if err != nil {
+		return nil, err
+	}
+	empty := &unstructured.Unstructured{}
+	empty.SetGroupVersionKind(obj.GroupVersionKind())
+	return empty, nil
+}
+
+func (p *unstructuredProvider) Assess(t *morphlingv1alpha1.Trial, obj runtime.Object) WorkloadState {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return WorkloadState{Failed: true, Message: fmt.Sprintf("unexpected workload %T", obj)}
+	}
+	spec := t.Spec.ServiceWorkload
+	for _, rule := range spec.FailureRules {
+		if matchStatusRule(u, rule) {
+			return WorkloadState{Failed: true, Message: fmt.Sprintf("Trial service workload failed: %s", describeStatusRule(rule))}
+		}
+	}
+
+	readinessRules := spec.ReadinessRules
+	if len(readinessRules) == 0 {
+		readinessRules = []morphlingv1alpha1.WorkloadStatusRule{{ConditionType: "Ready"}}
+	}
+	for _, rule := range readinessRules {
+		if !matchStatusRule(u, rule) {
+			if progressDeadlineExceeded(t, obj) {
+				return WorkloadState{Failed: true, Message: "Trial service workload is not ready within the progress deadline"}
+			}
+			return WorkloadState{
+				Message:      fmt.Sprintf("Trial service workload pending, waiting for %s", describeStatusRule(rule)),
+				RecheckAfter: workloadRecheckInterval,
+			}
+		}
+	}
+	return WorkloadState{Ready: true}
+}
+
+func (p *unstructuredProvider) Cleanup(c client.Client, obj runtime.Object) error {
+	return deleteWorkload(c, obj)
+}
+
+// template decodes the object template of the service workload
+func (p *unstructuredProvider) template(t *morphlingv1alpha1.Trial) (*unstructured.Unstructured, error) {
+	spec := t.Spec.ServiceWorkload
+	if spec == nil || spec.Template == nil || len(spec.Template.Raw) == 0 {
+		return nil, fmt.Errorf("template is required for %s service workload", morphlingv1alpha1.ServiceWorkloadUnstructured)
+	}
+	obj := &unstructured.Unstructured{}
+	if err := json.Unmarshal(spec.Template.Raw, &obj.Object); err != nil {
+		return nil, fmt.Errorf("invalid service workload template: %v", err)
+	}
+	if obj.GetAPIVersion() == "" || obj.GetKind() == "" {
+		return nil, fmt.Errorf("apiVersion and kind are required in service workload template")
+	}
+	return obj, nil
+}
+
+// matchStatusRule checks a rule against the status of the workload
+func matchStatusRule(obj *unstructured.Unstructured, rule morphlingv1alpha1.WorkloadStatusRule) bool {
+	if rule.ConditionType != "" {
+		expected := rule.ConditionStatus
+		if expected == "" {
+			expected = string(corev1.ConditionTrue)
+		}
+		conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+		for _, c := range conditions {
+			condition, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if fmt.Sprint(condition["type"]) == rule.ConditionType {
+				return fmt.Sprint(condition["status"]) == expected
+			}
+		}
+		return false
+	}
+	if rule.FieldPath == "" {
+		return false
+	}
+	value, found, err := unstructured.NestedFieldNoCopy(obj.Object, strings.Split(rule.FieldPath, ".")...)
+	if err != nil || !found {
+		return false
+	}
+	return fmt.Sprint(value) == rule.Value
+}
+
+func describeStatusRule(rule morphlingv1alpha1.WorkloadStatusRule) string {
+	if rule.ConditionType != "" {
+		status := rule.ConditionStatus
+		if status == "" {
+			status = string(corev1.ConditionTrue)
+		}
+		return fmt.Sprintf("condition %s=%s", rule.ConditionType, status)
+	}
+	return fmt.Sprintf("%s=%s", rule.FieldPath, rule.Value)
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trial
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	"github.com/alibaba/morphling/pkg/controllers/util"
+)
+
+func TestUnstructuredServiceWorkload(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	instance := newFakeInstance()
+	instance.Spec.ServiceWorkload = &morphlingv1alpha1.ServiceWorkloadSpec{
+		Kind: morphlingv1alpha1.ServiceWorkloadUnstructured,
+		Template: &runtime.RawExtension{Raw: []byte(`{
+			"apiVersion": "serving.knative.dev/v1",
+			"kind": "Service",
+			"spec": {"template": {"spec": {"containers": [{"name": "model", "image": "model:v1"}]}}}
+		}`)},
+		FailureRules: []morphlingv1alpha1.WorkloadStatusRule{{ConditionType: "Ready", ConditionStatus: "False"}},
+	}
+	provider, err := getServiceWorkloadProvider(instance)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	obj, err := provider.New(instance)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	u := obj.(*unstructured.Unstructured)
+	g.Expect(u.GetName()).To(gomega.Equal(util.GetServiceDeploymentName(instance)))
+	g.Expect(u.GetNamespace()).To(gomega.Equal(namespace))
+	podLabels, _, _ := unstructured.NestedStringMap(u.Object, "spec", "template", "metadata", "labels")
+	g.Expect(podLabels).To(gomega.Equal(util.ServicePodLabels(instance)))
+	containers, _, _ := unstructured.NestedSlice(u.Object, "spec", "template", "spec", "containers")
+	g.Expect(containers).To(gomega.HaveLen(1))
+	cpu, _, _ := unstructured.NestedString(containers[0].(map[string]interface{}), "resources", "limits", "cpu")
+	g.Expect(cpu).To(gomega.Equal("1"))
+
+	empty, err := provider.Empty(instance)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(empty.GetObjectKind().GroupVersionKind().Kind).To(gomega.Equal("Service"))
+
+	g.Expect(provider.Assess(instance, u).Ready).To(gomega.BeFalse())
+	setCondition := func(status string) {
+		_ = unstructured.SetNestedSlice(u.Object, []interface{}{
+			map[string]interface{}{"type": "Ready", "status": status},
+		}, "status", "conditions")
+	}
+	setCondition("True")
+	g.Expect(provider.Assess(instance, u).Ready).To(gomega.BeTrue())
+	setCondition("False")
+	g.Expect(provider.Assess(instance, u).Failed).To(gomega.BeTrue())
+}
+
+func TestUnstructuredServiceWorkloadPodSpecPath(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	instance := newFakeInstance()
+	instance.Spec.ServiceWorkload = &morphlingv1alpha1.ServiceWorkloadSpec{
+		Kind: morphlingv1alpha1.ServiceWorkloadUnstructured,
+		Template: &runtime.RawExtension{Raw: []byte(`{
+			"apiVersion": "serving.kserve.io/v1beta1",
+			"kind": "InferenceService",
+			"metadata": {"labels": {"team": "ml"}},
+			"spec": {"predictor": {"logger": {"mode": "all"}, "containers": [{"name": "model", "image": "model:v1"}]}}
+		}`)},
+		PodSpecPath: "spec.predictor",
+	}
+	provider, err := getServiceWorkloadProvider(instance)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	obj, err := provider.New(instance)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	u := obj.(*unstructured.Unstructured)
+	g.Expect(u.GetLabels()).To(gomega.HaveKeyWithValue("team", "ml"))
+	containers, _, _ := unstructured.NestedSlice(u.Object, "spec", "predictor", "containers")
+	g.Expect(containers).To(gomega.HaveLen(1))
+	mode, _, _ := unstructured.NestedString(u.Object, "spec", "predictor", "logger", "mode")
+	g.Expect(mode).To(gomega.Equal("all"))
+
+	// The pods of the inlined pod spec are selected by the trial service
+	s := runtime.NewScheme()
+	g.Expect(morphlingv1alpha1.AddToScheme(s)).To(gomega.Succeed())
+	r := &ReconcileTrial{Scheme: s}
+	service, err := r.getDesiredService(instance)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(labels.SelectorFromSet(service.Spec.Selector).Matches(labels.Set(u.GetLabels()))).To(gomega.BeTrue())
+}
+
+func TestStatefulSetServiceWorkload(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	instance := newFakeInstance()
+	instance.Spec.ServiceWorkload = &morphlingv1alpha1.ServiceWorkloadSpec{Kind: morphlingv1alpha1.ServiceWorkloadStatefulSet}
+	provider, err := getServiceWorkloadProvider(instance)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	obj, err := provider.New(instance)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	sts := obj.(*appsv1.StatefulSet)
+	g.Expect(sts.Spec.ServiceName).To(gomega.Equal(util.GetServiceName(instance)))
+	g.Expect(sts.Spec.Template.Spec.Containers[0].Resources.Limits.Cpu().String()).To(gomega.Equal("1"))
+
+	state := provider.Assess(instance, sts)
+	g.Expect(state.Ready).To(gomega.BeFalse())
+	g.Expect(state.RecheckAfter).NotTo(gomega.BeZero())
+	sts.Status.ReadyReplicas = 1
+	sts.Status.UpdatedReplicas = 1
+	g.Expect(provider.Assess(instance, sts).Ready).To(gomega.BeTrue())
+
+	instance.Spec.ServiceWorkload.Kind = "Unknown"
+	_, err = getServiceWorkloadProvider(instance)
+	g.Expect(err).To(gomega.HaveOccurred())
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trial
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	"github.com/alibaba/morphling/pkg/controllers/trial/dbclient"
+	"github.com/alibaba/morphling/pkg/controllers/util"
+)
+
+// NewSimulatedReconciler returns a reconciler completing trials with the results of db, e.g., a synthetic objective
+// function, in place of deploying the service and running the client-side stress test job
+func NewSimulatedReconciler(c client.Client, scheme *runtime.Scheme, recorder record.EventRecorder, db dbclient.DBClient) *ReconcileTrial {
+	r := &ReconcileTrial{
+		Client:    c,
+		Scheme:    scheme,
+		DBClient:  db,
+		recorder:  recorder,
+		Log:       logf.Log.WithName(ControllerName),
+		simulated: true,
+	}
+	r.updateStatusHandler = r.updateStatus
+	return r
+}
+
+// reconcileSimulatedTrial completes the trial with the result of the db client, a trial without a result fails
+func (r *ReconcileTrial) reconcileSimulatedTrial(instance *morphlingv1alpha1.Trial) error {
+	if util.IsCompletedTrial(instance) {
+		return nil
+	}
+	now := metav1.Now()
+	instance.Status.CompletionTime = &now
+	reply, err := r.GetTrialResult(instance)
+	if err == nil {
+		instance.Status.TrialResult = reply
+		if !isTrialResultAvailable(instance) {
+			err = fmt.Errorf("objective metric %s is not observed", instance.Spec.Objective.ObjectiveMetricName)
+		}
+	}
+	if err != nil {
+		state := WorkloadState{Failed: true, Message: fmt.Sprintf("Simulated trial failed: %v", err)}
+		r.UpdateTrialStatusByServiceWorkload(instance, state, instance.Name)
+		return nil
+	}
+	if err := appendCostMetrics(instance, reply); err != nil {
+		log.Error(err, "Observe cost metrics error", "trial", instance.GetName())
+	}
+	util.MarkTrialStatusSucceeded(instance, corev1.ConditionTrue, "Simulated trial has completed")
+	return nil
+}
@@ -21,7 +21,6 @@ import (
 	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
 	"github.com/alibaba/morphling/pkg/controllers/consts"
 	"github.com/alibaba/morphling/pkg/controllers/util"
-	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -44,22 +43,27 @@ func (r *ReconcileTrial) UpdateTrialStatusByClientJob(instance *morphlingv1alpha
 	return nil
 }
 
-func (r *ReconcileTrial) UpdateTrialStatusByServiceDeployment(instance *morphlingv1alpha1.Trial, deployedDeployment *appsv1.Deployment) {
+func (r *ReconcileTrial) UpdateTrialStatusByServiceWorkload(instance *morphlingv1alpha1.Trial, state WorkloadState, name string) {
 	logger := log.WithValues("Trial", types.NamespacedName{Name: instance.GetName(), Namespace: instance.GetNamespace()})
 
-	ServiceDeploymentCondition := deployedDeployment.Status.Conditions
-	if util.IsServiceDeplomentFail(ServiceDeploymentCondition) {
-		message := "Trial service pod failed"
+	if state.Failed {
+		message := state.Message
+		if message == "" {
+			message = "Trial service pod failed"
+		}
 		objectiveMetricName := instance.Spec.Objective.ObjectiveMetricName
 		metric := morphlingv1alpha1.Metric{Name: objectiveMetricName, Value: "0.0"}
 		instance.Status.TrialResult = &morphlingv1alpha1.TrialResult{}
 		instance.Status.TrialResult.ObjectiveMetricsObserved = []morphlingv1alpha1.Metric{metric}
 		util.MarkTrialStatusFailed(instance, message)
-		logger.Info("Service deployment is failed", "name", deployedDeployment.GetName())
+		logger.Info("Service workload is failed", "name", name)
 	} else {
-		message := "Trial service pod pending"
+		message := state.Message
+		if message == "" {
+			message = "Trial service pod pending"
+		}
 		util.MarkTrialStatusPendingTrial(instance, message)
-		logger.Info("Service deployment is pending", "name", deployedDeployment.GetName())
+		logger.Info("Service workload is pending", "name", name)
 	}
 }
 
@@ -124,6 +128,9 @@ func (r *ReconcileTrial) updateTrialResultForSucceededTrial(instance *morphlingv
 		return err
 	}
 	if reply != nil {
+		if err := appendCostMetrics(instance, reply); err != nil {
+			log.Error(err, "Observe cost metrics error", "trial", instance.GetName())
+		}
 		instance.Status.TrialResult = reply
 	}
 	return nil
@@ -137,11 +144,7 @@ func (r *ReconcileTrial) updateTrialResultForFailedTrial(instance *morphlingv1al
 
 	instance.Status.TrialResult.TunableParameters = make([]morphlingv1alpha1.ParameterAssignment, 0)
 	for _, assignment := range instance.Spec.SamplingResult {
-		instance.Status.TrialResult.TunableParameters = append(instance.Status.TrialResult.TunableParameters, morphlingv1alpha1.ParameterAssignment{
-			Name:     assignment.Name,
-			Value:    assignment.Value,
-			Category: assignment.Category,
-		})
+		instance.Status.TrialResult.TunableParameters = append(instance.Status.TrialResult.TunableParameters, *assignment.DeepCopy())
 	}
 	instance.Status.TrialResult.ObjectiveMetricsObserved = append(instance.Status.TrialResult.ObjectiveMetricsObserved, morphlingv1alpha1.Metric{
 		Name:  instance.Spec.Objective.ObjectiveMetricName,
@@ -18,7 +18,9 @@ package trial
 
 import (
 	"context"
+	"fmt"
 	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	"github.com/alibaba/morphling/pkg/controllers/grpcconn"
 	"github.com/alibaba/morphling/pkg/controllers/trial/dbclient"
 	"github.com/go-logr/logr"
 	appsv1 "k8s.io/api/apps/v1"
@@ -26,6 +28,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -113,6 +116,15 @@ func addWatch(c controller.Controller) error {
 		log.Error(err, "Service Deployment watch error")
 		return err
 	}
+	// Watch for changes to service statefulset
+	err = c.Watch(&source.Kind{Type: &appsv1.StatefulSet{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &morphlingv1alpha1.Trial{},
+	})
+	if err != nil {
+		log.Error(err, "Service StatefulSet watch error")
+		return err
+	}
 	// Watch for changes to service
 	err = c.Watch(&source.Kind{Type: &corev1.Service{}}, &handler.EnqueueRequestForOwner{
 		IsController: true,
@@ -132,12 +144,16 @@ type ReconcileTrial struct {
 	recorder record.EventRecorder
 	dbclient.DBClient
 	updateStatusHandler updateStatusFunc
+	// simulated trials take their results from DBClient right away, without service workloads or client jobs
+	simulated bool
 }
 
 // +kubebuilder:rbac:groups=morphling.kubedl.io,resources=trials,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=morphling.kubedl.io,resources=trials/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps,resources=deployments/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=metrics.k8s.io,resources=pods,verbs=get;list
 
 // Reconcile reads that state of the cluster for a trial object and makes changes based on the state read
 func (r *ReconcileTrial) Reconcile(req ctrl.Request) (ctrl.Result, error) {
@@ -157,6 +173,7 @@ func (r *ReconcileTrial) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	}
 
 	instance := original.DeepCopy()
+	result := ctrl.Result{}
 	// If not created, create the trial
 	if !util.IsCreatedTrial(instance) {
 		if instance.Status.StartTime == nil {
@@ -167,8 +184,12 @@ func (r *ReconcileTrial) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		util.MarkTrialStatusCreatedTrial(instance, msg)
 	} else {
 		// Reconcile trial
-		err := r.reconcileTrial(instance)
-		if err != nil {
+		result, err = r.reconcileTrial(instance)
+		if retryAfter, ok := grpcconn.RetryAfter(err); ok {
+			// Retry later instead of blocking the worker
+			logger.Info("DB storage is unavailable", "error", err.Error(), "retryAfter", retryAfter)
+			result = ctrl.Result{RequeueAfter: retryAfter}
+		} else if err != nil {
 			logger.Error(err, "Reconcile trial error")
 			return reconcile.Result{}, err
 		}
@@ -181,72 +202,103 @@ func (r *ReconcileTrial) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 			return reconcile.Result{}, err
 		}
 	}
-	return ctrl.Result{}, nil
+	return result, nil
 }
 
 //reconcileTrial reconcile the trial with core functions
-func (r *ReconcileTrial) reconcileTrial(instance *morphlingv1alpha1.Trial) error {
+func (r *ReconcileTrial) reconcileTrial(instance *morphlingv1alpha1.Trial) (ctrl.Result, error) {
 	logger := log.WithValues("Trial", types.NamespacedName{Name: instance.GetName(), Namespace: instance.GetNamespace()})
 
+	if r.simulated {
+		return ctrl.Result{}, r.reconcileSimulatedTrial(instance)
+	}
+
 	// Get desired service, and reconcile it
 	service, err := r.getDesiredService(instance)
 	if err != nil {
 		logger.Error(err, "ML service get error")
-		return err
+		return ctrl.Result{}, err
 	}
-	// Get desired deployment
-	desiredDeploy, err := r.getDesiredDeploymentSpec(instance)
+	// Get desired service workload
+	provider, err := getServiceWorkloadProvider(instance)
 	if err != nil {
-		logger.Error(err, "Service deployment construction error")
-		return err
+		logger.Error(err, "Service workload provider error")
+		return ctrl.Result{}, err
+	}
+	desiredWorkload, err := r.getDesiredServiceWorkload(instance, provider)
+	if err != nil {
+		if !isParameterError(err) {
+			logger.Error(err, "Service workload construction error")
+			return ctrl.Result{}, err
+		}
+		// Invalid parameters never make a working service, fail the trial and clean up the service
+		if !util.IsCompletedTrial(instance) {
+			logger.Info("Trial parameters are invalid", "error", err.Error())
+			state := WorkloadState{Failed: true, Message: fmt.Sprintf("Invalid trial parameters: %v", err)}
+			r.UpdateTrialStatusByServiceWorkload(instance, state, util.GetServiceDeploymentName(instance))
+		}
+		return ctrl.Result{}, r.reconcileService(instance, service)
 	}
 	// Get desired client job
 	desiredJob, err := r.getDesiredJobSpec(instance)
 	if err != nil {
 		logger.Error(err, "Client-side job construction error")
-		return err
+		return ctrl.Result{}, err
 	}
 
 	// Reconcile the service
 	err = r.reconcileService(instance, service)
 	if err != nil {
 		logger.Error(err, "Reconcile ML service error")
-		return err
+		return ctrl.Result{}, err
 	}
-	// Reconcile the deployment
-	deployedDeployment, err := r.reconcileServiceDeployment(instance, desiredDeploy)
+	// Reconcile the workload
+	deployedWorkload, err := r.reconcileServiceWorkload(instance, provider, desiredWorkload)
 	if err != nil {
-		logger.Error(err, "Reconcile ML deployment error")
-		return err
+		logger.Error(err, "Reconcile ML workload error")
+		return ctrl.Result{}, err
 	}
 	// Check if the job need to be deleted
-	if deployedDeployment == nil {
+	if deployedWorkload == nil {
 		_, err := r.reconcileJob(instance, desiredJob)
 		if err != nil {
 			logger.Error(err, "Reconcile client-side job error")
-			return err
+			return ctrl.Result{}, err
 		}
-		return nil
+		return ctrl.Result{}, nil
 	}
 
-	deployedJob := &batchv1.Job{}
-	// Create client job
-	if util.IsServiceDeplomentReady(deployedDeployment.Status.Conditions) {
-		logger.Info("Service Pod is ready", "name", deployedDeployment.GetName())
-		deployedJob, err = r.reconcileJob(instance, desiredJob)
-		if err != nil || deployedJob == nil {
-			logger.Error(err, "Reconcile client-side job error")
-			return err
+	state := provider.Assess(instance, deployedWorkload)
+	name := desiredJob.GetName()
+	if accessor, err := meta.Accessor(deployedWorkload); err == nil {
+		name = accessor.GetName()
+	}
+	if !state.Ready {
+		r.UpdateTrialStatusByServiceWorkload(instance, state, name)
+		if state.Failed {
+			return ctrl.Result{}, nil
 		}
+		return ctrl.Result{RequeueAfter: state.RecheckAfter}, nil
+	}
+
+	// Create client job
+	logger.Info("Service Pod is ready", "name", name)
+	deployedJob, err := r.reconcileJob(instance, desiredJob)
+	if err != nil || deployedJob == nil {
+		logger.Error(err, "Reconcile client-side job error")
+		return ctrl.Result{}, err
 	}
 	// Update trial status (conditions and results)
-	if util.IsServiceDeplomentReady(deployedDeployment.Status.Conditions) {
-		if err = r.UpdateTrialStatusByClientJob(instance, deployedJob); err != nil {
-			logger.Error(err, "Update trial status by client-side job condition error")
-			return err
+	if err = r.UpdateTrialStatusByClientJob(instance, deployedJob); err != nil {
+		logger.Error(err, "Update trial status by client-side job condition error")
+		return ctrl.Result{}, err
+	}
+	// Sample the resource usage of the service while the client job runs, to observe the cost of the trial
+	if instance.Spec.CostModel != nil && !util.IsCompletedTrial(instance) {
+		if err = r.sampleResourceUsage(instance); err != nil {
+			logger.Info("Service resource usage is unavailable", "error", err.Error())
 		}
-	} else {
-		r.UpdateTrialStatusByServiceDeployment(instance, deployedDeployment)
+		return ctrl.Result{RequeueAfter: resourceUsageSampleInterval}, nil
 	}
-	return nil
+	return ctrl.Result{}, nil
 }
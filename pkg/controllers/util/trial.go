@@ -35,10 +35,21 @@ func GetStressTestJobName(t *morphlingv1alpha1.Trial) string {
 	return t.Name + "-" + "client-job"
 }
 
+// GetServicePorts returns the ports of the trial service, the default port if not specified
+func GetServicePorts(t *morphlingv1alpha1.Trial) []morphlingv1alpha1.ServicePort {
+	if t.Spec.Service != nil && len(t.Spec.Service.Ports) > 0 {
+		return t.Spec.Service.Ports
+	}
+	return []morphlingv1alpha1.ServicePort{{
+		Name: consts.DefaultServicePortName,
+		Port: consts.DefaultServicePort,
+	}}
+}
+
 func GetServiceEndpoint(t *morphlingv1alpha1.Trial) string {
 	return fmt.Sprintf("%s:%d",
 		GetServiceName(t),
-		consts.DefaultServicePort)
+		GetServicePorts(t)[0].Port)
 }
 
 func GetDBStorageEndpoint() string {
@@ -17,12 +17,15 @@ limitations under the License.
 package util
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
 	"github.com/alibaba/morphling/pkg/controllers/consts"
@@ -132,10 +135,41 @@ func MarkExperimentStatusSucceeded(exp *morphlingv1alpha1.ProfilingExperiment, m
 }
 
 func MarkExperimentStatusRunning(exp *morphlingv1alpha1.ProfilingExperiment, message string) {
+	for _, condType := range []morphlingv1alpha1.ProfilingConditionType{morphlingv1alpha1.ProfilingRestarting, morphlingv1alpha1.ProfilingSuspended} {
+		if currentCond := getConditionExperiment(exp, condType); currentCond != nil {
+			setConditionExperiment(exp, condType, v1.ConditionFalse, currentCond.Message)
+		}
+	}
 	setConditionExperiment(exp, morphlingv1alpha1.ProfilingRunning, v1.ConditionTrue, message)
 
 }
 
+// MarkExperimentStatusRestarting marks the experiment restarting, e.g., on resumption or spec changes, until it runs
+// again
+func MarkExperimentStatusRestarting(exp *morphlingv1alpha1.ProfilingExperiment, message string) {
+	markExperimentStatusPaused(exp, morphlingv1alpha1.ProfilingRestarting, message)
+}
+
+// MarkExperimentStatusSuspended marks the experiment suspended, until it is resumed
+func MarkExperimentStatusSuspended(exp *morphlingv1alpha1.ProfilingExperiment, message string) {
+	markExperimentStatusPaused(exp, morphlingv1alpha1.ProfilingSuspended, message)
+}
+
+func markExperimentStatusPaused(exp *morphlingv1alpha1.ProfilingExperiment, conditionType morphlingv1alpha1.ProfilingConditionType, message string) {
+	if currentCond := getConditionExperiment(exp, morphlingv1alpha1.ProfilingRunning); currentCond != nil {
+		setConditionExperiment(exp, morphlingv1alpha1.ProfilingRunning, v1.ConditionFalse, currentCond.Message)
+	}
+	setConditionExperiment(exp, conditionType, v1.ConditionTrue, message)
+}
+
+func IsRestartingExperiment(exp *morphlingv1alpha1.ProfilingExperiment) bool {
+	return hasConditionExperiment(exp, morphlingv1alpha1.ProfilingRestarting)
+}
+
+func IsSuspendedExperiment(exp *morphlingv1alpha1.ProfilingExperiment) bool {
+	return hasConditionExperiment(exp, morphlingv1alpha1.ProfilingSuspended)
+}
+
 func ServiceDeploymentLabels(instance *morphlingv1alpha1.Trial) map[string]string {
 	res := make(map[string]string)
 	for k, v := range instance.Labels {
@@ -246,6 +280,35 @@ func MarkTrialStatusFailed(trial *morphlingv1alpha1.Trial, message string) {
 	SetConditionTrial(trial, morphlingv1alpha1.TrialFailed, v1.ConditionTrue, message)
 }
 
+func MarkTrialStatusKilled(trial *morphlingv1alpha1.Trial, message string) {
+	currentCond := getConditionTrial(trial, morphlingv1alpha1.TrialRunning)
+	if currentCond != nil {
+		SetConditionTrial(trial, morphlingv1alpha1.TrialRunning, v1.ConditionFalse, currentCond.Message)
+	}
+	SetConditionTrial(trial, morphlingv1alpha1.TrialKilled, v1.ConditionTrue, message)
+}
+
+// KillTrial marks a trial which has not completed as killed and completed for the reason, and updates its status. The
+// trial controller then cleans up its service and client job.
+func KillTrial(c client.Client, trial *morphlingv1alpha1.Trial, reason, message string) error {
+	if IsCompletedTrial(trial) {
+		return fmt.Errorf("trial %s/%s has already completed", trial.Namespace, trial.Name)
+	}
+	now := metav1.Now()
+	MarkTrialStatusKilled(trial, message)
+	trial.Status.Conditions[len(trial.Status.Conditions)-1].Reason = reason
+	trial.Status.CompletionTime = &now
+	return c.Status().Update(context.TODO(), trial)
+}
+
+// GetTrialKilledReason returns the reason of the Killed condition of a trial, empty if not killed
+func GetTrialKilledReason(trial *morphlingv1alpha1.Trial) string {
+	if condition := getConditionTrial(trial, morphlingv1alpha1.TrialKilled); condition != nil && condition.Status == v1.ConditionTrue {
+		return condition.Reason
+	}
+	return ""
+}
+
 func MarkTrialStatusRunning(trial *morphlingv1alpha1.Trial, message string) {
 	SetConditionTrial(trial, morphlingv1alpha1.TrialRunning, v1.ConditionTrue, message)
 }
@@ -294,7 +357,7 @@ func IsServiceDeplomentFail(podConditions []appsv1.DeploymentCondition) bool {
 }
 
 func IsCompletedTrial(trial *morphlingv1alpha1.Trial) bool {
-	return IsSucceededTrial(trial) || IsFailedTrial(trial)
+	return IsSucceededTrial(trial) || IsFailedTrial(trial) || IsKilledTrial(trial)
 }
 
 func IsSucceededTrial(trial *morphlingv1alpha1.Trial) bool {
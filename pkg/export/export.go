@@ -0,0 +1,356 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	"github.com/alibaba/morphling/pkg/controllers/consts"
+	"github.com/alibaba/morphling/pkg/controllers/trial/dbclient"
+	"github.com/alibaba/morphling/pkg/controllers/util"
+)
+
+var log = logf.Log.WithName("export")
+
+// Format is the file format of exported trial records
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatJSONL   Format = "jsonl"
+	FormatParquet Format = "parquet"
+)
+
+// Prefixes of the flattened parameter and metric columns in csv and parquet
+const (
+	ParameterColumnPrefix = "param."
+	MetricColumnPrefix    = "metric."
+)
+
+// ParseFormat validates the format name
+func ParseFormat(format string) (Format, error) {
+	switch f := Format(format); f {
+	case FormatCSV, FormatJSONL, FormatParquet:
+		return f, nil
+	}
+	return "", fmt.Errorf("unsupported export format %q, should be one of %s, %s, %s", format, FormatCSV, FormatJSONL, FormatParquet)
+}
+
+// ContentType returns the MIME type of the format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv"
+	case FormatJSONL:
+		return "application/x-ndjson"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// Condition is a trial condition in the exported record
+type Condition struct {
+	Type               string `json:"type"`
+	Status             string `json:"status"`
+	Message            string `json:"message,omitempty"`
+	LastTransitionTime string `json:"lastTransitionTime,omitempty"`
+}
+
+// Record is a trial of an experiment, with its sampled parameters and observed metrics
+type Record struct {
+	Experiment      string            `json:"experiment"`
+	Namespace       string            `json:"namespace"`
+	Trial           string            `json:"trial"`
+	Status          string            `json:"status"`
+	StartTime       string            `json:"startTime,omitempty"`
+	CompletionTime  string            `json:"completionTime,omitempty"`
+	DurationSeconds *float64          `json:"durationSeconds,omitempty"`
+	Parameters      map[string]string `json:"parameters"`
+	Metrics         map[string]string `json:"metrics"`
+	Conditions      []Condition       `json:"conditions"`
+}
+
+// Collect gathers the records of all trials of the experiment. Metrics are taken from the trial status, and the
+// ones missing there, e.g., of trials whose status update was lost, are filled in from db-manager if db is not nil.
+func Collect(ctx context.Context, c client.Client, db dbclient.DBClient, namespace, experiment string) ([]Record, error) {
+	pe := &morphlingv1alpha1.ProfilingExperiment{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: experiment}, pe); err != nil {
+		return nil, err
+	}
+
+	trials := &morphlingv1alpha1.TrialList{}
+	if err := c.List(ctx, trials, client.InNamespace(namespace), client.MatchingLabels{consts.LabelExperimentName: experiment}); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(trials.Items, func(i, j int) bool {
+		return trials.Items[i].CreationTimestamp.Before(&trials.Items[j].CreationTimestamp) ||
+			(trials.Items[i].CreationTimestamp.Equal(&trials.Items[j].CreationTimestamp) && trials.Items[i].Name < trials.Items[j].Name)
+	})
+
+	records := make([]Record, 0, len(trials.Items))
+	for i := range trials.Items {
+		trial := &trials.Items[i]
+		record := NewRecord(experiment, trial)
+
+		// Only completed trials have results stored
+		if db != nil && util.IsCompletedTrial(trial) {
+			result, err := db.GetTrialResult(trial)
+			if err != nil {
+				// The db-manager is likely unreachable, do not wait on it for each of the remaining trials
+				log.Error(err, "Failed to get trial result from db-manager, export metrics in trial status only", "trial", trial.Name)
+				db = nil
+			} else if result != nil {
+				for _, metric := range result.ObjectiveMetricsObserved {
+					if _, ok := record.Metrics[metric.Name]; !ok {
+						record.Metrics[metric.Name] = metric.Value
+					}
+				}
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// NewRecord converts a trial into a record
+func NewRecord(experiment string, trial *morphlingv1alpha1.Trial) Record {
+	record := Record{
+		Experiment: experiment,
+		Namespace:  trial.Namespace,
+		Trial:      trial.Name,
+		Parameters: map[string]string{},
+		Metrics:    map[string]string{},
+		Conditions: make([]Condition, 0, len(trial.Status.Conditions)),
+	}
+	if lastCondition, err := util.GetLastConditionType(trial); err == nil {
+		record.Status = string(lastCondition)
+	}
+	if trial.Status.StartTime != nil {
+		record.StartTime = trial.Status.StartTime.UTC().Format(time.RFC3339)
+		if trial.Status.CompletionTime != nil {
+			duration := trial.Status.CompletionTime.Sub(trial.Status.StartTime.Time).Seconds()
+			record.DurationSeconds = &duration
+		}
+	}
+	if trial.Status.CompletionTime != nil {
+		record.CompletionTime = trial.Status.CompletionTime.UTC().Format(time.RFC3339)
+	}
+	for _, assignment := range trial.Spec.SamplingResult {
+		record.Parameters[assignment.Name] = assignment.Value
+	}
+	if trial.Status.TrialResult != nil {
+		for _, metric := range trial.Status.TrialResult.ObjectiveMetricsObserved {
+			record.Metrics[metric.Name] = metric.Value
+		}
+	}
+	for _, condition := range trial.Status.Conditions {
+		c := Condition{
+			Type:    string(condition.Type),
+			Status:  string(condition.Status),
+			Message: condition.Message,
+		}
+		if !condition.LastTransitionTime.IsZero() {
+			c.LastTransitionTime = condition.LastTransitionTime.UTC().Format(time.RFC3339)
+		}
+		record.Conditions = append(record.Conditions, c)
+	}
+	return record
+}
+
+// Write encodes the records in the format
+func Write(w io.Writer, format Format, records []Record) error {
+	switch format {
+	case FormatCSV:
+		return WriteCSV(w, records)
+	case FormatJSONL:
+		return WriteJSONL(w, records)
+	case FormatParquet:
+		return WriteParquet(w, records)
+	}
+	_, err := ParseFormat(string(format))
+	return err
+}
+
+// WriteJSONL writes a record per line, with all the conditions of the trials
+func WriteJSONL(w io.Writer, records []Record) error {
+	encoder := json.NewEncoder(w)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadJSONL reads the records written by WriteJSONL
+func ReadJSONL(r io.Reader) ([]Record, error) {
+	records := make([]Record, 0)
+	decoder := json.NewDecoder(r)
+	for {
+		var record Record
+		if err := decoder.Decode(&record); err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid record %d: %v", len(records)+1, err)
+		}
+		records = append(records, record)
+	}
+}
+
+// WriteCSV writes a header line and a line per record, parameters and metrics are flattened into prefixed columns
+func WriteCSV(w io.Writer, records []Record) error {
+	columns := flatten(records)
+	writer := csv.NewWriter(w)
+
+	header := make([]string, len(columns))
+	for i, col := range columns {
+		header[i] = col.name
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	line := make([]string, len(columns))
+	for row := range records {
+		for i, col := range columns {
+			line[i] = ""
+			if v := col.values[row]; v != nil {
+				line[i] = *v
+			}
+		}
+		if err := writer.Write(line); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// column is a flattened field of the records, a nil value means the field is absent in the record
+type column struct {
+	name   string
+	values []*string
+	// numeric is true if all present values are numbers
+	numeric bool
+}
+
+// flatten lays the records out column by column: the trial fields, the message of the last condition,
+// then the parameters and metrics sorted by name
+func flatten(records []Record) []*column {
+	fixed := []struct {
+		name  string
+		value func(r *Record) *string
+	}{
+		{"experiment", func(r *Record) *string { return &r.Experiment }},
+		{"namespace", func(r *Record) *string { return &r.Namespace }},
+		{"trial", func(r *Record) *string { return &r.Trial }},
+		{"status", func(r *Record) *string { return &r.Status }},
+		{"start_time", func(r *Record) *string { return optional(r.StartTime) }},
+		{"completion_time", func(r *Record) *string { return optional(r.CompletionTime) }},
+		{"duration_seconds", func(r *Record) *string {
+			if r.DurationSeconds == nil {
+				return nil
+			}
+			v := strconv.FormatFloat(*r.DurationSeconds, 'f', -1, 64)
+			return &v
+		}},
+		{"message", func(r *Record) *string {
+			if len(r.Conditions) == 0 {
+				return nil
+			}
+			return optional(r.Conditions[len(r.Conditions)-1].Message)
+		}},
+	}
+
+	columns := make([]*column, 0)
+	for _, f := range fixed {
+		col := &column{name: f.name, values: make([]*string, len(records))}
+		for i := range records {
+			col.values[i] = f.value(&records[i])
+		}
+		columns = append(columns, col)
+	}
+	columns = append(columns, mapColumns(records, ParameterColumnPrefix, func(r *Record) map[string]string { return r.Parameters })...)
+	columns = append(columns, mapColumns(records, MetricColumnPrefix, func(r *Record) map[string]string { return r.Metrics })...)
+
+	for _, col := range columns {
+		if col.name == "duration_seconds" || strings.HasPrefix(col.name, ParameterColumnPrefix) || strings.HasPrefix(col.name, MetricColumnPrefix) {
+			col.numeric = isNumeric(col.values)
+		}
+	}
+	return columns
+}
+
+func mapColumns(records []Record, prefix string, fields func(r *Record) map[string]string) []*column {
+	names := map[string]bool{}
+	for i := range records {
+		for name := range fields(&records[i]) {
+			names[name] = true
+		}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	columns := make([]*column, 0, len(sorted))
+	for _, name := range sorted {
+		col := &column{name: prefix + name, values: make([]*string, len(records))}
+		for i := range records {
+			if v, ok := fields(&records[i])[name]; ok {
+				v := v
+				col.values[i] = &v
+			}
+		}
+		columns = append(columns, col)
+	}
+	return columns
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func isNumeric(values []*string) bool {
+	present := false
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		if _, err := strconv.ParseFloat(*v, 64); err != nil {
+			return false
+		}
+		present = true
+	}
+	return present
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+)
+
+func newTestRecords() []Record {
+	start := metav1.NewTime(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC))
+	end := metav1.NewTime(start.Add(90 * time.Second))
+	succeeded := &morphlingv1alpha1.Trial{
+		ObjectMeta: metav1.ObjectMeta{Name: "exp-1", Namespace: "default"},
+		Spec: morphlingv1alpha1.TrialSpec{SamplingResult: []morphlingv1alpha1.ParameterAssignment{
+			{Name: "cpu", Value: "2"},
+			{Name: "model", Value: "resnet"},
+		}},
+		Status: morphlingv1alpha1.TrialStatus{
+			StartTime:      &start,
+			CompletionTime: &end,
+			TrialResult: &morphlingv1alpha1.TrialResult{ObjectiveMetricsObserved: []morphlingv1alpha1.Metric{
+				{Name: "qps", Value: "12.5"},
+			}},
+			Conditions: []morphlingv1alpha1.TrialCondition{
+				{Type: morphlingv1alpha1.TrialSucceeded, Status: "True", Message: "Trial has succeeded"},
+			},
+		},
+	}
+	pending := &morphlingv1alpha1.Trial{
+		ObjectMeta: metav1.ObjectMeta{Name: "exp-2", Namespace: "default"},
+		Spec: morphlingv1alpha1.TrialSpec{SamplingResult: []morphlingv1alpha1.ParameterAssignment{
+			{Name: "cpu", Value: "4"},
+			{Name: "model", Value: "bert"},
+		}},
+	}
+	return []Record{NewRecord("exp", succeeded), NewRecord("exp", pending)}
+}
+
+func TestWriteCSV(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	buf := &bytes.Buffer{}
+	g.Expect(WriteCSV(buf, newTestRecords())).To(gomega.Succeed())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	g.Expect(lines).To(gomega.Equal([]string{
+		"experiment,namespace,trial,status,start_time,completion_time,duration_seconds,message,param.cpu,param.model,metric.qps",
+		"exp,default,exp-1,Succeeded,2021-01-01T00:00:00Z,2021-01-01T00:01:30Z,90,Trial has succeeded,2,resnet,12.5",
+		"exp,default,exp-2,,,,,,4,bert,",
+	}))
+}
+
+func TestWriteJSONL(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	buf := &bytes.Buffer{}
+	g.Expect(WriteJSONL(buf, newTestRecords())).To(gomega.Succeed())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	g.Expect(lines).To(gomega.HaveLen(2))
+	record := Record{}
+	g.Expect(json.Unmarshal([]byte(lines[0]), &record)).To(gomega.Succeed())
+	g.Expect(record.Metrics).To(gomega.Equal(map[string]string{"qps": "12.5"}))
+	g.Expect(record.Conditions).To(gomega.HaveLen(1))
+	g.Expect(*record.DurationSeconds).To(gomega.Equal(90.0))
+}
+
+func TestReadJSONL(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	buf := &bytes.Buffer{}
+	g.Expect(WriteJSONL(buf, newTestRecords())).To(gomega.Succeed())
+
+	records, err := ReadJSONL(buf)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(records).To(gomega.Equal(newTestRecords()))
+
+	_, err = ReadJSONL(strings.NewReader("{}\n{"))
+	g.Expect(err).To(gomega.HaveOccurred())
+}
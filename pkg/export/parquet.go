@@ -0,0 +1,80 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// WriteParquet writes the flattened records as a parquet file. Every column is OPTIONAL, numeric parameters and metrics
+// are stored as doubles and the other columns as UTF8 strings.
+func WriteParquet(w io.Writer, records []Record) error {
+	columns := flatten(records)
+	schema := make([]string, 0, len(columns))
+	for _, col := range columns {
+		md, err := col.parquetMetadata()
+		if err != nil {
+			return err
+		}
+		schema = append(schema, md)
+	}
+
+	pw, err := writer.NewCSVWriterFromWriter(schema, w, 1)
+	if err != nil {
+		return err
+	}
+	for i := range records {
+		row := make([]interface{}, len(columns))
+		for j, col := range columns {
+			v := col.values[i]
+			if v == nil {
+				continue
+			}
+			if !col.numeric {
+				row[j] = *v
+				continue
+			}
+			f, err := strconv.ParseFloat(*v, 64)
+			if err != nil {
+				return fmt.Errorf("failed to write column %s of trial %s as a double: %v", col.name, records[i].Trial, err)
+			}
+			row[j] = f
+		}
+		if err := pw.Write(row); err != nil {
+			return err
+		}
+	}
+	return pw.WriteStop()
+}
+
+// parquetMetadata returns the schema of the column in the metadata format of the csv writer, e.g.,
+// name=param.cpu, type=DOUBLE, repetitiontype=OPTIONAL
+func (col *column) parquetMetadata() (string, error) {
+	// Fields of the metadata are separated by commas
+	if strings.ContainsAny(col.name, ",=") {
+		return "", fmt.Errorf("column name %q could not be written to parquet", col.name)
+	}
+	if col.numeric {
+		return fmt.Sprintf("name=%s, type=DOUBLE, repetitiontype=OPTIONAL", col.name), nil
+	}
+	return fmt.Sprintf("name=%s, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL", col.name), nil
+}
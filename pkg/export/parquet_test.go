@@ -0,0 +1,87 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/common"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+func TestWriteParquet(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	buf := &bytes.Buffer{}
+	g.Expect(WriteParquet(buf, newTestRecords())).To(gomega.Succeed())
+
+	file, err := buffer.NewBufferFile(buf.Bytes())
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	pr, err := reader.NewParquetColumnReader(file, 1)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	defer pr.ReadStop()
+
+	columns := make([]string, 0)
+	types := map[string]parquet.Type{}
+	// The reader renames the schema elements to go field names, the names in the file are kept as external names
+	for i, element := range pr.Footer.Schema[1:] {
+		name := pr.SchemaHandler.Infos[i+1].ExName
+		columns = append(columns, name)
+		types[name] = element.GetType()
+		g.Expect(element.GetRepetitionType()).To(gomega.Equal(parquet.FieldRepetitionType_OPTIONAL))
+	}
+	g.Expect(columns).To(gomega.Equal([]string{
+		"experiment", "namespace", "trial", "status", "start_time", "completion_time", "duration_seconds", "message",
+		"param.cpu", "param.model", "metric.qps",
+	}))
+	g.Expect(types["param.cpu"]).To(gomega.Equal(parquet.Type_DOUBLE))
+	g.Expect(types["param.model"]).To(gomega.Equal(parquet.Type_BYTE_ARRAY))
+
+	numRows := pr.GetNumRows()
+	g.Expect(numRows).To(gomega.Equal(int64(2)))
+	rows := []map[string]interface{}{{}, {}}
+	for _, name := range columns {
+		values, _, _, err := pr.ReadColumnByPath(common.PathToStr([]string{"parquet_go_root", name}), numRows)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(values).To(gomega.HaveLen(len(rows)))
+		for i := range rows {
+			rows[i][name] = values[i]
+		}
+	}
+	g.Expect(rows).To(gomega.Equal([]map[string]interface{}{
+		{
+			"experiment": "exp", "namespace": "default", "trial": "exp-1", "status": "Succeeded",
+			"start_time": "2021-01-01T00:00:00Z", "completion_time": "2021-01-01T00:01:30Z", "duration_seconds": 90.0,
+			"message": "Trial has succeeded", "param.cpu": 2.0, "param.model": "resnet", "metric.qps": 12.5,
+		},
+		{
+			"experiment": "exp", "namespace": "default", "trial": "exp-2", "status": "",
+			"start_time": nil, "completion_time": nil, "duration_seconds": nil,
+			"message": nil, "param.cpu": 4.0, "param.model": "bert", "metric.qps": nil,
+		},
+	}))
+}
+
+func TestWriteParquetInvalidColumnName(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	records := newTestRecords()
+	records[0].Metrics["qps,p99"] = "1"
+	g.Expect(WriteParquet(&bytes.Buffer{}, records)).NotTo(gomega.Succeed())
+}
@@ -103,3 +103,32 @@ func (mr *MockStorageBackendMockRecorder) GetTrialResult(request interface{}) *g
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTrialResult", reflect.TypeOf((*MockStorageBackend)(nil).GetTrialResult), request)
 }
+
+// SaveSession mocks base method
+func (m *MockStorageBackend) SaveSession(request *_go.SaveSessionRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSession", request)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveSession indicates an expected call of SaveSession
+func (mr *MockStorageBackendMockRecorder) SaveSession(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSession", reflect.TypeOf((*MockStorageBackend)(nil).SaveSession), request)
+}
+
+// GetSession mocks base method
+func (m *MockStorageBackend) GetSession(request *_go.GetSessionRequest) (*_go.GetSessionReply, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSession", request)
+	ret0, _ := ret[0].(*_go.GetSessionReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSession indicates an expected call of GetSession
+func (mr *MockStorageBackendMockRecorder) GetSession(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockStorageBackend)(nil).GetSession), request)
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package recording records the samplings and trial results of experiments into a file, and replays them to the
+// controllers, so that tests could reproduce real runs without the algorithm server or live workloads.
+package recording
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	grpcapi "github.com/alibaba/morphling/api/v1alpha1/grpc_proto/grpc_algorithm/go"
+	"github.com/alibaba/morphling/pkg/controllers/consts"
+	"github.com/alibaba/morphling/pkg/controllers/experiment/sampling_client"
+	"github.com/alibaba/morphling/pkg/controllers/trial/dbclient"
+)
+
+var log = logf.Log.WithName("recording")
+
+// Kind is the kind of a recorded entry
+type Kind string
+
+const (
+	// KindSampling is a call of Sampling.GetSamplings
+	KindSampling Kind = "sampling"
+	// KindResult is a call of DBClient.GetTrialResult
+	KindResult Kind = "result"
+)
+
+// Entry is a recorded call, written as a line of JSON
+type Entry struct {
+	Kind       Kind   `json:"kind"`
+	Namespace  string `json:"namespace"`
+	Experiment string `json:"experiment,omitempty"`
+	Trial      string `json:"trial,omitempty"`
+	// Request and Response are the protobuf JSON of the request to the algorithm server and its response of a
+	// sampling, absent if the sampling client does not talk to an algorithm server
+	Request     json.RawMessage                     `json:"request,omitempty"`
+	Response    json.RawMessage                     `json:"response,omitempty"`
+	Assignments []morphlingv1alpha1.TrialAssignment `json:"assignments,omitempty"`
+	Result      *morphlingv1alpha1.TrialResult      `json:"result,omitempty"`
+	// Error is the message of the error returned by the call
+	Error string `json:"error,omitempty"`
+}
+
+// Read reads the entries written by a recorder
+func Read(r io.Reader) ([]Entry, error) {
+	entries := make([]Entry, 0)
+	decoder := json.NewDecoder(r)
+	for {
+		var entry Entry
+		if err := decoder.Decode(&entry); err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid entry %d: %v", len(entries)+1, err)
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// Recorder writes the calls of the sampling and db clients it wraps as entries
+type Recorder struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewRecorder returns a recorder writing a line of JSON per entry to w
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{encoder: json.NewEncoder(w)}
+}
+
+func (r *Recorder) write(entry *Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.encoder.Encode(entry); err != nil {
+		log.Error(err, "Failed to record entry", "kind", entry.Kind, "namespace", entry.Namespace)
+	}
+}
+
+// Sampling returns the sampling client recording the calls of s. Requests and responses are recorded as well if s
+// is a client of the algorithm server.
+func (r *Recorder) Sampling(s sampling_client.Sampling) sampling_client.Sampling {
+	rs := &recordingSampling{Sampling: s, recorder: r}
+	if g, ok := s.(*sampling_client.General); ok {
+		g.Observe(rs.observe)
+	}
+	return rs
+}
+
+// DBClient returns the db client recording the calls of db
+func (r *Recorder) DBClient(db dbclient.DBClient) dbclient.DBClient {
+	return &recordingDBClient{DBClient: db, recorder: r}
+}
+
+type recordingSampling struct {
+	sampling_client.Sampling
+	recorder *Recorder
+	// mu serializes the calls, so that the observed request and response belong to the current one
+	mu       sync.Mutex
+	request  *grpcapi.SamplingRequest
+	response *grpcapi.SamplingResponse
+}
+
+func (s *recordingSampling) observe(instance *morphlingv1alpha1.ProfilingExperiment, request *grpcapi.SamplingRequest, response *grpcapi.SamplingResponse) {
+	s.request, s.response = request, response
+}
+
+func (s *recordingSampling) GetSamplings(requestNum int32, instance *morphlingv1alpha1.ProfilingExperiment, currentCount int32, trials []morphlingv1alpha1.Trial) ([]morphlingv1alpha1.TrialAssignment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.request, s.response = nil, nil
+
+	assignments, err := s.Sampling.GetSamplings(requestNum, instance, currentCount, trials)
+	entry := &Entry{
+		Kind:        KindSampling,
+		Namespace:   instance.Namespace,
+		Experiment:  instance.Name,
+		Assignments: assignments,
+	}
+	if s.request != nil {
+		entry.Request = marshalProto(s.request)
+	}
+	if s.response != nil {
+		entry.Response = marshalProto(s.response)
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	s.recorder.write(entry)
+	return assignments, err
+}
+
+type recordingDBClient struct {
+	dbclient.DBClient
+	recorder *Recorder
+}
+
+func (c *recordingDBClient) GetTrialResult(trial *morphlingv1alpha1.Trial) (*morphlingv1alpha1.TrialResult, error) {
+	result, err := c.DBClient.GetTrialResult(trial)
+	entry := &Entry{
+		Kind:       KindResult,
+		Namespace:  trial.Namespace,
+		Experiment: trial.Labels[consts.LabelExperimentName],
+		Trial:      trial.Name,
+		Result:     result,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	c.recorder.write(entry)
+	return result, err
+}
+
+func marshalProto(m proto.Message) json.RawMessage {
+	data, err := protojson.Marshal(m)
+	if err != nil {
+		log.Error(err, "Failed to marshal sampling")
+		return nil
+	}
+	return data
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recording
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	grpcapi "github.com/alibaba/morphling/api/v1alpha1/grpc_proto/grpc_algorithm/go"
+	"github.com/alibaba/morphling/pkg/controllers/experiment/sampling_client"
+	"github.com/alibaba/morphling/pkg/simulation"
+)
+
+// gridServer samples the feasible space of the first parameter in order
+type gridServer struct {
+	grpcapi.UnimplementedSuggestionServer
+	next int
+}
+
+func (s *gridServer) GetSuggestions(ctx context.Context, request *grpcapi.SamplingRequest) (*grpcapi.SamplingResponse, error) {
+	response := &grpcapi.SamplingResponse{}
+	space := request.Parameters[0].FeasibleSpace
+	for i := int32(0); i < request.RequiredSampling; i++ {
+		response.AssignmentsSet = append(response.AssignmentsSet, &grpcapi.ParameterAssignments{
+			KeyValues: []*grpcapi.KeyValue{{Key: request.Parameters[0].Name, Value: space[s.next%len(space)]}},
+		})
+		s.next++
+	}
+	return response, nil
+}
+
+func startServer(t *testing.T) (string, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := grpc.NewServer()
+	grpcapi.RegisterSuggestionServer(server, &gridServer{})
+	go server.Serve(listener)
+	return listener.Addr().String(), server.Stop
+}
+
+func newTestExperiment() *morphlingv1alpha1.ProfilingExperiment {
+	maxNumTrials := int32(6)
+	parallelism := int32(2)
+	return &morphlingv1alpha1.ProfilingExperiment{
+		ObjectMeta: metav1.ObjectMeta{Name: "rec", Namespace: "default"},
+		Spec: morphlingv1alpha1.ProfilingExperimentSpec{
+			Objective:    morphlingv1alpha1.ObjectiveSpec{Type: morphlingv1alpha1.ObjectiveTypeMinimize, ObjectiveMetricName: "latency"},
+			MaxNumTrials: &maxNumTrials,
+			Parallelism:  &parallelism,
+			TunableParameters: []morphlingv1alpha1.ParameterCategory{{
+				Category: morphlingv1alpha1.CategoryEnv,
+				Parameters: []morphlingv1alpha1.ParameterSpec{
+					{Name: "x", ParameterType: morphlingv1alpha1.ParameterTypeInt, FeasibleSpace: morphlingv1alpha1.FeasibleSpace{Min: "1", Max: "6", Step: "1"}},
+				},
+			}},
+		},
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, morphlingv1alpha1.AddToScheme(scheme))
+	endpoint, stop := startServer(t)
+	defer stop()
+
+	// Record a run
+	buf := &bytes.Buffer{}
+	recorder := NewRecorder(buf)
+	objective, err := simulation.NewFunctionObjective("sphere")
+	assert.NoError(t, err)
+	c := simulation.NewClient(scheme)
+	s := simulation.New(c, scheme, recorder.Sampling(sampling_client.NewForEndpoint(scheme, c, endpoint)), recorder.DBClient(objective))
+	recorded, recordedTrials, err := s.Run(newTestExperiment())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	entries, err := Read(buf)
+	assert.NoError(t, err)
+	samplings, results := 0, 0
+	for _, entry := range entries {
+		switch entry.Kind {
+		case KindSampling:
+			samplings++
+			assert.NotEmpty(t, entry.Request)
+			assert.NotEmpty(t, entry.Response)
+		case KindResult:
+			results++
+			assert.Equal(t, "rec", entry.Experiment)
+		}
+	}
+	assert.True(t, samplings >= 3)
+	assert.Equal(t, 6, results)
+
+	// Replay it without the algorithm server
+	replay := NewReplay(entries)
+	s = simulation.New(simulation.NewClient(scheme), scheme, replay.Sampling(), replay.DBClient())
+	replayed, replayedTrials, err := s.Run(newTestExperiment())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Empty(t, replay.Divergences())
+	assert.Equal(t, recorded.Status.CurrentOptimalTrial, replayed.Status.CurrentOptimalTrial)
+	assert.Len(t, replayedTrials, len(recordedTrials))
+	for i := range recordedTrials {
+		assert.Equal(t, recordedTrials[i].Name, replayedTrials[i].Name)
+		assert.Equal(t, recordedTrials[i].Spec.SamplingResult, replayedTrials[i].Spec.SamplingResult)
+		assert.Equal(t, recordedTrials[i].Status.TrialResult, replayedTrials[i].Status.TrialResult)
+	}
+
+	// A run of another experiment spec diverges
+	replay = NewReplay(entries)
+	s = simulation.New(simulation.NewClient(scheme), scheme, replay.Sampling(), replay.DBClient())
+	pe := newTestExperiment()
+	pe.Spec.Objective.Type = morphlingv1alpha1.ObjectiveTypeMaximize
+	_, _, _ = s.Run(pe)
+	assert.NotEmpty(t, replay.Divergences())
+}
+
+func TestReplayErrors(t *testing.T) {
+	replay := NewReplay([]Entry{
+		{Kind: KindSampling, Namespace: "default", Experiment: "rec", Error: "algorithm server unavailable"},
+		{Kind: KindResult, Namespace: "default", Trial: "rec-1", Error: "db unavailable"},
+		{Kind: KindResult, Namespace: "default", Trial: "rec-1", Result: &morphlingv1alpha1.TrialResult{
+			ObjectiveMetricsObserved: []morphlingv1alpha1.Metric{{Name: "latency", Value: "1"}},
+		}},
+	})
+	pe := newTestExperiment()
+	_, err := replay.Sampling().GetSamplings(1, pe, 0, nil)
+	assert.EqualError(t, err, "algorithm server unavailable")
+	_, err = replay.Sampling().GetSamplings(1, pe, 0, nil)
+	assert.Error(t, err)
+
+	db := replay.DBClient()
+	trial := &morphlingv1alpha1.Trial{ObjectMeta: metav1.ObjectMeta{Name: "rec-1", Namespace: "default"}}
+	_, err = db.GetTrialResult(trial)
+	assert.EqualError(t, err, "db unavailable")
+	// The last result is repeated
+	for i := 0; i < 2; i++ {
+		result, err := db.GetTrialResult(trial)
+		assert.NoError(t, err)
+		assert.Equal(t, "1", result.ObjectiveMetricsObserved[0].Value)
+	}
+	trial.Name = "rec-2"
+	_, err = db.GetTrialResult(trial)
+	assert.Error(t, err)
+	assert.Len(t, replay.Divergences(), 2)
+}
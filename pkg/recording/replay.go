@@ -0,0 +1,155 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recording
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	grpcapi "github.com/alibaba/morphling/api/v1alpha1/grpc_proto/grpc_algorithm/go"
+	"github.com/alibaba/morphling/pkg/controllers/experiment/sampling_client"
+	"github.com/alibaba/morphling/pkg/controllers/trial/dbclient"
+)
+
+// Replay feeds the controllers with recorded entries. Samplings of an experiment are replayed in the recorded order,
+// and results of a trial are replayed in the recorded order too, with the last one repeated once all are replayed.
+type Replay struct {
+	mu        sync.Mutex
+	samplings map[string][]Entry
+	results   map[string][]Entry
+	// samplingCalls and resultCalls are the numbers of replayed samplings of experiments and results of trials
+	samplingCalls map[string]int
+	resultCalls   map[string]int
+	// divergences are the differences of the replayed run from the recorded one
+	divergences []string
+}
+
+// NewReplay returns the replay of the entries
+func NewReplay(entries []Entry) *Replay {
+	p := &Replay{
+		samplings:     map[string][]Entry{},
+		results:       map[string][]Entry{},
+		samplingCalls: map[string]int{},
+		resultCalls:   map[string]int{},
+	}
+	for _, entry := range entries {
+		switch entry.Kind {
+		case KindSampling:
+			key := entry.Namespace + "/" + entry.Experiment
+			p.samplings[key] = append(p.samplings[key], entry)
+		case KindResult:
+			key := entry.Namespace + "/" + entry.Trial
+			p.results[key] = append(p.results[key], entry)
+		}
+	}
+	return p
+}
+
+// Divergences returns the calls of the replayed run different from the recorded ones, e.g., a sampling request with
+// other trial results, which should be none if the run is reproduced exactly
+func (p *Replay) Divergences() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.divergences...)
+}
+
+// Sampling returns the sampling client replaying the recorded samplings
+func (p *Replay) Sampling() sampling_client.Sampling {
+	return &replaySampling{replay: p}
+}
+
+// DBClient returns the db client replaying the recorded trial results
+func (p *Replay) DBClient() dbclient.DBClient {
+	return &replayDBClient{replay: p}
+}
+
+type replaySampling struct {
+	replay *Replay
+}
+
+func (s *replaySampling) GetSamplings(requestNum int32, instance *morphlingv1alpha1.ProfilingExperiment, currentCount int32, trials []morphlingv1alpha1.Trial) ([]morphlingv1alpha1.TrialAssignment, error) {
+	p := s.replay
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := instance.Namespace + "/" + instance.Name
+	call := p.samplingCalls[key]
+	if call >= len(p.samplings[key]) {
+		p.divergences = append(p.divergences, fmt.Sprintf("sampling %d of experiment %s is not recorded", call+1, key))
+		return nil, fmt.Errorf("sampling %d of experiment %s is not recorded", call+1, key)
+	}
+	p.samplingCalls[key]++
+	entry := p.samplings[key][call]
+
+	if entry.Request != nil {
+		recorded := &grpcapi.SamplingRequest{}
+		request, err := sampling_client.NewSamplingRequest(requestNum, instance, currentCount, trials)
+		if err == nil {
+			err = protojson.Unmarshal(entry.Request, recorded)
+		}
+		if err != nil || !proto.Equal(request, recorded) {
+			p.divergences = append(p.divergences, fmt.Sprintf("sampling %d of experiment %s: request differs from the recorded one", call+1, key))
+		}
+	} else if n := len(entry.Assignments); entry.Error == "" && n != int(requestNum) {
+		p.divergences = append(p.divergences, fmt.Sprintf("sampling %d of experiment %s: %d samplings requested, %d recorded", call+1, key, requestNum, n))
+	}
+	assignments := make([]morphlingv1alpha1.TrialAssignment, 0, len(entry.Assignments))
+	for i := range entry.Assignments {
+		assignments = append(assignments, *entry.Assignments[i].DeepCopy())
+	}
+	switch entry.Error {
+	case "":
+		return assignments, nil
+	case sampling_client.ErrSearchSpaceExhausted.Error():
+		// The last samplings are returned along with the exhaustion
+		return assignments, sampling_client.ErrSearchSpaceExhausted
+	default:
+		return nil, errors.New(entry.Error)
+	}
+}
+
+type replayDBClient struct {
+	replay *Replay
+}
+
+func (c *replayDBClient) GetTrialResult(trial *morphlingv1alpha1.Trial) (*morphlingv1alpha1.TrialResult, error) {
+	p := c.replay
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := trial.Namespace + "/" + trial.Name
+	recorded := p.results[key]
+	if len(recorded) == 0 {
+		p.divergences = append(p.divergences, fmt.Sprintf("result of trial %s is not recorded", key))
+		return nil, fmt.Errorf("result of trial %s is not recorded", key)
+	}
+	call := p.resultCalls[key]
+	if call >= len(recorded) {
+		call = len(recorded) - 1
+	}
+	p.resultCalls[key]++
+	entry := recorded[call]
+	if entry.Error != "" {
+		return nil, errors.New(entry.Error)
+	}
+	return entry.Result.DeepCopy(), nil
+}
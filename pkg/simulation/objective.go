@@ -0,0 +1,191 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	"github.com/alibaba/morphling/pkg/controllers/trial/dbclient"
+	"github.com/alibaba/morphling/pkg/export"
+)
+
+// Function is an analytic benchmark function of the parameter values ordered by parameter names, minimized at its optimum
+type Function func(x []float64) float64
+
+// Functions are the benchmark functions by name
+var Functions = map[string]Function{
+	"sphere":     sphere,
+	"rosenbrock": rosenbrock,
+	"rastrigin":  rastrigin,
+}
+
+// sphere is minimized to 0 at the origin
+func sphere(x []float64) float64 {
+	sum := 0.0
+	for _, v := range x {
+		sum += v * v
+	}
+	return sum
+}
+
+// rosenbrock is minimized to 0 at (1, ..., 1), at the bottom of a narrow curved valley
+func rosenbrock(x []float64) float64 {
+	sum := 0.0
+	for i := 0; i+1 < len(x); i++ {
+		sum += 100*math.Pow(x[i+1]-x[i]*x[i], 2) + math.Pow(1-x[i], 2)
+	}
+	return sum
+}
+
+// rastrigin is minimized to 0 at the origin, surrounded by regularly spaced local minima
+func rastrigin(x []float64) float64 {
+	sum := 10 * float64(len(x))
+	for _, v := range x {
+		sum += v*v - 10*math.Cos(2*math.Pi*v)
+	}
+	return sum
+}
+
+// FunctionObjective evaluates trials with a benchmark function of their parameters. The objective metric is the value
+// of the function for experiments minimizing it, or the negated value for experiments maximizing it, so that the
+// optimal trial is at the optimum of the function either way.
+type FunctionObjective struct {
+	fn Function
+}
+
+var _ dbclient.DBClient = &FunctionObjective{}
+
+// NewFunctionObjective returns the objective of the benchmark function of the name
+func NewFunctionObjective(name string) (*FunctionObjective, error) {
+	fn, ok := Functions[name]
+	if !ok {
+		names := make([]string, 0, len(Functions))
+		for n := range Functions {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown function %q, should be one of %s", name, strings.Join(names, ", "))
+	}
+	return &FunctionObjective{fn: fn}, nil
+}
+
+// GetTrialResult evaluates the function with the parameters of the trial, which should all be numbers or quantities
+func (o *FunctionObjective) GetTrialResult(trial *morphlingv1alpha1.Trial) (*morphlingv1alpha1.TrialResult, error) {
+	assignments := make([]morphlingv1alpha1.ParameterAssignment, len(trial.Spec.SamplingResult))
+	copy(assignments, trial.Spec.SamplingResult)
+	sort.Slice(assignments, func(i, j int) bool { return assignments[i].Name < assignments[j].Name })
+
+	x := make([]float64, 0, len(assignments))
+	for _, assignment := range assignments {
+		v, err := parseNumber(assignment.Value)
+		if err != nil {
+			return nil, fmt.Errorf("value %q of parameter %s is not a number", assignment.Value, assignment.Name)
+		}
+		x = append(x, v)
+	}
+	value := o.fn(x)
+	if trial.Spec.Objective.Type == morphlingv1alpha1.ObjectiveTypeMaximize {
+		value = -value
+	}
+	return newTrialResult(trial, map[string]string{
+		trial.Spec.Objective.ObjectiveMetricName: strconv.FormatFloat(value, 'g', -1, 64),
+	}), nil
+}
+
+// ReplayObjective evaluates trials with the metrics of the recorded trials of the same parameter values, e.g.,
+// exported by `morphlingctl export -format jsonl`. A trial is failed if its recorded trial failed or is not recorded.
+type ReplayObjective struct {
+	records map[string]export.Record
+}
+
+var _ dbclient.DBClient = &ReplayObjective{}
+
+// NewReplayObjective returns the objective replaying the records, the first one of the same parameter values is taken
+func NewReplayObjective(records []export.Record) *ReplayObjective {
+	o := &ReplayObjective{records: make(map[string]export.Record, len(records))}
+	for _, record := range records {
+		key := parametersKey(record.Parameters)
+		if _, ok := o.records[key]; !ok {
+			o.records[key] = record
+		}
+	}
+	return o
+}
+
+// GetTrialResult returns the metrics of the recorded trial
+func (o *ReplayObjective) GetTrialResult(trial *morphlingv1alpha1.Trial) (*morphlingv1alpha1.TrialResult, error) {
+	parameters := make(map[string]string, len(trial.Spec.SamplingResult))
+	for _, assignment := range trial.Spec.SamplingResult {
+		parameters[assignment.Name] = assignment.Value
+	}
+	record, ok := o.records[parametersKey(parameters)]
+	if !ok {
+		return nil, fmt.Errorf("no trial of parameters %s is recorded", parametersKey(parameters))
+	}
+	if record.Status == string(morphlingv1alpha1.TrialFailed) {
+		return nil, fmt.Errorf("recorded trial %s failed", record.Trial)
+	}
+	return newTrialResult(trial, record.Metrics), nil
+}
+
+func newTrialResult(trial *morphlingv1alpha1.Trial, metrics map[string]string) *morphlingv1alpha1.TrialResult {
+	result := &morphlingv1alpha1.TrialResult{
+		TunableParameters:        make([]morphlingv1alpha1.ParameterAssignment, 0, len(trial.Spec.SamplingResult)),
+		ObjectiveMetricsObserved: make([]morphlingv1alpha1.Metric, 0, len(metrics)),
+	}
+	for _, assignment := range trial.Spec.SamplingResult {
+		result.TunableParameters = append(result.TunableParameters, *assignment.DeepCopy())
+	}
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		result.ObjectiveMetricsObserved = append(result.ObjectiveMetricsObserved, morphlingv1alpha1.Metric{Name: name, Value: metrics[name]})
+	}
+	return result
+}
+
+// parametersKey formats the parameter values as name=value pairs ordered by names
+func parametersKey(parameters map[string]string) string {
+	pairs := make([]string, 0, len(parameters))
+	for name, value := range parameters {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// parseNumber parses a number or a resource quantity, e.g., 500m or 2Gi
+func parseNumber(value string) (float64, error) {
+	if v, err := strconv.ParseFloat(value, 64); err == nil {
+		return v, nil
+	}
+	q, err := resource.ParseQuantity(value)
+	if err != nil {
+		return 0, err
+	}
+	return float64(q.MilliValue()) / 1000, nil
+}
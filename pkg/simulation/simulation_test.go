@@ -0,0 +1,197 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulation
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	"github.com/alibaba/morphling/pkg/controllers/experiment/sampling_client"
+	"github.com/alibaba/morphling/pkg/controllers/util"
+	"github.com/alibaba/morphling/pkg/export"
+)
+
+// gridSampling samples the grid of the search space in order
+type gridSampling struct {
+	next int
+}
+
+func (g *gridSampling) GetSamplings(requestNum int32, instance *morphlingv1alpha1.ProfilingExperiment, currentCount int32, trials []morphlingv1alpha1.Trial) ([]morphlingv1alpha1.TrialAssignment, error) {
+	pars := instance.Spec.TunableParameters[0].Parameters
+	spaces := make([][]string, len(pars))
+	for i, par := range pars {
+		space, err := sampling_client.ConvertFeasibleSpace(par.FeasibleSpace, par.ParameterType)
+		if err != nil {
+			return nil, err
+		}
+		spaces[i] = space
+	}
+	res := make([]morphlingv1alpha1.TrialAssignment, 0, requestNum)
+	for n := int32(0); n < requestNum; n++ {
+		assignment := morphlingv1alpha1.TrialAssignment{Name: fmt.Sprintf("%s-%d", instance.Name, g.next)}
+		index := g.next
+		for i, par := range pars {
+			assignment.ParameterAssignments = append(assignment.ParameterAssignments, morphlingv1alpha1.ParameterAssignment{
+				Name:     par.Name,
+				Value:    spaces[i][index%len(spaces[i])],
+				Category: instance.Spec.TunableParameters[0].Category,
+			})
+			index /= len(spaces[i])
+		}
+		res = append(res, assignment)
+		g.next++
+	}
+	return res, nil
+}
+
+func newTestExperiment() *morphlingv1alpha1.ProfilingExperiment {
+	maxNumTrials := int32(25)
+	parallelism := int32(3)
+	return &morphlingv1alpha1.ProfilingExperiment{
+		ObjectMeta: metav1.ObjectMeta{Name: "sim", Namespace: "default"},
+		Spec: morphlingv1alpha1.ProfilingExperimentSpec{
+			Objective:    morphlingv1alpha1.ObjectiveSpec{Type: morphlingv1alpha1.ObjectiveTypeMinimize, ObjectiveMetricName: "latency"},
+			MaxNumTrials: &maxNumTrials,
+			Parallelism:  &parallelism,
+			TunableParameters: []morphlingv1alpha1.ParameterCategory{{
+				Category: morphlingv1alpha1.CategoryEnv,
+				Parameters: []morphlingv1alpha1.ParameterSpec{
+					{Name: "x", ParameterType: morphlingv1alpha1.ParameterTypeInt, FeasibleSpace: morphlingv1alpha1.FeasibleSpace{Min: "1", Max: "5", Step: "1"}},
+					{Name: "y", ParameterType: morphlingv1alpha1.ParameterTypeInt, FeasibleSpace: morphlingv1alpha1.FeasibleSpace{Min: "1", Max: "5", Step: "1"}},
+				},
+			}},
+		},
+	}
+}
+
+func newScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, morphlingv1alpha1.AddToScheme(scheme))
+	return scheme
+}
+
+func TestFunctions(t *testing.T) {
+	assert.Equal(t, float64(0), sphere([]float64{0, 0}))
+	assert.Equal(t, float64(5), sphere([]float64{1, -2}))
+	assert.Equal(t, float64(0), rosenbrock([]float64{1, 1, 1}))
+	assert.Equal(t, float64(101), rosenbrock([]float64{0, 1}))
+	assert.Equal(t, float64(0), rastrigin([]float64{0, 0}))
+	assert.InDelta(t, 2, rastrigin([]float64{1, -1}), 1e-9)
+}
+
+func TestFunctionObjective(t *testing.T) {
+	_, err := NewFunctionObjective("ackley")
+	assert.Error(t, err)
+
+	o, err := NewFunctionObjective("sphere")
+	assert.NoError(t, err)
+	trial := &morphlingv1alpha1.Trial{Spec: morphlingv1alpha1.TrialSpec{
+		Objective: morphlingv1alpha1.ObjectiveSpec{Type: morphlingv1alpha1.ObjectiveTypeMaximize, ObjectiveMetricName: "qps"},
+		SamplingResult: []morphlingv1alpha1.ParameterAssignment{
+			{Name: "cpu", Value: "1500m"},
+			{Name: "batch", Value: "2"},
+		},
+	}}
+	result, err := o.GetTrialResult(trial)
+	assert.NoError(t, err)
+	assert.Equal(t, []morphlingv1alpha1.Metric{{Name: "qps", Value: "-6.25"}}, result.ObjectiveMetricsObserved)
+	assert.Equal(t, trial.Spec.SamplingResult, result.TunableParameters)
+
+	trial.Spec.SamplingResult[1].Value = "fp16"
+	_, err = o.GetTrialResult(trial)
+	assert.Error(t, err)
+}
+
+func TestReplayObjective(t *testing.T) {
+	o := NewReplayObjective([]export.Record{
+		{Trial: "a", Status: "Succeeded", Parameters: map[string]string{"cpu": "1", "batch": "2"}, Metrics: map[string]string{"qps": "10", "p99": "0.2"}},
+		{Trial: "b", Status: "Succeeded", Parameters: map[string]string{"cpu": "1", "batch": "2"}, Metrics: map[string]string{"qps": "12"}},
+		{Trial: "c", Status: "Failed", Parameters: map[string]string{"cpu": "2", "batch": "2"}, Metrics: map[string]string{"qps": "0.0"}},
+	})
+	trial := &morphlingv1alpha1.Trial{Spec: morphlingv1alpha1.TrialSpec{
+		SamplingResult: []morphlingv1alpha1.ParameterAssignment{{Name: "batch", Value: "2"}, {Name: "cpu", Value: "1"}},
+	}}
+	result, err := o.GetTrialResult(trial)
+	assert.NoError(t, err)
+	assert.Equal(t, []morphlingv1alpha1.Metric{{Name: "p99", Value: "0.2"}, {Name: "qps", Value: "10"}}, result.ObjectiveMetricsObserved)
+
+	trial.Spec.SamplingResult[1].Value = "2"
+	_, err = o.GetTrialResult(trial)
+	assert.Error(t, err)
+
+	trial.Spec.SamplingResult[1].Value = "4"
+	_, err = o.GetTrialResult(trial)
+	assert.Error(t, err)
+}
+
+func TestSimulatorRun(t *testing.T) {
+	scheme := newScheme(t)
+	objective, err := NewFunctionObjective("sphere")
+	assert.NoError(t, err)
+	s := New(NewClient(scheme), scheme, &gridSampling{}, objective)
+
+	pe, trials, err := s.Run(newTestExperiment())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, util.IsSucceededExperiment(pe))
+	assert.Len(t, trials, 25)
+	for i := range trials {
+		assert.True(t, util.IsSucceededTrial(&trials[i]), trials[i].Name)
+	}
+	assert.Equal(t, []morphlingv1alpha1.Metric{{Name: "latency", Value: "2"}}, pe.Status.CurrentOptimalTrial.ObjectiveMetricsObserved)
+	assert.ElementsMatch(t, []morphlingv1alpha1.ParameterAssignment{
+		{Name: "x", Value: "1", Category: morphlingv1alpha1.CategoryEnv},
+		{Name: "y", Value: "1", Category: morphlingv1alpha1.CategoryEnv},
+	}, pe.Status.CurrentOptimalTrial.TunableParameters)
+}
+
+func TestSimulatorRunWithFailedTrials(t *testing.T) {
+	scheme := newScheme(t)
+	// Only the trials of x = 1 are recorded
+	records := make([]export.Record, 0)
+	for y := 1; y <= 5; y++ {
+		records = append(records, export.Record{
+			Status:     "Succeeded",
+			Parameters: map[string]string{"x": "1", "y": fmt.Sprint(y)},
+			Metrics:    map[string]string{"qps": fmt.Sprint(10 + y)},
+		})
+	}
+	s := New(NewClient(scheme), scheme, &gridSampling{}, NewReplayObjective(records))
+
+	pe := newTestExperiment()
+	pe.Spec.Objective = morphlingv1alpha1.ObjectiveSpec{Type: morphlingv1alpha1.ObjectiveTypeMaximize, ObjectiveMetricName: "qps"}
+	pe, trials, err := s.Run(pe)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, trials, 25)
+	assert.Equal(t, int32(5), pe.Status.TrialsSucceeded)
+	assert.Equal(t, int32(20), pe.Status.TrialsFailed)
+	assert.Equal(t, []morphlingv1alpha1.Metric{{Name: "qps", Value: "15"}}, pe.Status.CurrentOptimalTrial.ObjectiveMetricsObserved)
+
+	s.MaxRounds = 1
+	pe = newTestExperiment()
+	pe.Name = "sim-2"
+	_, _, err = s.Run(pe)
+	assert.Error(t, err)
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2021 The Alibaba Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulation
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	morphlingv1alpha1 "github.com/alibaba/morphling/api/v1alpha1"
+	"github.com/alibaba/morphling/pkg/controllers/consts"
+	"github.com/alibaba/morphling/pkg/controllers/experiment"
+	"github.com/alibaba/morphling/pkg/controllers/experiment/sampling_client"
+	"github.com/alibaba/morphling/pkg/controllers/trial"
+	"github.com/alibaba/morphling/pkg/controllers/trial/dbclient"
+	"github.com/alibaba/morphling/pkg/controllers/util"
+)
+
+// DefaultMaxRounds bounds the rounds of an experiment, each of which reconciles the experiment and its trials once
+const DefaultMaxRounds = 10000
+
+// Simulator runs experiments in memory with the experiment and trial reconcilers of the controllers, where trials
+// take their results from an objective instead of running the service and the client-side stress test job
+type Simulator struct {
+	client.Client
+	experiments *experiment.ProfilingExperimentReconciler
+	trials      *trial.ReconcileTrial
+	MaxRounds   int
+}
+
+// NewClient returns an in-memory client of the simulated objects
+func NewClient(scheme *runtime.Scheme) client.Client {
+	return fake.NewFakeClientWithScheme(scheme)
+}
+
+// New returns a simulator of the objects in c, sampling trials with the sampling client and evaluating them with the
+// objective. Events are discarded.
+func New(c client.Client, scheme *runtime.Scheme, sampling sampling_client.Sampling, objective dbclient.DBClient) *Simulator {
+	recorder := &record.FakeRecorder{}
+	return &Simulator{
+		Client:      c,
+		experiments: experiment.NewReconcilerForClient(c, scheme, recorder, sampling),
+		trials:      trial.NewSimulatedReconciler(c, scheme, recorder, objective),
+		MaxRounds:   DefaultMaxRounds,
+	}
+}
+
+// Run creates the experiment and reconciles it and its trials until the experiment completes. The completed
+// experiment is returned with its trials in the order they are created.
+func (s *Simulator) Run(pe *morphlingv1alpha1.ProfilingExperiment) (*morphlingv1alpha1.ProfilingExperiment, []morphlingv1alpha1.Trial, error) {
+	if err := s.Create(context.TODO(), pe.DeepCopy()); err != nil {
+		return nil, nil, err
+	}
+	key := types.NamespacedName{Namespace: pe.Namespace, Name: pe.Name}
+	// In-memory objects have no creation timestamps, trials are ordered by the rounds they are seen first
+	seen := map[string]int{}
+	for round := 0; round < s.MaxRounds; round++ {
+		if _, err := s.experiments.Reconcile(ctrl.Request{NamespacedName: key}); err != nil {
+			return nil, nil, err
+		}
+		current := &morphlingv1alpha1.ProfilingExperiment{}
+		if err := s.Get(context.TODO(), key, current); err != nil {
+			return nil, nil, err
+		}
+		trials, err := s.listTrials(current)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, t := range trials {
+			if _, ok := seen[t.Name]; !ok {
+				seen[t.Name] = round
+			}
+		}
+		sort.SliceStable(trials, func(i, j int) bool {
+			return seen[trials[i].Name] < seen[trials[j].Name] ||
+				(seen[trials[i].Name] == seen[trials[j].Name] && trials[i].Name < trials[j].Name)
+		})
+		if util.IsCompletedExperiment(current) && !util.HasRunningTrials(current) {
+			// Once more to settle the apply policy
+			if _, err := s.experiments.Reconcile(ctrl.Request{NamespacedName: key}); err != nil {
+				return nil, nil, err
+			}
+			if err := s.Get(context.TODO(), key, current); err != nil {
+				return nil, nil, err
+			}
+			return current, trials, nil
+		}
+		for i := range trials {
+			if util.IsCompletedTrial(&trials[i]) {
+				continue
+			}
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: trials[i].Namespace, Name: trials[i].Name}}
+			if _, err := s.trials.Reconcile(req); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return nil, nil, fmt.Errorf("experiment %s did not complete in %d rounds", key, s.MaxRounds)
+}
+
+func (s *Simulator) listTrials(pe *morphlingv1alpha1.ProfilingExperiment) ([]morphlingv1alpha1.Trial, error) {
+	trials := &morphlingv1alpha1.TrialList{}
+	if err := s.List(context.TODO(), trials, client.InNamespace(pe.Namespace), client.MatchingLabels{consts.LabelExperimentName: pe.Name}); err != nil {
+		return nil, err
+	}
+	return trials.Items, nil
+}
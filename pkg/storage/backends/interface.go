@@ -17,9 +17,14 @@ limitations under the License.
 package backends
 
 import (
+	"errors"
+
 	api_pb "github.com/alibaba/morphling/api/v1alpha1/grpc_proto/grpc_storage/go"
 )
 
+// ErrSessionNotFound is returned by GetSession if the session has not been saved
+var ErrSessionNotFound = errors.New("session not found")
+
 // StorageBackend provides a collection of abstract methods to
 // interact with different storage backends, write/read pod and job objects.
 type StorageBackend interface {
@@ -33,4 +38,8 @@ type StorageBackend interface {
 	SaveTrialResult(observationLog *api_pb.SaveResultRequest) error
 	// GetTrialResult retrieve a TrialResult from backend.
 	GetTrialResult(request *api_pb.GetResultRequest) (*api_pb.GetResultReply, error)
+	// SaveSession create or update the state of a session of the algorithm server.
+	SaveSession(request *api_pb.SaveSessionRequest) error
+	// GetSession retrieve the state of a session, or ErrSessionNotFound.
+	GetSession(request *api_pb.GetSessionRequest) (*api_pb.GetSessionReply, error)
 }
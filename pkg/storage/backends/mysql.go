@@ -41,12 +41,8 @@ func NewMysqlBackend(mockDB *gorm.DB) (*MysqlBackend, error) {
 	mysql := &MysqlBackend{initialized: 0}
 	mysql.db = mockDB
 	// Try create tables if they have not been created in database, or the storage service will not work.
-	if !mysql.db.HasTable(&TrialResult{}) {
-		klog.Infof("database has not table %s, try to create it", TrialResult{}.TableName())
-		err := mysql.db.CreateTable(&TrialResult{}).Error
-		if err != nil {
-			return nil, err
-		}
+	if err := mysql.createTables(); err != nil {
+		return nil, err
 	}
 	atomic.StoreInt32(&mysql.initialized, 1)
 	return mysql, nil
@@ -155,6 +151,23 @@ func (b *MysqlBackend) GetTrialResult(request *api_pb.GetResultRequest) (*api_pb
 	return reply, nil
 }
 
+func (b *MysqlBackend) SaveSession(request *api_pb.SaveSessionRequest) error {
+	klog.V(5).Infof("[mysql.SaveSession] session: %s, %d bytes", request.SessionId, len(request.State))
+	return b.db.Save(&SamplingSession{SessionID: request.SessionId, State: request.State}).Error
+}
+
+func (b *MysqlBackend) GetSession(request *api_pb.GetSessionRequest) (*api_pb.GetSessionReply, error) {
+	klog.V(5).Infof("[mysql.GetSession] session: %s", request.SessionId)
+	session := SamplingSession{}
+	if err := b.db.Where(&SamplingSession{SessionID: request.SessionId}).First(&session).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	return &api_pb.GetSessionReply{SessionId: session.SessionID, State: session.State}, nil
+}
+
 func (b *MysqlBackend) openMysqlConnection(dbDriver, dbSource string) (db *gorm.DB, err error) {
 	ticker := time.NewTicker(initInterval)
 	defer ticker.Stop()
@@ -188,11 +201,19 @@ func (b *MysqlBackend) init() error {
 	b.db.LogMode(logMode == "debug")
 
 	// Try create tables if they have not been created in database, or the storage service will not work.
-	if !b.db.HasTable(&TrialResult{}) {
-		klog.Infof("database has not table %s, try to create it", TrialResult{}.TableName())
-		err = b.db.CreateTable(&TrialResult{}).Error
-		if err != nil {
-			return err
+	return b.createTables()
+}
+
+func (b *MysqlBackend) createTables() error {
+	tables := []interface {
+		TableName() string
+	}{&TrialResult{}, &SamplingSession{}}
+	for _, table := range tables {
+		if !b.db.HasTable(table) {
+			klog.Infof("database has not table %s, try to create it", table.TableName())
+			if err := b.db.CreateTable(table).Error; err != nil {
+				return err
+			}
 		}
 	}
 	return nil
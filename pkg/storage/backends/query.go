@@ -49,3 +49,13 @@ func (tr *TrialResult) BeforeCreate(scope *gorm.Scope) error {
 func (tr *TrialResult) BeforeUpdate(scope *gorm.Scope) error {
 	return nil //scope.SetColumn("gmt_modified", time.Now().UTC())
 }
+
+// SamplingSession is the state of a session of the algorithm server, opaque to the storage
+type SamplingSession struct {
+	SessionID string `gorm:"type:varchar(255);column:session_id;primary_key" json:"session_id"`
+	State     []byte `gorm:"type:longblob;column:state" json:"state"`
+}
+
+func (s SamplingSession) TableName() string {
+	return "sampling_session_info"
+}